@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,13 +14,21 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 
+	"mangahub/internal/account"
 	"mangahub/internal/auth"
+	"mangahub/internal/bus"
 	"mangahub/internal/chat"
+	"mangahub/internal/covers"
+	"mangahub/internal/grpcserver"
 	"mangahub/internal/library"
 	"mangahub/internal/manga"
+	"mangahub/internal/notify"
 	"mangahub/internal/progress"
 	"mangahub/internal/reviews"
+	"mangahub/internal/scraper"
 	syncsrv "mangahub/internal/sync"
 	"mangahub/pkg/database"
 	"mangahub/pkg/utils"
@@ -45,15 +54,29 @@ func main() {
 	router.Static("/assets", filepath.Join(webRoot, "assets"))
 	router.StaticFile("/", filepath.Join(webRoot, "index.html"))
 
+	// --- Message bus (shared with other replicas when not in memory) ---
+	busCfg := utils.LoadBusConfig()
+	broker, err := bus.New(busCfg, db)
+	if err != nil {
+		log.Fatalf("bus: %v", err)
+	}
+	defer broker.Close()
+
+	listen := utils.LoadListenConfig()
+
 	// --- Sync hub (WS + TCP) ---
-	hub := syncsrv.NewHub()
-	router.GET("/ws", syncsrv.WSHandler(hub))
-	tcpSrv := syncsrv.NewServer(":7070", hub)
+	hub := syncsrv.NewHub(broker)
+	tcpSrv := syncsrv.NewServer(listen.TCPAddr, hub)
+
+	// --- UDP notify ---
+	notifyRegistry := notify.NewRegistry()
+	notifySrv := notify.NewServer(listen.UDPAddr, notifyRegistry, nil)
 
 	// --- Chat ---
-	chatHub := chat.NewHub(50)
-	router.GET("/ws/chat", chat.WSHandler(chatHub))
-	router.GET("/chat/history", chat.HistoryHandler(chatHub))
+	chatCfg := utils.LoadChatConfig()
+	chatHub := chat.NewHub(50, chat.NewRepo(db), broker)
+	chatHub.Filter = chat.NewFilter(chatCfg.BlockedWords, chatCfg.BlockLinks, chatCfg.FilterReject)
+	router.GET("/chat/rooms", chat.RoomsHandler(chatHub))
 
 	// --- Health/Ready/Debug ---
 	router.GET("/health", func(c *gin.Context) {
@@ -94,24 +117,52 @@ func main() {
 
 	// --- Manga (public) ---
 	mangaRepo := manga.NewRepo(db)
-	mangaHandler := manga.NewHandler(mangaRepo)
+	mangaSvc := manga.NewService(mangaRepo)
+	mangaHandler := manga.NewHandler(mangaSvc)
 	mangaHandler.RegisterRoutes(router.Group("/manga"))
 
+	// --- Covers mirrored by the scraper (public) ---
+	coversCfg := utils.LoadCoversConfig()
+	coversHandler := covers.NewHandler(covers.NewStore(coversCfg.Dir), covers.NewRepo(db))
+	coversHandler.RegisterRoutes(router.Group("/covers"))
+
 	// --- Reviews (public) ---
-	reviewRepo := reviews.NewRepo(db)
-	reviewHandler := reviews.NewHandler(reviewRepo)
+	reviewSvc := reviews.NewService(reviews.NewRepo(db))
+	reviewHandler := reviews.NewHandler(reviewSvc)
 	reviewHandler.RegisterPublicRoutes(router.Group(""))
 
 	// --- Auth (public) ---
 	authCfg := utils.LoadAuthConfig()
-	tokenSvc := auth.TokenService{
-		Secret:   []byte(authCfg.JWTSecret),
-		Issuer:   authCfg.JWTIssuer,
-		Duration: authCfg.JWTDuration,
+	tokenSvc, err := auth.NewTokenService(context.Background(), authCfg)
+	if err != nil {
+		log.Fatalf("auth config: %v", err)
 	}
 	authRepo := auth.NewRepo(db)
+	if n, err := authRepo.PromoteAdmins(context.Background(), authCfg.AdminEmails); err != nil {
+		log.Printf("promote admins: %v", err)
+	} else if n > 0 {
+		log.Printf("promoted %d account(s) to admin", n)
+	}
 	authHandler := auth.NewHandler(authRepo, tokenSvc)
+	// connections opened with revoked tokens go, as on account deletion
+	authHandler.Service.OnRevoke = []func(string){hub.RemoveUser, chatHub.RemoveUser}
 	authHandler.RegisterRoutes(router.Group("/auth"))
+	authHandler.RegisterWellKnown(router.Group("/.well-known"))
+	router.GET("/ws", syncsrv.WSHandler(hub, auth.UserFromRequest(tokenSvc, authRepo)))
+	chatIdentify := auth.ClaimsFromRequest(tokenSvc, authRepo)
+	router.GET("/ws/chat", chat.WSHandler(chatHub, chatIdentify, chatCfg.AllowAnonymous))
+	router.GET("/chat/history", chat.HistoryHandler(chatHub, chatIdentify))
+	router.GET("/chat/moderation", auth.AuthMiddleware(tokenSvc, authRepo), chat.ModerationLogHandler(chatHub))
+	router.POST("/chat/moderation", auth.AuthMiddleware(tokenSvc, authRepo), chat.ModerateHandler(chatHub))
+	router.GET("/chat/unread", auth.AuthMiddleware(tokenSvc, authRepo), chat.UnreadHandler(chatHub))
+	router.GET("/chat/conversations", auth.AuthMiddleware(tokenSvc, authRepo), chat.ConversationsHandler(chatHub))
+
+	bgCtx, stopBg := context.WithCancel(context.Background())
+	defer stopBg()
+	if tokenSvc.Keys != nil {
+		go tokenSvc.Keys.Maintain(bgCtx, authCfg.JWTRotateEvery)
+	}
+	go chatHub.Run(bgCtx, chatCfg.RoomIdle)
 
 	// --- Protected routes ---
 	protected := router.Group("/users")
@@ -126,14 +177,31 @@ func main() {
 		})
 	})
 
+	// --- Admin (protected, admin role) ---
+	admin := router.Group("/admin")
+	admin.Use(auth.AuthMiddleware(tokenSvc, authRepo), auth.RequireRole(authRepo, auth.RoleAdmin))
+	authHandler.RegisterAdminRoutes(admin)
+	scraper.NewHandler(scraper.NewRepo(db)).RegisterAdminRoutes(admin)
+	mangaHandler.RegisterAdminRoutes(admin)
+
+	// --- Account export/deletion (protected) ---
+	accountCfg := utils.LoadAccountConfig()
+	accountHandler := account.NewHandler(account.NewRepo(db), authRepo, accountCfg.DeletePolicy,
+		hub.RemoveUser,
+		chatHub.RemoveUser,
+		notifyRegistry.Remove,
+	)
+	accountHandler.RegisterRoutes(protected)
+
 	// --- Library (protected) ---
-	libRepo := library.NewRepo(db)
-	libHandler := library.NewHandler(libRepo, hub)
+	// the service publishes to the hub for REST and gRPC writes alike
+	libSvc := library.NewService(library.NewRepo(db), mangaRepo, hub)
+	libHandler := library.NewHandler(libSvc)
 	libHandler.RegisterRoutes(protected)
 
 	// --- Progress (protected) ---
-	progressRepo := progress.NewRepo(db)
-	progressHandler := progress.NewHandler(progressRepo)
+	progressSvc := progress.NewService(progress.NewRepo(db))
+	progressHandler := progress.NewHandler(progressSvc)
 	progressHandler.RegisterRoutes(protected)
 
 	// --- Reviews (protected) ---
@@ -161,13 +229,47 @@ func main() {
 		})
 	*/
 
+	// --- gRPC (optional, in-process so Subscribe and Chat share the hubs) ---
+	grpcCfg := utils.LoadGrpcConfig()
+	var grpcSrv *grpc.Server
+	var grpcListener net.Listener
+	var grpcHealth *grpcserver.Health
+	if grpcCfg.InProcess {
+		grpcListener, err = net.Listen("tcp", grpcCfg.Addr)
+		if err != nil {
+			log.Fatalf("grpc listen failed: %v", err)
+		}
+		opts := grpcserver.ServerOptions(grpcserver.Authenticator{
+			Tokens: tokenSvc,
+			Users:  authRepo,
+		})
+		grpcSrv = grpc.NewServer(append(opts, grpcserver.TransportOptions(grpcCfg)...)...)
+		grpcserver.NewServer(grpcserver.Deps{
+			Manga:    mangaSvc,
+			Library:  libSvc,
+			Reviews:  reviewSvc,
+			Progress: progressSvc,
+			Auth:     authHandler.Service,
+			Tokens:   tokenSvc,
+			Hub:      hub,
+			ChatHub:  chatHub,
+		}).RegisterServices(grpcSrv)
+
+		grpcHealth = grpcserver.NewHealth(db)
+		grpcHealth.RegisterService(grpcSrv)
+		go grpcHealth.Run(bgCtx, 10*time.Second)
+		if grpcCfg.Reflection {
+			reflection.Register(grpcSrv)
+		}
+	}
+
 	// --- HTTP server (single runner) ---
 	httpSrv := &http.Server{
-		Addr:    ":8080",
+		Addr:    listen.HTTPAddr,
 		Handler: router,
 	}
 
-	errCh := make(chan error, 2)
+	errCh := make(chan error, 4)
 	var wg stdsync.WaitGroup
 
 	wg.Add(1)
@@ -181,7 +283,26 @@ func main() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		log.Println("HTTP API server listening on :8080")
+		if err := notifySrv.Run(); err != nil {
+			errCh <- err
+		}
+	}()
+
+	if grpcSrv != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Printf("gRPC server listening on %s (in-process)", grpcCfg.Addr)
+			if err := grpcSrv.Serve(grpcListener); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		log.Printf("HTTP API server listening on %s", listen.HTTPAddr)
 		if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errCh <- err
 		}
@@ -207,6 +328,13 @@ func main() {
 	if err := tcpSrv.Close(); err != nil {
 		log.Printf("tcp shutdown error: %v", err)
 	}
+	if err := notifySrv.Close(); err != nil {
+		log.Printf("udp notify shutdown error: %v", err)
+	}
+	if grpcSrv != nil {
+		grpcHealth.Shutdown()
+		grpcserver.Stop(grpcSrv, grpcCfg.ShutdownTimeout)
+	}
 
 	wg.Wait()
 	log.Println("servers stopped")
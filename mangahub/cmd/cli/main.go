@@ -24,6 +24,8 @@ import (
 	"github.com/gorilla/websocket"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 
 	"mangahub/pkg/database"
 	"mangahub/pkg/grpc/mangapb"
@@ -93,13 +95,15 @@ func main() {
 	case "notify":
 		handleNotify(ctx, client, cfg, *baseURL, *tokenPath, sub, args[2:])
 	case "chat":
-		handleChat(ctx, client, cfg, *baseURL, sub, args[2:])
+		handleChat(ctx, client, cfg, *baseURL, *tokenPath, sub, args[2:])
 	case "grpc":
-		handleGrpc(cfg, sub, args[2:])
+		handleGrpc(cfg, *tokenPath, sub, args[2:])
 	case "server":
 		handleServer(ctx, client, *baseURL, sub, args[2:])
 	case "export":
 		handleExport(ctx, client, *baseURL, sub, args[2:])
+	case "admin":
+		handleAdmin(ctx, client, *baseURL, *tokenPath, sub, args[2:])
 	default:
 		printUsage()
 		os.Exit(1)
@@ -192,6 +196,35 @@ func handleAuth(ctx context.Context, client *http.Client, baseURL, tokenPath, su
 			log.Fatalf("status failed: %v", err)
 		}
 		printJSON(resp)
+	case "export":
+		fs := flag.NewFlagSet("auth export", flag.ExitOnError)
+		out := fs.String("out", "mangahub-export.json", "output path (.zip for a ZIP bundle)")
+		_ = fs.Parse(args)
+
+		token := mustToken(tokenPath)
+		format := "json"
+		if strings.HasSuffix(strings.ToLower(*out), ".zip") {
+			format = "zip"
+		}
+		if err := downloadFile(ctx, client, baseURL+"/users/me/export?format="+format, token, *out); err != nil {
+			log.Fatalf("export failed: %v", err)
+		}
+		fmt.Printf("✅ exported account data to %s\n", *out)
+	case "delete-account":
+		fs := flag.NewFlagSet("auth delete-account", flag.ExitOnError)
+		password := fs.String("password", "", "current password")
+		_ = fs.Parse(args)
+		if *password == "" {
+			log.Fatal("password is required")
+		}
+
+		token := mustToken(tokenPath)
+		var resp map[string]any
+		if err := doJSON(ctx, client, http.MethodDelete, baseURL+"/users/me", token, map[string]string{"password": *password}, &resp); err != nil {
+			log.Fatalf("delete-account failed: %v", err)
+		}
+		_ = clearToken(tokenPath)
+		printJSON(resp)
 	case "change-password":
 		fs := flag.NewFlagSet("auth change-password", flag.ExitOnError)
 		oldPassword := fs.String("old", "", "current password")
@@ -210,7 +243,7 @@ func handleAuth(ctx context.Context, client *http.Client, baseURL, tokenPath, su
 		}
 		printJSON(resp)
 	default:
-		log.Fatal("usage: mangahub auth <login|register|logout|status|change-password>")
+		log.Fatal("usage: mangahub auth <login|register|logout|status|change-password|export|delete-account>")
 	}
 }
 
@@ -223,6 +256,7 @@ func handleManga(ctx context.Context, client *http.Client, baseURL, sub string,
 		genres := fs.String("genres", "", "comma-separated genres")
 		limit := fs.Int("limit", 20, "page size")
 		offset := fs.Int("offset", 0, "offset")
+		delisted := fs.Bool("include-delisted", false, "also list titles no source returns any more")
 		_ = fs.Parse(args)
 
 		u, err := url.Parse(baseURL + "/manga")
@@ -239,6 +273,9 @@ func handleManga(ctx context.Context, client *http.Client, baseURL, sub string,
 		if *genres != "" {
 			qv.Set("genres", *genres)
 		}
+		if *delisted {
+			qv.Set("include_delisted", "true")
+		}
 		qv.Set("limit", fmt.Sprintf("%d", *limit))
 		qv.Set("offset", fmt.Sprintf("%d", *offset))
 		u.RawQuery = qv.Encode()
@@ -251,13 +288,18 @@ func handleManga(ctx context.Context, client *http.Client, baseURL, sub string,
 	case "show":
 		fs := flag.NewFlagSet("manga show", flag.ExitOnError)
 		id := fs.String("id", "", "manga id")
+		provenance := fs.Bool("provenance", false, "include the source of each field")
 		_ = fs.Parse(args)
 		if *id == "" {
 			log.Fatal("manga id is required")
 		}
 
+		endpoint := baseURL + "/manga/" + url.PathEscape(*id)
+		if *provenance {
+			endpoint += "?provenance=true"
+		}
 		var resp models.MangaDB
-		if err := doJSON(ctx, client, http.MethodGet, baseURL+"/manga/"+url.PathEscape(*id), "", nil, &resp); err != nil {
+		if err := doJSON(ctx, client, http.MethodGet, endpoint, "", nil, &resp); err != nil {
 			log.Fatalf("show failed: %v", err)
 		}
 		printJSON(resp)
@@ -567,14 +609,16 @@ func handleNotify(ctx context.Context, client *http.Client, cfg CLIConfig, baseU
 	}
 }
 
-func handleChat(ctx context.Context, client *http.Client, cfg CLIConfig, baseURL string, sub string, args []string) {
+func handleChat(ctx context.Context, client *http.Client, cfg CLIConfig, baseURL, tokenPath string, sub string, args []string) {
 	switch sub {
 	case "join":
 		fs := flag.NewFlagSet("chat join", flag.ExitOnError)
 		room := fs.String("room", "lobby", "room name")
-		name := fs.String("name", "guest", "display name")
 		wsURL := fs.String("ws", "", "WebSocket URL (defaults to /ws/chat on API host)")
 		_ = fs.Parse(args)
+		// without a token the server may still let us read, if it allows
+		// anonymous readers
+		token, _ := readToken(tokenPath)
 		endpoint := *wsURL
 		if endpoint == "" {
 			var err error
@@ -583,23 +627,21 @@ func handleChat(ctx context.Context, client *http.Client, cfg CLIConfig, baseURL
 				log.Fatalf("ws url: %v", err)
 			}
 		}
-		endpoint = addWSQuery(endpoint, map[string]string{
-			"room": *room,
-			"user": *name,
-		})
-		if err := runChatWebSocket(endpoint); err != nil {
+		endpoint = addWSQuery(endpoint, map[string]string{"room": *room})
+		if err := runChatWebSocket(endpoint, token); err != nil {
 			log.Fatalf("chat join failed: %v", err)
 		}
 	case "send":
 		fs := flag.NewFlagSet("chat send", flag.ExitOnError)
 		room := fs.String("room", "lobby", "room name")
-		name := fs.String("name", "guest", "display name")
 		text := fs.String("text", "", "message text")
+		spoiler := fs.Int("spoiler", 0, "tag as a spoiler up to this chapter (manga rooms only)")
 		wsURL := fs.String("ws", "", "WebSocket URL (defaults to /ws/chat on API host)")
 		_ = fs.Parse(args)
 		if strings.TrimSpace(*text) == "" {
 			log.Fatal("text is required")
 		}
+		token := mustToken(tokenPath)
 		endpoint := *wsURL
 		if endpoint == "" {
 			var err error
@@ -608,17 +650,74 @@ func handleChat(ctx context.Context, client *http.Client, cfg CLIConfig, baseURL
 				log.Fatalf("ws url: %v", err)
 			}
 		}
-		endpoint = addWSQuery(endpoint, map[string]string{
-			"room": *room,
-			"user": *name,
-		})
-		if err := sendChatWebSocket(endpoint, *text, *name); err != nil {
+		endpoint = addWSQuery(endpoint, map[string]string{"room": *room})
+		payload := map[string]any{"text": *text}
+		if *spoiler > 0 {
+			payload["spoiler_chapter"] = *spoiler
+		}
+		if err := sendChatWebSocket(endpoint, token, payload); err != nil {
 			log.Fatalf("chat send failed: %v", err)
 		}
 		fmt.Println("✅ message sent")
+	case "dm", "read":
+		fs := flag.NewFlagSet("chat "+sub, flag.ExitOnError)
+		to := fs.String("to", "", "recipient user id (dm)")
+		text := fs.String("text", "", "message text (dm)")
+		room := fs.String("room", "", "room or dm:<id>:<id> conversation (read)")
+		id := fs.Int64("id", 0, "last message read (read)")
+		via := fs.String("via", "lobby", "room to connect through")
+		wsURL := fs.String("ws", "", "WebSocket URL (defaults to /ws/chat on API host)")
+		_ = fs.Parse(args)
+		payload := map[string]any{"type": sub}
+		if sub == "dm" {
+			if strings.TrimSpace(*to) == "" || strings.TrimSpace(*text) == "" {
+				log.Fatal("to and text are required")
+			}
+			payload["user_id"] = *to
+			payload["text"] = *text
+		} else {
+			if *room == "" || *id <= 0 {
+				log.Fatal("room and id are required")
+			}
+			payload["room"] = *room
+			payload["id"] = *id
+		}
+		token := mustToken(tokenPath)
+		endpoint := *wsURL
+		if endpoint == "" {
+			var err error
+			endpoint, err = websocketURL(baseURL, "/ws/chat")
+			if err != nil {
+				log.Fatalf("ws url: %v", err)
+			}
+		}
+		endpoint = addWSQuery(endpoint, map[string]string{"room": *via})
+		if err := sendChatWebSocket(endpoint, token, payload); err != nil {
+			log.Fatalf("chat %s failed: %v", sub, err)
+		}
+		fmt.Println("✅ done")
+	case "unread":
+		token := mustToken(tokenPath)
+		var resp map[string]any
+		if err := doJSON(ctx, client, http.MethodGet, baseURL+"/chat/unread", token, nil, &resp); err != nil {
+			log.Fatalf("chat unread failed: %v", err)
+		}
+		printJSON(resp)
+	case "conversations":
+		fs := flag.NewFlagSet("chat conversations", flag.ExitOnError)
+		limit := fs.Int("limit", 20, "page size")
+		_ = fs.Parse(args)
+		token := mustToken(tokenPath)
+		var resp []map[string]any
+		if err := doJSON(ctx, client, http.MethodGet, fmt.Sprintf("%s/chat/conversations?limit=%d", baseURL, *limit), token, nil, &resp); err != nil {
+			log.Fatalf("chat conversations failed: %v", err)
+		}
+		printJSON(resp)
 	case "history":
 		fs := flag.NewFlagSet("chat history", flag.ExitOnError)
 		room := fs.String("room", "lobby", "room name")
+		before := fs.Int64("before", 0, "only messages older than this id")
+		limit := fs.Int("limit", 50, "page size")
 		_ = fs.Parse(args)
 		u, err := url.Parse(baseURL + "/chat/history")
 		if err != nil {
@@ -626,25 +725,101 @@ func handleChat(ctx context.Context, client *http.Client, cfg CLIConfig, baseURL
 		}
 		qv := u.Query()
 		qv.Set("room", *room)
+		qv.Set("limit", strconv.Itoa(*limit))
+		if *before > 0 {
+			qv.Set("before", strconv.FormatInt(*before, 10))
+		}
 		u.RawQuery = qv.Encode()
+		// signed in, spoilers we have caught up on are shown
+		token, _ := readToken(tokenPath)
 		var resp []map[string]any
-		if err := doJSON(ctx, client, http.MethodGet, u.String(), "", nil, &resp); err != nil {
+		if err := doJSON(ctx, client, http.MethodGet, u.String(), token, nil, &resp); err != nil {
 			log.Fatalf("chat history failed: %v", err)
 		}
 		printJSON(resp)
+	case "rooms":
+		fs := flag.NewFlagSet("chat rooms", flag.ExitOnError)
+		mangaID := fs.String("manga-id", "", "only the room of this manga")
+		limit := fs.Int("limit", 20, "page size")
+		offset := fs.Int("offset", 0, "offset")
+		_ = fs.Parse(args)
+		u, err := url.Parse(baseURL + "/chat/rooms")
+		if err != nil {
+			log.Fatalf("invalid base url: %v", err)
+		}
+		qv := u.Query()
+		if *mangaID != "" {
+			qv.Set("manga_id", *mangaID)
+		}
+		qv.Set("limit", strconv.Itoa(*limit))
+		qv.Set("offset", strconv.Itoa(*offset))
+		u.RawQuery = qv.Encode()
+		var resp map[string]any
+		if err := doJSON(ctx, client, http.MethodGet, u.String(), "", nil, &resp); err != nil {
+			log.Fatalf("chat rooms failed: %v", err)
+		}
+		printJSON(resp)
+	case "mod":
+		fs := flag.NewFlagSet("chat mod", flag.ExitOnError)
+		room := fs.String("room", "lobby", "room name")
+		action := fs.String("action", "", "mute|unmute|kick|ban|unban|slow_mode|add_moderator|remove_moderator")
+		userID := fs.String("user-id", "", "target user id")
+		minutes := fs.Int("minutes", 0, "mute/ban length (0 = indefinite)")
+		seconds := fs.Int("seconds", 0, "slow mode interval (0 = off)")
+		reason := fs.String("reason", "", "reason shown in the room")
+		_ = fs.Parse(args)
+		if *action == "" {
+			log.Fatal("action is required")
+		}
+		token := mustToken(tokenPath)
+		payload := map[string]any{
+			"room":    *room,
+			"type":    *action,
+			"user_id": *userID,
+			"minutes": *minutes,
+			"seconds": *seconds,
+			"reason":  *reason,
+		}
+		var resp map[string]any
+		if err := doJSON(ctx, client, http.MethodPost, baseURL+"/chat/moderation", token, payload, &resp); err != nil {
+			log.Fatalf("chat mod failed: %v", err)
+		}
+		fmt.Println("✅ done")
+	case "modlog":
+		fs := flag.NewFlagSet("chat modlog", flag.ExitOnError)
+		room := fs.String("room", "lobby", "room name")
+		limit := fs.Int("limit", 50, "entries")
+		_ = fs.Parse(args)
+		token := mustToken(tokenPath)
+		u := fmt.Sprintf("%s/chat/moderation?room=%s&limit=%d", baseURL, url.QueryEscape(*room), *limit)
+		var resp []map[string]any
+		if err := doJSON(ctx, client, http.MethodGet, u, token, nil, &resp); err != nil {
+			log.Fatalf("chat modlog failed: %v", err)
+		}
+		printJSON(resp)
 	default:
-		log.Fatal("usage: mangahub chat <join|send|history>")
+		log.Fatal("usage: mangahub chat <join|send|dm|read|unread|conversations|history|rooms|mod|modlog>")
 	}
 }
 
-func handleGrpc(cfg CLIConfig, sub string, args []string) {
+func handleGrpc(cfg CLIConfig, tokenPath, sub string, args []string) {
 	switch sub {
 	case "manga":
 		handleGrpcManga(cfg, args)
 	case "progress":
-		handleGrpcProgress(cfg, args)
+		handleGrpcProgress(cfg, tokenPath, args)
+	case "sync":
+		handleGrpcSync(cfg, tokenPath, args)
+	case "auth":
+		handleGrpcAuth(cfg, tokenPath, args)
+	case "reviews":
+		handleGrpcReviews(cfg, args)
+	case "chat":
+		handleGrpcChat(cfg, tokenPath, args)
+	case "health":
+		handleGrpcHealth(cfg, args)
 	default:
-		log.Fatal("usage: mangahub grpc <manga|progress>")
+		log.Fatal("usage: mangahub grpc <manga|progress|sync|auth|reviews|chat|health>")
 	}
 }
 
@@ -715,7 +890,7 @@ func handleGrpcManga(cfg CLIConfig, args []string) {
 	}
 }
 
-func handleGrpcProgress(cfg CLIConfig, args []string) {
+func handleGrpcProgress(cfg CLIConfig, tokenPath string, args []string) {
 	if len(args) == 0 {
 		log.Fatal("usage: mangahub grpc progress <update>")
 	}
@@ -726,14 +901,14 @@ func handleGrpcProgress(cfg CLIConfig, args []string) {
 	case "update":
 		fs := flag.NewFlagSet("grpc progress update", flag.ExitOnError)
 		addr := fs.String("addr", cfg.GRPCAddr, "gRPC server address")
-		userID := fs.String("user-id", "", "user id")
+		userID := fs.String("user-id", "", "user id (defaults to the logged-in user)")
 		mangaID := fs.String("manga-id", "", "manga id")
 		chapter := fs.Int("chapter", 0, "current chapter")
 		status := fs.String("status", "reading", "status")
 		_ = fs.Parse(rest)
 
-		if *userID == "" || *mangaID == "" {
-			log.Fatal("user-id and manga-id are required")
+		if *mangaID == "" {
+			log.Fatal("manga-id is required")
 		}
 
 		conn, err := newGrpcConn(*addr)
@@ -743,7 +918,7 @@ func handleGrpcProgress(cfg CLIConfig, args []string) {
 		defer conn.Close()
 
 		client := mangapb.NewProgressServiceClient(conn)
-		resp, err := client.UpsertProgress(context.Background(), &mangapb.UpsertProgressRequest{
+		resp, err := client.UpsertProgress(grpcAuthContext(tokenPath), &mangapb.UpsertProgressRequest{
 			UserId:         *userID,
 			MangaId:        *mangaID,
 			CurrentChapter: int32(*chapter),
@@ -758,6 +933,188 @@ func handleGrpcProgress(cfg CLIConfig, args []string) {
 	}
 }
 
+func handleGrpcSync(cfg CLIConfig, tokenPath string, args []string) {
+	if len(args) == 0 || args[0] != "subscribe" {
+		log.Fatal("usage: mangahub grpc sync subscribe [-cursor <cursor>]")
+	}
+
+	fs := flag.NewFlagSet("grpc sync subscribe", flag.ExitOnError)
+	addr := fs.String("addr", cfg.GRPCAddr, "gRPC server address")
+	cursor := fs.String("cursor", "", "resume after this cursor")
+	_ = fs.Parse(args[1:])
+
+	conn, err := newGrpcConn(*addr)
+	if err != nil {
+		log.Fatalf("grpc connect: %v", err)
+	}
+	defer conn.Close()
+
+	client := mangapb.NewSyncServiceClient(conn)
+	stream, err := client.Subscribe(grpcAuthContext(tokenPath), &mangapb.SubscribeRequest{Cursor: *cursor})
+	if err != nil {
+		log.Fatalf("grpc subscribe: %v", err)
+	}
+	log.Printf("[sync] subscribed via gRPC at %s", *addr)
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Fatalf("grpc subscribe: %v", err)
+		}
+		printJSON(ev)
+	}
+}
+
+func handleGrpcAuth(cfg CLIConfig, tokenPath string, args []string) {
+	if len(args) == 0 || args[0] != "login" {
+		log.Fatal("usage: mangahub grpc auth login -email <email> -password <password>")
+	}
+
+	fs := flag.NewFlagSet("grpc auth login", flag.ExitOnError)
+	addr := fs.String("addr", cfg.GRPCAddr, "gRPC server address")
+	email := fs.String("email", "", "email address")
+	password := fs.String("password", "", "password")
+	_ = fs.Parse(args[1:])
+
+	if *email == "" || *password == "" {
+		log.Fatal("email and password are required")
+	}
+
+	conn, err := newGrpcConn(*addr)
+	if err != nil {
+		log.Fatalf("grpc connect: %v", err)
+	}
+	defer conn.Close()
+
+	client := mangapb.NewAuthServiceClient(conn)
+	resp, err := client.Login(context.Background(), &mangapb.LoginRequest{Email: *email, Password: *password})
+	if err != nil {
+		log.Fatalf("grpc login: %v", err)
+	}
+	if err := saveToken(tokenPath, resp.GetToken()); err != nil {
+		log.Fatalf("save token: %v", err)
+	}
+	fmt.Println("✅ logged in")
+}
+
+func handleGrpcReviews(cfg CLIConfig, args []string) {
+	if len(args) == 0 || args[0] != "list" {
+		log.Fatal("usage: mangahub grpc reviews list -manga-id <id>")
+	}
+
+	fs := flag.NewFlagSet("grpc reviews list", flag.ExitOnError)
+	addr := fs.String("addr", cfg.GRPCAddr, "gRPC server address")
+	mangaID := fs.String("manga-id", "", "manga id")
+	limit := fs.Int("limit", 20, "page size")
+	offset := fs.Int("offset", 0, "offset")
+	_ = fs.Parse(args[1:])
+
+	if *mangaID == "" {
+		log.Fatal("manga-id is required")
+	}
+
+	conn, err := newGrpcConn(*addr)
+	if err != nil {
+		log.Fatalf("grpc connect: %v", err)
+	}
+	defer conn.Close()
+
+	client := mangapb.NewReviewServiceClient(conn)
+	resp, err := client.ListReviews(context.Background(), &mangapb.ListReviewsRequest{
+		MangaId: *mangaID,
+		Limit:   int32(*limit),
+		Offset:  int32(*offset),
+	})
+	if err != nil {
+		log.Fatalf("grpc reviews: %v", err)
+	}
+	printJSON(resp)
+}
+
+func handleGrpcChat(cfg CLIConfig, tokenPath string, args []string) {
+	if len(args) == 0 || args[0] != "join" {
+		log.Fatal("usage: mangahub grpc chat join [-room <room>]")
+	}
+
+	fs := flag.NewFlagSet("grpc chat join", flag.ExitOnError)
+	addr := fs.String("addr", cfg.GRPCAddr, "gRPC server address")
+	room := fs.String("room", "lobby", "room name")
+	_ = fs.Parse(args[1:])
+
+	conn, err := newGrpcConn(*addr)
+	if err != nil {
+		log.Fatalf("grpc connect: %v", err)
+	}
+	defer conn.Close()
+
+	client := mangapb.NewChatServiceClient(conn)
+	stream, err := client.Chat(grpcAuthContext(tokenPath))
+	if err != nil {
+		log.Fatalf("grpc chat: %v", err)
+	}
+	if err := stream.Send(&mangapb.ChatRequest{Room: *room}); err != nil {
+		log.Fatalf("grpc chat: %v", err)
+	}
+	log.Printf("[chat] joined %s via gRPC at %s", *room, *addr)
+
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			if err := stream.Send(&mangapb.ChatRequest{Text: text}); err != nil {
+				return
+			}
+		}
+		_ = stream.CloseSend()
+	}()
+
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Fatalf("grpc chat: %v", err)
+		}
+		printJSON(msg)
+	}
+}
+
+func handleGrpcHealth(cfg CLIConfig, args []string) {
+	fs := flag.NewFlagSet("grpc health", flag.ExitOnError)
+	addr := fs.String("addr", cfg.GRPCAddr, "gRPC server address")
+	service := fs.String("service", "", "service name (empty checks the whole server)")
+	_ = fs.Parse(args)
+
+	conn, err := newGrpcConn(*addr)
+	if err != nil {
+		log.Fatalf("grpc connect: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: *service})
+	if err != nil {
+		log.Fatalf("grpc health: %v", err)
+	}
+	fmt.Println(resp.GetStatus())
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		os.Exit(1)
+	}
+}
+
+// grpcAuthContext attaches the stored token as bearer metadata.
+func grpcAuthContext(tokenPath string) context.Context {
+	token := mustToken(tokenPath)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
 func newGrpcConn(addr string) (*grpc.ClientConn, error) {
 	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -804,6 +1161,233 @@ func handleServer(ctx context.Context, client *http.Client, baseURL, sub string,
 	}
 }
 
+func handleAdmin(ctx context.Context, client *http.Client, baseURL, tokenPath, sub string, args []string) {
+	token := mustToken(tokenPath)
+	switch sub {
+	case "role":
+		fs := flag.NewFlagSet("admin role", flag.ExitOnError)
+		userID := fs.String("user-id", "", "user id")
+		role := fs.String("role", "", "user|moderator|admin")
+		_ = fs.Parse(args)
+		if *userID == "" || *role == "" {
+			log.Fatal("user-id and role are required")
+		}
+		var resp map[string]any
+		if err := doJSON(ctx, client, http.MethodPut, baseURL+"/admin/users/"+url.PathEscape(*userID)+"/role", token, map[string]string{"role": *role}, &resp); err != nil {
+			log.Fatalf("admin role failed: %v", err)
+		}
+		printJSON(resp)
+	case "scrape-runs":
+		fs := flag.NewFlagSet("admin scrape-runs", flag.ExitOnError)
+		id := fs.Int64("id", 0, "show one run in full")
+		limit := fs.Int("limit", 20, "runs per page")
+		offset := fs.Int("offset", 0, "offset")
+		_ = fs.Parse(args)
+		if *id > 0 {
+			var resp map[string]any
+			if err := doJSON(ctx, client, http.MethodGet, fmt.Sprintf("%s/admin/scrape-runs/%d", baseURL, *id), token, nil, &resp); err != nil {
+				log.Fatalf("admin scrape-runs failed: %v", err)
+			}
+			printJSON(resp)
+			return
+		}
+		var resp struct {
+			Total int `json:"total"`
+			Items []struct {
+				ID         int64     `json:"id"`
+				StartedAt  time.Time `json:"started_at"`
+				FinishedAt time.Time `json:"finished_at"`
+				Status     string    `json:"status"`
+				Merged     int       `json:"merged"`
+				New        int       `json:"new"`
+				Updated    int       `json:"updated"`
+				Unchanged  int       `json:"unchanged"`
+				Sources    []struct {
+					Name   string `json:"name"`
+					Status string `json:"status"`
+				} `json:"sources"`
+			} `json:"items"`
+		}
+		endpoint := fmt.Sprintf("%s/admin/scrape-runs?limit=%d&offset=%d", baseURL, *limit, *offset)
+		if err := doJSON(ctx, client, http.MethodGet, endpoint, token, nil, &resp); err != nil {
+			log.Fatalf("admin scrape-runs failed: %v", err)
+		}
+		for _, r := range resp.Items {
+			var failed []string
+			for _, s := range r.Sources {
+				if s.Status != "ok" {
+					failed = append(failed, s.Name+":"+s.Status)
+				}
+			}
+			fmt.Printf("#%-5d %s  %-7s %6s  merged %d (new %d, updated %d, unchanged %d)  %s\n",
+				r.ID, r.StartedAt.Local().Format("2006-01-02 15:04"), r.Status,
+				r.FinishedAt.Sub(r.StartedAt).Round(time.Second), r.Merged, r.New, r.Updated, r.Unchanged,
+				strings.Join(failed, " "))
+		}
+		fmt.Printf("%d of %d runs\n", len(resp.Items), resp.Total)
+	case "scrape":
+		fs := flag.NewFlagSet("admin scrape", flag.ExitOnError)
+		sources := fs.String("sources", "", "comma separated sources to fetch (default: all)")
+		_ = fs.Parse(args)
+		names := []string{}
+		for _, s := range strings.Split(*sources, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				names = append(names, s)
+			}
+		}
+		payload := map[string]any{"sources": names}
+		var resp map[string]any
+		if err := doJSON(ctx, client, http.MethodPost, baseURL+"/admin/scrape-runs", token, payload, &resp); err != nil {
+			log.Fatalf("admin scrape failed: %v", err)
+		}
+		fmt.Printf("requested run #%v; the worker picks it up shortly (see: mangahub admin scrape-status)\n", resp["id"])
+	case "scrape-status":
+		var resp struct {
+			Running *struct {
+				Holder     string    `json:"holder"`
+				Trigger    string    `json:"trigger"`
+				AcquiredAt time.Time `json:"acquired_at"`
+			} `json:"running"`
+			Schedule []struct {
+				Source string    `json:"source"`
+				NextAt time.Time `json:"next_at"`
+			} `json:"schedule"`
+			Requests []struct {
+				ID      int64    `json:"id"`
+				Sources []string `json:"sources"`
+				Status  string   `json:"status"`
+			} `json:"requests"`
+			LastRun *struct {
+				ID         int64     `json:"id"`
+				Status     string    `json:"status"`
+				Trigger    string    `json:"trigger"`
+				FinishedAt time.Time `json:"finished_at"`
+			} `json:"last_run"`
+		}
+		if err := doJSON(ctx, client, http.MethodGet, baseURL+"/admin/scraper/status", token, nil, &resp); err != nil {
+			log.Fatalf("admin scrape-status failed: %v", err)
+		}
+		if r := resp.Running; r != nil {
+			fmt.Printf("running: %s run on %s since %s\n", r.Trigger, r.Holder, r.AcquiredAt.Local().Format("15:04:05"))
+		} else {
+			fmt.Println("running: nothing")
+		}
+		if r := resp.LastRun; r != nil {
+			fmt.Printf("last run: #%d %s (%s) at %s\n", r.ID, r.Status, r.Trigger, r.FinishedAt.Local().Format("2006-01-02 15:04"))
+		}
+		for _, s := range resp.Schedule {
+			fmt.Printf("  %-12s next %s\n", s.Source, s.NextAt.Local().Format("2006-01-02 15:04"))
+		}
+		for _, r := range resp.Requests {
+			fmt.Printf("  request #%d %s %v\n", r.ID, r.Status, r.Sources)
+		}
+	case "match-reviews":
+		fs := flag.NewFlagSet("admin match-reviews", flag.ExitOnError)
+		status := fs.String("status", "pending", "pending, merged, rejected or all")
+		merge := fs.Int64("merge", 0, "review id to resolve as the same work")
+		reject := fs.Int64("reject", 0, "review id to resolve as different works")
+		limit := fs.Int("limit", 20, "reviews per page")
+		offset := fs.Int("offset", 0, "offset")
+		_ = fs.Parse(args)
+		if *merge > 0 || *reject > 0 {
+			id, action := *merge, "merge"
+			if *reject > 0 {
+				id, action = *reject, "reject"
+			}
+			var resp map[string]any
+			endpoint := fmt.Sprintf("%s/admin/match-reviews/%d", baseURL, id)
+			if err := doJSON(ctx, client, http.MethodPost, endpoint, token, map[string]string{"action": action}, &resp); err != nil {
+				log.Fatalf("admin match-reviews failed: %v", err)
+			}
+			printJSON(resp)
+			return
+		}
+		var resp struct {
+			Total int `json:"total"`
+			Items []struct {
+				ID             int64   `json:"id"`
+				Source         string  `json:"source"`
+				SourceID       string  `json:"source_id"`
+				Title          string  `json:"title"`
+				CandidateID    string  `json:"candidate_id"`
+				CandidateTitle string  `json:"candidate_title"`
+				Score          float64 `json:"score"`
+				Status         string  `json:"status"`
+			} `json:"items"`
+		}
+		endpoint := fmt.Sprintf("%s/admin/match-reviews?status=%s&limit=%d&offset=%d", baseURL, url.QueryEscape(*status), *limit, *offset)
+		if err := doJSON(ctx, client, http.MethodGet, endpoint, token, nil, &resp); err != nil {
+			log.Fatalf("admin match-reviews failed: %v", err)
+		}
+		for _, r := range resp.Items {
+			fmt.Printf("#%-5d %.2f %-8s %s/%s %q ~ %s %q\n",
+				r.ID, r.Score, r.Status, r.Source, r.SourceID, r.Title, r.CandidateID, r.CandidateTitle)
+		}
+		fmt.Printf("%d of %d reviews\n", len(resp.Items), resp.Total)
+	case "manga":
+		fs := flag.NewFlagSet("admin manga", flag.ExitOnError)
+		id := fs.String("id", "", "manga id")
+		fs.String("title", "", "set title")
+		fs.String("author", "", "set author")
+		fs.String("genres", "", "set genres (comma separated)")
+		fs.String("status", "", "set status")
+		fs.Int("chapters", 0, "set total chapters")
+		fs.String("description", "", "set description")
+		fs.String("cover", "", "set cover URL")
+		noLock := fs.Bool("no-lock", false, "let the scraper overwrite the edited fields again")
+		note := fs.String("note", "", "why the edit was made")
+		revert := fs.Int64("revert", 0, "edit id to revert")
+		unlock := fs.String("unlock", "", "field to hand back to the scraper")
+		_ = fs.Parse(args)
+		if *id == "" {
+			log.Fatal("manga id is required")
+		}
+		base := fmt.Sprintf("%s/admin/manga/%s", baseURL, url.PathEscape(*id))
+
+		payload := map[string]any{}
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "title", "author", "status", "description":
+				payload[f.Name] = f.Value.String()
+			case "cover":
+				payload["cover_url"] = f.Value.String()
+			case "chapters":
+				n, _ := strconv.Atoi(f.Value.String())
+				payload["total_chapters"] = n
+			case "genres":
+				genres := []string{}
+				for _, g := range strings.Split(f.Value.String(), ",") {
+					if g = strings.TrimSpace(g); g != "" {
+						genres = append(genres, g)
+					}
+				}
+				payload["genres"] = genres
+			}
+		})
+
+		var resp map[string]any
+		var err error
+		switch {
+		case *revert > 0:
+			err = doJSON(ctx, client, http.MethodPost, fmt.Sprintf("%s/edits/%d/revert", base, *revert), token, nil, &resp)
+		case *unlock != "":
+			err = doJSON(ctx, client, http.MethodDelete, base+"/locks/"+url.PathEscape(*unlock), token, nil, &resp)
+		case len(payload) > 0:
+			payload["lock"] = !*noLock
+			payload["note"] = *note
+			err = doJSON(ctx, client, http.MethodPatch, base, token, payload, &resp)
+		default:
+			err = doJSON(ctx, client, http.MethodGet, base+"/edits", token, nil, &resp)
+		}
+		if err != nil {
+			log.Fatalf("admin manga failed: %v", err)
+		}
+		printJSON(resp)
+	default:
+		log.Fatal("usage: mangahub admin <role|scrape|scrape-status|scrape-runs|match-reviews|manga>")
+	}
+}
+
 func handleExport(ctx context.Context, client *http.Client, baseURL, sub string, args []string) {
 	switch sub {
 	case "json":
@@ -1045,6 +1629,35 @@ func doJSON(ctx context.Context, client *http.Client, method, endpoint, token st
 	return nil
 }
 
+func downloadFile(ctx context.Context, client *http.Client, endpoint, token, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GET %s failed: %s", endpoint, strings.TrimSpace(string(data)))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, resp.Body)
+	return err
+}
+
 func printJSON(v any) {
 	b, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
@@ -1343,8 +1956,18 @@ func addWSQuery(endpoint string, values map[string]string) string {
 	return u.String()
 }
 
-func runChatWebSocket(wsURL string) error {
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+// chatHeader authenticates a chat connection; an empty token connects
+// anonymously.
+func chatHeader(token string) http.Header {
+	h := http.Header{}
+	if token != "" {
+		h.Set("Authorization", "Bearer "+token)
+	}
+	return h
+}
+
+func runChatWebSocket(wsURL, token string) error {
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, chatHeader(token))
 	if err != nil {
 		return err
 	}
@@ -1385,13 +2008,13 @@ func runChatWebSocket(wsURL string) error {
 	return nil
 }
 
-func sendChatWebSocket(wsURL, text, user string) error {
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+// sendChatWebSocket writes one frame and hangs up.
+func sendChatWebSocket(wsURL, token string, payload map[string]any) error {
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, chatHeader(token))
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
-	payload := map[string]string{"text": text, "user": user}
 	b, err := json.Marshal(payload)
 	if err != nil {
 		return err
@@ -1461,14 +2084,16 @@ func printUsage() {
 	fmt.Println("mangahub <command> [subcommand] [flags]")
 	fmt.Println("commands:")
 	fmt.Println("  init")
-	fmt.Println("  auth login|register|logout|status|change-password")
+	fmt.Println("  auth login|register|logout|status|change-password|export|delete-account")
 	fmt.Println("  manga search|show|list|info")
 	fmt.Println("  library add|remove|list|update")
 	fmt.Println("  progress update|history|sync|sync-status")
 	fmt.Println("  sync connect|disconnect|status|listen|monitor")
 	fmt.Println("  notify subscribe|unsubscribe|preferences|test")
-	fmt.Println("  chat join|send|history")
-	fmt.Println("  grpc manga get|search; grpc progress update")
+	fmt.Println("  chat join|send|dm|read|unread|conversations|history|rooms|mod|modlog")
+	fmt.Println("  grpc manga get|search; grpc progress update; grpc sync subscribe")
+	fmt.Println("  grpc auth login; grpc reviews list; grpc chat join; grpc health")
 	fmt.Println("  server start|stop|status|health|logs|ping")
 	fmt.Println("  export json|csv")
+	fmt.Println("  admin role|scrape|scrape-status|scrape-runs|match-reviews|manga")
 }
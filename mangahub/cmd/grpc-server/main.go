@@ -1,20 +1,35 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 
+	"mangahub/internal/auth"
+	"mangahub/internal/bus"
 	"mangahub/internal/grpcserver"
 	"mangahub/internal/library"
 	"mangahub/internal/manga"
+	"mangahub/internal/progress"
+	"mangahub/internal/reviews"
+	syncsrv "mangahub/internal/sync"
 	"mangahub/pkg/database"
-	"mangahub/pkg/grpc/mangapb"
 	"mangahub/pkg/utils"
 )
 
 func main() {
+	grpcCfg := utils.LoadGrpcConfig()
+	enableReflection := flag.Bool("reflection", grpcCfg.Reflection, "register the server reflection service (grpcurl, evans)")
+	flag.Parse()
+
 	cfg := database.DefaultConfig()
 	db := database.MustOpen(cfg)
 	defer db.Close()
@@ -23,22 +38,89 @@ func main() {
 		log.Fatalf("db migrate failed: %v", err)
 	}
 
-	grpcCfg := utils.LoadGrpcConfig()
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	authCfg := utils.LoadAuthConfig()
+	tokenSvc, err := auth.NewTokenService(ctx, authCfg)
+	if err != nil {
+		log.Fatalf("auth config: %v", err)
+	}
+	if tokenSvc.Keys != nil {
+		// the API server owns rotation; only pick up its new keys here
+		go tokenSvc.Keys.Maintain(ctx, 0)
+	}
+
 	listener, err := net.Listen("tcp", grpcCfg.Addr)
 	if err != nil {
 		log.Fatalf("grpc listen failed: %v", err)
 	}
 
+	users := auth.NewRepo(db)
 	mangaRepo := manga.NewRepo(db)
-	libraryRepo := library.NewRepo(db)
-	svc := grpcserver.NewServer(mangaRepo, libraryRepo)
+	// no chat hub here: it lives inside the api-server, so ChatService is
+	// served by its in-process gRPC listener. A sync hub only makes sense
+	// on a bus shared with the api-server; then library writes made here
+	// reach its sync clients, and SyncService is served here too.
+	var hub *syncsrv.Hub
+	if busCfg := utils.LoadBusConfig(); busCfg.Backend == "sqlite" {
+		broker, err := bus.New(busCfg, db)
+		if err != nil {
+			log.Fatalf("bus: %v", err)
+		}
+		defer broker.Close()
+		hub = syncsrv.NewHub(broker)
+	}
+	authSvc := auth.NewService(users)
+	if hub != nil {
+		// reaches the api-server's sync clients over the bus; its chat
+		// connections are only dropped by revocations made there
+		authSvc.OnRevoke = []func(string){hub.RemoveUser}
+	}
+	svc := grpcserver.NewServer(grpcserver.Deps{
+		Manga:    manga.NewService(mangaRepo),
+		Library:  library.NewService(library.NewRepo(db), mangaRepo, hub),
+		Reviews:  reviews.NewService(reviews.NewRepo(db)),
+		Progress: progress.NewService(progress.NewRepo(db)),
+		Auth:     authSvc,
+		Tokens:   tokenSvc,
+		Hub:      hub,
+	})
+
+	opts := grpcserver.ServerOptions(grpcserver.Authenticator{
+		Tokens: tokenSvc,
+		Users:  users,
+	})
+	grpcServer := grpc.NewServer(append(opts, grpcserver.TransportOptions(grpcCfg)...)...)
+	svc.RegisterServices(grpcServer)
+
+	healthSrv := grpcserver.NewHealth(db)
+	healthSrv.RegisterService(grpcServer)
+	go healthSrv.Run(ctx, 10*time.Second)
 
-	grpcServer := grpc.NewServer()
-	mangapb.RegisterMangaServiceServer(grpcServer, svc)
-	mangapb.RegisterProgressServiceServer(grpcServer, svc)
+	if *enableReflection {
+		reflection.Register(grpcServer)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("gRPC server listening on %s", grpcCfg.Addr)
+		errCh <- grpcServer.Serve(listener)
+	}()
 
-	log.Printf("gRPC server listening on %s", grpcCfg.Addr)
-	if err := grpcServer.Serve(listener); err != nil {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case sig := <-sigCh:
+		log.Printf("shutdown signal received: %s", sig)
+	case err := <-errCh:
 		log.Fatalf("grpc server stopped: %v", err)
 	}
+
+	// report NOT_SERVING so load balancers drain us before connections close
+	stop()
+	healthSrv.Shutdown()
+	grpcserver.Stop(grpcServer, grpcCfg.ShutdownTimeout)
+	log.Println("gRPC server stopped")
 }
@@ -58,17 +58,18 @@ func importManga(ctx context.Context, db *sql.DB, path string) error {
 		return err
 	}
 
+	// fields an admin locked (manga_locks) keep their current value
 	stmt, err := db.PrepareContext(ctx, `
 		INSERT INTO manga (id, title, author, genres, status, total_chapters, description, cover_url)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
-		  title = excluded.title,
-		  author = excluded.author,
-		  genres = excluded.genres,
-		  status = excluded.status,
-		  total_chapters = excluded.total_chapters,
-		  description = excluded.description,
-		  cover_url = excluded.cover_url
+		  title = `+unlessLocked("title")+`,
+		  author = `+unlessLocked("author")+`,
+		  genres = `+unlessLocked("genres")+`,
+		  status = `+unlessLocked("status")+`,
+		  total_chapters = `+unlessLocked("total_chapters")+`,
+		  description = `+unlessLocked("description")+`,
+		  cover_url = `+unlessLocked("cover_url")+`
 	`)
 	if err != nil {
 		return err
@@ -116,6 +117,14 @@ func importManga(ctx context.Context, db *sql.DB, path string) error {
 	return nil
 }
 
+// unlessLocked is the ON CONFLICT value for column: the imported one,
+// unless the field is locked for this title.
+func unlessLocked(column string) string {
+	return fmt.Sprintf(`CASE WHEN EXISTS (
+		SELECT 1 FROM manga_locks WHERE manga_id = manga.id AND field = '%s'
+	) THEN manga.%s ELSE excluded.%s END`, column, column, column)
+}
+
 func importUserProgress(ctx context.Context, db *sql.DB, path string) error {
 	f, err := os.Open(path)
 	if err != nil {
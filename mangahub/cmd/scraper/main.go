@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"time"
@@ -11,8 +14,22 @@ import (
 )
 
 func main() {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	configPath := flag.String("config", os.Getenv("MANGAHUB_SCRAPER_CONFIG"), "YAML or JSON file listing the sources to scrape (default: MangaDex + mirror)")
+	only := flag.String("sources", "", "comma separated sources to fetch; the rest reuse their last snapshot (default: all)")
+	dryRun := flag.Bool("dry-run", false, "print what the run would change in the manga table and write nothing")
+	apply := flag.String("apply", "", "comma separated manga ids: print the diff, then save only these titles")
+	asJSON := flag.Bool("json", false, "print the diff as JSON (with -dry-run or -apply)")
+	flag.Parse()
+
+	cfg := scraper.DefaultConfig()
+	if *configPath != "" {
+		var err error
+		if cfg, err = scraper.LoadConfig(*configPath); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	ctx := context.Background()
 
 	db := database.MustOpen(database.DefaultConfig())
 	defer db.Close()
@@ -22,29 +39,85 @@ func main() {
 		log.Fatalf("db migrate failed: %v", err)
 	}
 
-	mirrorBaseURL := os.Getenv("MIRROR_BASE_URL")
-	if mirrorBaseURL == "" {
-		mirrorBaseURL = "http://localhost:9000"
+	opts := scraper.RunOptions{
+		Trigger: scraper.TriggerCLI,
+		Sources: scraper.ParseList(*only),
+	}
+	if *dryRun || *apply != "" {
+		plan, err := scraper.Preview(ctx, db, cfg, opts)
+		if err != nil {
+			if plan != nil {
+				printReport(plan.Report)
+			}
+			log.Fatalf("%v", err)
+		}
+		printPlan(plan, *asJSON)
+		if *dryRun {
+			return
+		}
+		report, err := plan.Apply(ctx, db, scraper.ParseList(*apply))
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		printReport(report)
+		if report.Status == scraper.RunFailed {
+			os.Exit(1)
+		}
+		return
 	}
 
-	// Source A: MangaDex (live)
-	srcA := scraper.NewSourceA()
-
-	// Source B: local mirror server (demo-safe)
-	srcB := scraper.NewSourceB(mirrorBaseURL)
-
-	agg := scraper.NewAggregator(srcA, srcB)
-
-	mangas, err := agg.FetchAndMerge(ctx)
+	report, err := scraper.Run(ctx, db, cfg, opts)
 	if err != nil {
-		log.Fatalf("scrape failed: %v", err)
+		log.Fatalf("%v", err)
 	}
+	printReport(report)
 
-	log.Printf("merged mangas: %d", len(mangas))
+	if report.Status == scraper.RunFailed {
+		os.Exit(1)
+	}
+	log.Println("✅ database populated at ~/.mangahub/data.db")
+}
 
-	if err := scraper.SaveToDatabase(ctx, db, mangas); err != nil {
-		log.Fatalf("save failed: %v", err)
+// printPlan writes the diff to stdout, so it can be piped while the
+// log goes to stderr.
+func printPlan(p *scraper.Plan, asJSON bool) {
+	if !asJSON {
+		printReport(p.Report)
+		p.Diff.Print(os.Stdout)
+		return
 	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(p); err != nil {
+		log.Fatalf("encode diff: %v", err)
+	}
+}
 
-	log.Println("✅ database populated at ~/.mangahub/data.db")
+func printReport(r *scraper.RunReport) {
+	var took time.Duration
+	if !r.FinishedAt.IsZero() {
+		took = r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond)
+	}
+	log.Printf("run #%d %s in %s: %d merged (%d new, %d updated, %d unchanged), %d queued for review, %d delisted, %d relisted",
+		r.ID, r.Status, took,
+		r.Merged, r.New, r.Updated, r.Unchanged, r.Queued, r.Delisted, r.Relisted)
+	for _, s := range r.Sources {
+		line := fmt.Sprintf("  %-12s %-8s %5d items %7dms", s.Name, s.Status, s.Items, s.DurationMS)
+		if s.Cached {
+			line += " (snapshot)"
+		}
+		if s.TimedOut {
+			line += " (timed out)"
+		}
+		if s.Truncated {
+			line += " (truncated)"
+		}
+		if s.Error != "" {
+			line += "  " + s.Error
+		}
+		log.Print(line)
+	}
+	if r.Error != "" {
+		log.Printf("  error: %s", r.Error)
+	}
 }
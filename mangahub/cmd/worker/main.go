@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"mangahub/internal/scraper"
+	"mangahub/pkg/database"
+)
+
+// worker scrapes on each source's schedule and runs the scrapes admins
+// request, until it is stopped.
+func main() {
+	configPath := flag.String("config", os.Getenv("MANGAHUB_SCRAPER_CONFIG"), "YAML or JSON file listing the sources and their schedules (default: MangaDex + mirror)")
+	poll := flag.Duration("poll", 5*time.Second, "how often to look for due sources and requested runs")
+	flag.Parse()
+
+	cfg := scraper.DefaultConfig()
+	if *configPath != "" {
+		var err error
+		if cfg, err = scraper.LoadConfig(*configPath); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	db := database.MustOpen(database.DefaultConfig())
+	defer db.Close()
+
+	if err := database.Migrate(db); err != nil {
+		log.Fatalf("db migrate failed: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	w := &scraper.Worker{DB: db, Config: cfg, Poll: *poll}
+	log.Printf("scrape worker started as %s", scraper.DefaultHolder())
+	if err := w.Run(ctx); err != nil {
+		log.Fatalf("worker: %v", err)
+	}
+	log.Printf("scrape worker stopped")
+}
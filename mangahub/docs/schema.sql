@@ -5,7 +5,9 @@ CREATE TABLE IF NOT EXISTS users (
   email TEXT UNIQUE NOT NULL,
   password_hash TEXT NOT NULL,
   token_version INTEGER NOT NULL DEFAULT 0,
-  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+  role TEXT NOT NULL DEFAULT 'user', -- user, moderator or admin
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP -- set when the account was anonymized on deletion
 );
 
 -- manga table
@@ -17,7 +19,8 @@ CREATE TABLE IF NOT EXISTS manga (
   status TEXT,
   total_chapters INTEGER,
   description TEXT,
-  cover_url TEXT
+  cover_url TEXT,
+  delisted_at TIMESTAMP -- set once no source has returned the title for a while
 );
 
 -- user progress table
@@ -28,7 +31,7 @@ CREATE TABLE IF NOT EXISTS user_progress (
   status TEXT,
   updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
   PRIMARY KEY (user_id, manga_id),
-  FOREIGN KEY (user_id) REFERENCES users(id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
   FOREIGN KEY (manga_id) REFERENCES manga(id)
 );
 
@@ -39,7 +42,7 @@ CREATE TABLE IF NOT EXISTS user_progress_history (
   chapter INTEGER NOT NULL,
   volume INTEGER,
   at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-  FOREIGN KEY (user_id) REFERENCES users(id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
   FOREIGN KEY (manga_id) REFERENCES manga(id)
 );
 
@@ -51,6 +54,246 @@ CREATE TABLE IF NOT EXISTS reviews (
   rating INTEGER NOT NULL,
   text TEXT,
   timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-  FOREIGN KEY (user_id) REFERENCES users(id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
   FOREIGN KEY (manga_id) REFERENCES manga(id)
 );
+
+-- chat rooms, created on first join. Discussion rooms for a manga are named
+-- "manga:<manga id>"; any other name is a free-form room.
+CREATE TABLE IF NOT EXISTS chat_rooms (
+  name TEXT PRIMARY KEY,
+  manga_id TEXT,
+  owner_id TEXT, -- first user in a free-form room; manga rooms have none
+  slow_mode_seconds INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  last_message_at TIMESTAMP,
+  FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE,
+  FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- moderators appointed by a room's owner or site staff
+CREATE TABLE IF NOT EXISTS chat_room_moderators (
+  room TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  added_by TEXT,
+  added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (room, user_id),
+  FOREIGN KEY (room) REFERENCES chat_rooms(name) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- mutes and bans; a NULL until never expires
+CREATE TABLE IF NOT EXISTS chat_sanctions (
+  room TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  kind TEXT NOT NULL, -- mute or ban
+  until TIMESTAMP,
+  by_user_id TEXT,
+  reason TEXT,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (room, user_id, kind),
+  FOREIGN KEY (room) REFERENCES chat_rooms(name) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- audit trail of moderation actions; kept when the accounts involved go
+CREATE TABLE IF NOT EXISTS chat_moderation_log (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  room TEXT NOT NULL,
+  actor_id TEXT NOT NULL,
+  action TEXT NOT NULL,
+  target_id TEXT,
+  detail TEXT,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_moderation_log_room ON chat_moderation_log(room, id);
+
+-- users who have joined a room at least once
+CREATE TABLE IF NOT EXISTS chat_room_members (
+  room TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (room, user_id),
+  FOREIGN KEY (room) REFERENCES chat_rooms(name) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- chat messages. Direct messages live in a room named
+-- "dm:<user id>:<user id>", the two ids in ascending order.
+CREATE TABLE IF NOT EXISTS chat_messages (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  room TEXT NOT NULL,
+  type TEXT NOT NULL DEFAULT 'message', -- message, system for moderation notices, or dm
+  user_id TEXT REFERENCES users(id) ON DELETE CASCADE, -- NULL on messages from before authenticated chat
+  username TEXT NOT NULL, -- as of posting
+  text TEXT NOT NULL,
+  spoiler_chapter INTEGER, -- text is hidden from readers behind this chapter
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  edited_at TIMESTAMP,
+  deleted_at TIMESTAMP -- soft delete; hidden from history
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_messages_room ON chat_messages(room, id);
+
+-- how far each user has read each room or direct conversation
+CREATE TABLE IF NOT EXISTS chat_read_markers (
+  user_id TEXT NOT NULL,
+  room TEXT NOT NULL,
+  last_read_id INTEGER NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, room),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- one row per scraper run; sources holds the per-source reports as JSON
+CREATE TABLE IF NOT EXISTS scrape_runs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  started_at TIMESTAMP NOT NULL,
+  finished_at TIMESTAMP,
+  status TEXT NOT NULL, -- ok, partial or failed
+  trigger TEXT,         -- cli, schedule or admin
+  merged INTEGER NOT NULL DEFAULT 0,
+  new INTEGER NOT NULL DEFAULT 0,
+  updated INTEGER NOT NULL DEFAULT 0,
+  unchanged INTEGER NOT NULL DEFAULT 0,
+  queued INTEGER NOT NULL DEFAULT 0, -- match candidates sent to review
+  delisted INTEGER NOT NULL DEFAULT 0,
+  relisted INTEGER NOT NULL DEFAULT 0,
+  sources TEXT NOT NULL DEFAULT '[]',
+  error TEXT
+);
+
+-- Which manga each source entry was saved under, so later runs keep
+-- entries matched once together even if titles drift.
+CREATE TABLE IF NOT EXISTS manga_sources (
+  source TEXT NOT NULL,
+  source_id TEXT NOT NULL,
+  manga_id TEXT NOT NULL,
+  first_seen_at TIMESTAMP,
+  last_seen_at TIMESTAMP,
+  missed_runs INTEGER NOT NULL DEFAULT 0, -- complete fetches of source in a row without this entry
+  PRIMARY KEY (source, source_id),
+  FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_manga_sources_manga ON manga_sources(manga_id);
+
+-- Possible duplicates the scraper was not sure enough to merge.
+CREATE TABLE IF NOT EXISTS scrape_match_reviews (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  source TEXT NOT NULL,
+  source_id TEXT NOT NULL,
+  manga_id TEXT NOT NULL,     -- where the entry was saved meanwhile
+  title TEXT NOT NULL,
+  author TEXT,
+  year INTEGER,
+  candidate_id TEXT NOT NULL, -- the manga it may be
+  candidate_title TEXT NOT NULL,
+  score REAL NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending', -- pending, merged or rejected
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  resolved_at TIMESTAMP,
+  resolved_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+  UNIQUE (source, source_id, candidate_id),
+  FOREIGN KEY (candidate_id) REFERENCES manga(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_scrape_match_reviews_status ON scrape_match_reviews(status, id);
+
+-- Which source each stored manga field came from, and since when.
+CREATE TABLE IF NOT EXISTS manga_provenance (
+  manga_id TEXT NOT NULL,
+  field TEXT NOT NULL,       -- title, author, genres, status, total_chapters, description, cover_url
+  source TEXT NOT NULL,      -- comma separated for merged lists
+  value TEXT NOT NULL,       -- JSON of the value the source gave
+  updated_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (manga_id, field),
+  FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+);
+
+-- Fields an admin set by hand; the scraper and importers leave them alone.
+CREATE TABLE IF NOT EXISTS manga_locks (
+  manga_id TEXT NOT NULL,
+  field TEXT NOT NULL,
+  locked_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+  locked_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (manga_id, field),
+  FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+);
+
+-- Audit trail of manual catalog edits. Values are JSON.
+CREATE TABLE IF NOT EXISTS manga_edits (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  manga_id TEXT NOT NULL,
+  field TEXT NOT NULL,
+  old_value TEXT NOT NULL,
+  new_value TEXT NOT NULL,
+  was_locked INTEGER NOT NULL DEFAULT 0,
+  locked INTEGER NOT NULL DEFAULT 0,
+  note TEXT,
+  edited_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+  edited_at TIMESTAMP NOT NULL,
+  revert_of INTEGER REFERENCES manga_edits(id),   -- the edit this one undid
+  reverted_by INTEGER REFERENCES manga_edits(id), -- the edit that undid this one
+  FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_manga_edits_manga ON manga_edits(manga_id, field, id);
+
+-- Held by the process running a scrape, so runs never overlap. A holder
+-- that dies stops renewing and the lease expires.
+CREATE TABLE IF NOT EXISTS scrape_lease (
+  name TEXT PRIMARY KEY,
+  holder TEXT NOT NULL,
+  token TEXT, -- unique per acquisition; renewals and release match on it
+  trigger TEXT,
+  acquired_at TIMESTAMP NOT NULL,
+  expires_at TIMESTAMP NOT NULL
+);
+
+-- What each source returned the last time it was fetched in full; runs
+-- that fetch only some sources merge against these for the rest.
+CREATE TABLE IF NOT EXISTS scrape_snapshots (
+  source TEXT PRIMARY KEY,
+  fetched_at TIMESTAMP NOT NULL,
+  items TEXT NOT NULL -- JSON array of fetched titles
+);
+
+-- When the worker fetches each source next.
+CREATE TABLE IF NOT EXISTS scrape_schedule (
+  source TEXT PRIMARY KEY,
+  next_at TIMESTAMP NOT NULL,
+  last_at TIMESTAMP
+);
+
+-- On-demand runs asked for through the admin API, picked up by the worker.
+CREATE TABLE IF NOT EXISTS scrape_requests (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  sources TEXT NOT NULL DEFAULT '[]', -- JSON array; empty means all
+  requested_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+  requested_at TIMESTAMP NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending', -- pending, running, done or failed
+  run_id INTEGER REFERENCES scrape_runs(id),
+  error TEXT,
+  claimed_by TEXT, -- worker (lease holder) running it
+  claimed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_scrape_requests_status ON scrape_requests(status, id);
+
+-- Cover images mirrored by the scraper, one row per cover_url. The image
+-- itself is stored under MANGAHUB_COVERS_DIR by hash and served at
+-- /covers/:hash.
+CREATE TABLE IF NOT EXISTS covers (
+  url TEXT PRIMARY KEY,
+  hash TEXT,                     -- sha256 of the image; NULL until a download works
+  content_type TEXT,
+  fetched_at TIMESTAMP,          -- last successful download
+  checked_at TIMESTAMP NOT NULL, -- last attempt
+  next_check_at TIMESTAMP NOT NULL,
+  failures INTEGER NOT NULL DEFAULT 0, -- in a row
+  error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_covers_hash ON covers(hash);
@@ -0,0 +1,139 @@
+package account
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"mangahub/internal/auth"
+)
+
+type Handler struct {
+	Repo   *Repo
+	Users  *auth.Repo
+	Policy string
+
+	// OnDelete hooks drop live registrations (sync and chat sockets, UDP
+	// notify clients) after the account is gone.
+	OnDelete []func(userID string)
+}
+
+func NewHandler(repo *Repo, users *auth.Repo, policy string, onDelete ...func(userID string)) *Handler {
+	if policy != PolicyAnonymize {
+		policy = PolicyDelete
+	}
+	return &Handler{Repo: repo, Users: users, Policy: policy, OnDelete: onDelete}
+}
+
+// RegisterRoutes expects a group already behind auth.AuthMiddleware.
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/me/export", h.export)
+	rg.DELETE("/me", h.deleteMe)
+}
+
+func (h *Handler) export(c *gin.Context) {
+	claims := auth.MustGetClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	data, err := h.Repo.Export(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "export failed"})
+		return
+	}
+	if data == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", "json")))
+	switch format {
+	case "json":
+		c.Header("Content-Disposition", `attachment; filename="mangahub-export.json"`)
+		c.JSON(http.StatusOK, data)
+	case "zip":
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", `attachment; filename="mangahub-export.zip"`)
+		c.Status(http.StatusOK)
+		if err := writeZip(c.Writer, data); err != nil {
+			// headers are gone already; the client sees a truncated archive
+			log.Printf("[account] zip export for %s failed: %v", claims.UserID, err)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or zip"})
+	}
+}
+
+type deleteReq struct {
+	Password string `json:"password"`
+}
+
+func (h *Handler) deleteMe(c *gin.Context) {
+	claims := auth.MustGetClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req deleteReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password required"})
+		return
+	}
+
+	u, err := h.Users.GetByID(c.Request.Context(), claims.UserID)
+	if err != nil || u == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	if err := h.Repo.Delete(c.Request.Context(), u.ID, h.Policy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+		return
+	}
+
+	for _, fn := range h.OnDelete {
+		fn(u.ID)
+	}
+	log.Printf("[account] deleted user %s (policy=%s)", u.ID, h.Policy)
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted", "policy": h.Policy})
+}
+
+func writeZip(w http.ResponseWriter, data *Export) error {
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"profile.json", data.Profile},
+		{"library.json", data.Library},
+		{"progress_history.json", data.History},
+		{"reviews.json", data.Reviews},
+		{"chat_messages.json", data.Chat},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return fmt.Errorf("zip create %s: %w", f.name, err)
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.v); err != nil {
+			return fmt.Errorf("zip write %s: %w", f.name, err)
+		}
+	}
+	return zw.Close()
+}
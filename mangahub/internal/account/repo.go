@@ -0,0 +1,246 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+const (
+	// PolicyDelete removes the user row and everything they authored.
+	PolicyDelete = "delete"
+	// PolicyAnonymize removes personal data but keeps reviews, attributed to a
+	// scrubbed placeholder account.
+	PolicyAnonymize = "anonymize"
+)
+
+type Profile struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatMessage is a chat or direct message the user posted that is still
+// visible.
+type ChatMessage struct {
+	ID       int64      `json:"id"`
+	Room     string     `json:"room"`
+	Text     string     `json:"text"`
+	At       time.Time  `json:"at"`
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+}
+
+// Export is everything we hold about one user.
+type Export struct {
+	ExportedAt time.Time                `json:"exported_at"`
+	Profile    Profile                  `json:"profile"`
+	Library    []models.LibraryItem     `json:"library"`
+	History    []models.ProgressHistory `json:"progress_history"`
+	Reviews    []models.Review          `json:"reviews"`
+	Chat       []ChatMessage            `json:"chat_messages"`
+}
+
+type Repo struct {
+	DB *sql.DB
+}
+
+func NewRepo(db *sql.DB) *Repo {
+	return &Repo{DB: db}
+}
+
+func (r *Repo) Export(ctx context.Context, userID string) (*Export, error) {
+	out := &Export{
+		ExportedAt: time.Now().UTC(),
+		Library:    []models.LibraryItem{},
+		History:    []models.ProgressHistory{},
+		Reviews:    []models.Review{},
+		Chat:       []ChatMessage{},
+	}
+
+	row := r.DB.QueryRowContext(ctx, `
+		SELECT id, username, email, created_at
+		FROM users
+		WHERE id = ? AND deleted_at IS NULL
+	`, userID)
+	if err := row.Scan(&out.Profile.ID, &out.Profile.Username, &out.Profile.Email, &out.Profile.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("export profile: %w", err)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT user_id, manga_id, current_chapter, status, updated_at
+		FROM user_progress
+		WHERE user_id = ?
+		ORDER BY updated_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("export library: %w", err)
+	}
+	for rows.Next() {
+		var it models.LibraryItem
+		var status sql.NullString
+		if err := rows.Scan(&it.UserID, &it.MangaID, &it.CurrentChapter, &status, &it.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan library row: %w", err)
+		}
+		it.Status = status.String
+		out.Library = append(out.Library, it)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("export library rows: %w", err)
+	}
+
+	rows, err = r.DB.QueryContext(ctx, `
+		SELECT user_id, manga_id, chapter, volume, at
+		FROM user_progress_history
+		WHERE user_id = ?
+		ORDER BY at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("export history: %w", err)
+	}
+	for rows.Next() {
+		var entry models.ProgressHistory
+		var volume sql.NullInt64
+		if err := rows.Scan(&entry.UserID, &entry.MangaID, &entry.Chapter, &volume, &entry.At); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan history row: %w", err)
+		}
+		if volume.Valid {
+			v := int(volume.Int64)
+			entry.Volume = &v
+		}
+		out.History = append(out.History, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("export history rows: %w", err)
+	}
+
+	rows, err = r.DB.QueryContext(ctx, `
+		SELECT id, user_id, manga_id, rating, text, timestamp
+		FROM reviews
+		WHERE user_id = ?
+		ORDER BY timestamp DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("export reviews: %w", err)
+	}
+	for rows.Next() {
+		var review models.Review
+		var text sql.NullString
+		if err := rows.Scan(&review.ID, &review.UserID, &review.MangaID, &review.Rating, &text, &review.Timestamp); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan review row: %w", err)
+		}
+		review.Text = text.String
+		out.Reviews = append(out.Reviews, review)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("export reviews rows: %w", err)
+	}
+
+	rows, err = r.DB.QueryContext(ctx, `
+		SELECT id, room, text, created_at, edited_at
+		FROM chat_messages
+		WHERE user_id = ? AND type IN ('message', 'dm') AND deleted_at IS NULL
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("export chat: %w", err)
+	}
+	for rows.Next() {
+		var msg ChatMessage
+		var edited sql.NullTime
+		if err := rows.Scan(&msg.ID, &msg.Room, &msg.Text, &msg.At, &edited); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan chat row: %w", err)
+		}
+		if edited.Valid {
+			t := edited.Time
+			msg.EditedAt = &t
+		}
+		out.Chat = append(out.Chat, msg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("export chat rows: %w", err)
+	}
+
+	return out, nil
+}
+
+// Delete removes a user's data according to policy in a single transaction.
+// Dependent rows are deleted explicitly because databases created before the
+// ON DELETE CASCADE rules still carry the old foreign keys.
+func (r *Repo) Delete(ctx context.Context, userID, policy string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin delete account: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, q := range []string{
+		`DELETE FROM user_progress_history WHERE user_id = ?`,
+		`DELETE FROM user_progress WHERE user_id = ?`,
+		`DELETE FROM chat_room_members WHERE user_id = ?`,
+		`DELETE FROM chat_room_moderators WHERE user_id = ?`,
+		`DELETE FROM chat_sanctions WHERE user_id = ?`,
+		`DELETE FROM chat_read_markers WHERE user_id = ?`,
+		`UPDATE chat_rooms SET owner_id = NULL WHERE owner_id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, userID); err != nil {
+			return fmt.Errorf("delete account data: %w", err)
+		}
+	}
+
+	var res sql.Result
+	switch policy {
+	case PolicyAnonymize:
+		// the username is longer than registration allows, so it can never
+		// collide with a real account; "!" is not a valid bcrypt hash
+		res, err = tx.ExecContext(ctx, `
+			UPDATE users
+			SET username = 'deleted-' || id,
+				email = id || '@deleted.invalid',
+				password_hash = '!',
+				token_version = token_version + 1,
+				deleted_at = CURRENT_TIMESTAMP
+			WHERE id = ? AND deleted_at IS NULL
+		`, userID)
+		if err == nil {
+			// chat keeps the username as of posting; point it at the placeholder
+			_, err = tx.ExecContext(ctx, `
+				UPDATE chat_messages SET username = 'deleted-' || user_id
+				WHERE user_id = ?
+			`, userID)
+		}
+	default:
+		if _, err := tx.ExecContext(ctx, `DELETE FROM reviews WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("delete reviews: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM chat_messages WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("delete chat messages: %w", err)
+		}
+		res, err = tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("delete user: not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete account: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Package apperr defines transport-neutral domain errors. Services return
+// them; the gin handlers and the gRPC server translate them with HTTPStatus
+// and GRPC so both APIs answer the same failure the same way.
+package apperr
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindNotFound
+	KindConflict
+	KindUnauthenticated
+	KindPermissionDenied
+	KindUnavailable
+)
+
+var mapping = map[Kind]struct {
+	http int
+	grpc codes.Code
+}{
+	KindInternal:         {http.StatusInternalServerError, codes.Internal},
+	KindInvalid:          {http.StatusBadRequest, codes.InvalidArgument},
+	KindNotFound:         {http.StatusNotFound, codes.NotFound},
+	KindConflict:         {http.StatusConflict, codes.AlreadyExists},
+	KindUnauthenticated:  {http.StatusUnauthorized, codes.Unauthenticated},
+	KindPermissionDenied: {http.StatusForbidden, codes.PermissionDenied},
+	KindUnavailable:      {http.StatusServiceUnavailable, codes.Unavailable},
+}
+
+// Error is a domain error. Msg is safe to show to clients; Err, if any, is
+// the underlying cause and only ends up in logs.
+type Error struct {
+	Kind Kind
+	Msg  string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Msg + ": " + e.Err.Error()
+	}
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+func Invalid(msg string) error          { return &Error{Kind: KindInvalid, Msg: msg} }
+func NotFound(msg string) error         { return &Error{Kind: KindNotFound, Msg: msg} }
+func Conflict(msg string) error         { return &Error{Kind: KindConflict, Msg: msg} }
+func Unauthenticated(msg string) error  { return &Error{Kind: KindUnauthenticated, Msg: msg} }
+func PermissionDenied(msg string) error { return &Error{Kind: KindPermissionDenied, Msg: msg} }
+func Unavailable(msg string) error      { return &Error{Kind: KindUnavailable, Msg: msg} }
+
+// Internal wraps an unexpected failure; msg ("save failed") is what clients
+// see.
+func Internal(msg string, err error) error {
+	return &Error{Kind: KindInternal, Msg: msg, Err: err}
+}
+
+// KindOf reports the kind of err; errors that are not *Error are internal.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// Message returns the client-facing text for err.
+func Message(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Msg
+	}
+	return "internal error"
+}
+
+func HTTPStatus(err error) int {
+	return mapping[KindOf(err)].http
+}
+
+// GRPC converts err to a status error with the matching code.
+func GRPC(err error) error {
+	return status.Error(mapping[KindOf(err)].grpc, Message(err))
+}
+
+// JSON writes err in the API's usual {"error": "..."} shape.
+func JSON(c *gin.Context, err error) {
+	c.JSON(HTTPStatus(err), gin.H{"error": Message(err)})
+}
@@ -0,0 +1,17 @@
+package auth
+
+import "context"
+
+type claimsCtxKey struct{}
+
+// WithClaims returns a context carrying the authenticated caller. Transports
+// without gin (gRPC) use this instead of CtxClaimsKey.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsCtxKey{}, claims)
+}
+
+// ClaimsFromContext returns the caller stored by WithClaims, or nil.
+func ClaimsFromContext(ctx context.Context) *Claims {
+	claims, _ := ctx.Value(claimsCtxKey{}).(*Claims)
+	return claims
+}
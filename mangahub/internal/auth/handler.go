@@ -2,21 +2,21 @@ package auth
 
 import (
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+
+	"mangahub/internal/apperr"
 )
 
 type Handler struct {
-	Repo   *Repo
-	Tokens TokenService
+	Repo    *Repo
+	Tokens  TokenService
+	Service Service
 }
 
 func NewHandler(repo *Repo, tokens TokenService) *Handler {
-	return &Handler{Repo: repo, Tokens: tokens}
+	return &Handler{Repo: repo, Tokens: tokens, Service: NewService(repo)}
 }
 
 func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
@@ -26,81 +26,64 @@ func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.POST("/logout", AuthMiddleware(h.Tokens, h.Repo), h.logout)
 }
 
-type registerReq struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+// RegisterAdminRoutes expects a group behind AuthMiddleware and
+// RequireRole(RoleAdmin).
+func (h *Handler) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.PUT("/users/:id/role", h.setRole)
 }
 
-func (h *Handler) register(c *gin.Context) {
-	var req registerReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
-		return
-	}
+// RegisterWellKnown exposes the public signing keys so other services can
+// verify tokens without holding a secret.
+func (h *Handler) RegisterWellKnown(rg *gin.RouterGroup) {
+	rg.GET("/jwks.json", h.jwks)
+}
 
-	req.Username = strings.TrimSpace(req.Username)
-	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+type roleReq struct {
+	Role string `json:"role"`
+}
 
-	if len(req.Username) < 3 || len(req.Username) > 30 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "username must be 3-30 chars"})
-		return
-	}
-	if !strings.Contains(req.Email, "@") || len(req.Email) > 255 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email"})
-		return
-	}
-	if len(req.Password) < 8 || len(req.Password) > 72 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "password must be 8-72 chars"})
+func (h *Handler) setRole(c *gin.Context) {
+	claims := MustGetClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	// uniqueness checks
-	if u, _ := h.Repo.GetByEmail(c.Request.Context(), req.Email); u != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "email already exists"})
-		return
-	}
-	if u, _ := h.Repo.GetByUsername(c.Request.Context(), req.Username); u != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "username already exists"})
+	var req roleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
 		return
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "hash failed"})
+	userID := c.Param("id")
+	if err := h.Service.SetRole(c.Request.Context(), claims.UserID, userID, req.Role); err != nil {
+		apperr.JSON(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"id": userID, "role": req.Role})
+}
 
-	u := User{
-		ID:           uuid.NewString(),
-		Username:     req.Username,
-		Email:        req.Email,
-		PasswordHash: string(hash),
-	}
+type registerReq struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
 
-	if err := h.Repo.CreateUser(c.Request.Context(), u); err != nil {
-		// SQLite unique constraint will also trigger here in races
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "create user failed"})
+func (h *Handler) register(c *gin.Context) {
+	var req registerReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
 		return
 	}
 
-	// auto-login
-	created := &u
-	token, exp, err := h.Tokens.Sign(created)
+	created, err := h.Service.Register(c.Request.Context(), req.Username, req.Email, req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token failed"})
+		apperr.JSON(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"user": gin.H{
-			"id":       created.ID,
-			"username": created.Username,
-			"email":    created.Email,
-		},
-		"token":      token,
-		"expires_at": exp.UTC().Format(time.RFC3339),
-	})
+	// auto-login
+	h.writeToken(c, http.StatusCreated, created)
 }
 
 type loginReq struct {
@@ -115,39 +98,13 @@ func (h *Handler) login(c *gin.Context) {
 		return
 	}
 
-	email := strings.TrimSpace(strings.ToLower(req.Email))
-	if email == "" || req.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "email and password required"})
-		return
-	}
-
-	u, err := h.Repo.GetByEmail(c.Request.Context(), email)
-	if err != nil || u == nil {
-		// don't reveal which part failed
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
-		return
-	}
-
-	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
-		return
-	}
-
-	token, exp, err := h.Tokens.Sign(u)
+	u, err := h.Service.Login(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token failed"})
+		apperr.JSON(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user": gin.H{
-			"id":       u.ID,
-			"username": u.Username,
-			"email":    u.Email,
-		},
-		"token":      token,
-		"expires_at": exp.UTC().Format(time.RFC3339),
-	})
+	h.writeToken(c, http.StatusOK, u)
 }
 
 type changePasswordReq struct {
@@ -161,14 +118,6 @@ func (h *Handler) changePassword(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
 		return
 	}
-	if req.OldPassword == "" || req.NewPassword == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "old and new password required"})
-		return
-	}
-	if len(req.NewPassword) < 8 || len(req.NewPassword) > 72 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "password must be 8-72 chars"})
-		return
-	}
 
 	claims := MustGetClaims(c)
 	if claims == nil {
@@ -176,25 +125,8 @@ func (h *Handler) changePassword(c *gin.Context) {
 		return
 	}
 
-	u, err := h.Repo.GetByID(c.Request.Context(), claims.UserID)
-	if err != nil || u == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-		return
-	}
-
-	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.OldPassword)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
-		return
-	}
-
-	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "hash failed"})
-		return
-	}
-
-	if err := h.Repo.UpdatePasswordAndBumpTokenVersion(c.Request.Context(), u.ID, string(hash)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "update password failed"})
+	if err := h.Service.ChangePassword(c.Request.Context(), claims.UserID, req.OldPassword, req.NewPassword); err != nil {
+		apperr.JSON(c, err)
 		return
 	}
 
@@ -208,10 +140,39 @@ func (h *Handler) logout(c *gin.Context) {
 		return
 	}
 
-	if err := h.Repo.BumpTokenVersion(c.Request.Context(), claims.UserID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "logout failed"})
+	if err := h.Service.Logout(c.Request.Context(), claims.UserID); err != nil {
+		apperr.JSON(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "logged out"})
 }
+
+func (h *Handler) writeToken(c *gin.Context, code int, u *User) {
+	token, exp, err := h.Tokens.Sign(u)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token failed"})
+		return
+	}
+
+	c.JSON(code, gin.H{
+		"user": gin.H{
+			"id":       u.ID,
+			"username": u.Username,
+			"email":    u.Email,
+		},
+		"token":      token,
+		"expires_at": exp.UTC().Format(time.RFC3339),
+	})
+}
+
+func (h *Handler) jwks(c *gin.Context) {
+	// HS256 deployments have nothing to publish
+	doc := JWKS{Keys: []JWK{}}
+	if h.Tokens.Keys != nil {
+		doc = h.Tokens.Keys.JWKS()
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, doc)
+}
@@ -1,16 +1,24 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"mangahub/pkg/utils"
 )
 
 type TokenService struct {
 	Secret   []byte
 	Issuer   string
 	Duration time.Duration
+
+	// Keys, when set, signs tokens with the active asymmetric key and verifies
+	// them by kid. Secret is then only used for HS256 tokens.
+	Keys *KeySet
 }
 
 type Claims struct {
@@ -21,6 +29,34 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// NewTokenService builds a TokenService from config. Asymmetric algorithms load
+// (or create) the keyset file, or fetch public keys from MANGAHUB_JWT_JWKS_URL
+// for services that only verify.
+func NewTokenService(ctx context.Context, cfg utils.AuthConfig) (TokenService, error) {
+	if err := cfg.Validate(); err != nil {
+		return TokenService{}, err
+	}
+
+	ts := TokenService{Issuer: cfg.JWTIssuer, Duration: cfg.JWTDuration}
+	switch {
+	case cfg.JWTAlg == "HS256":
+		ts.Secret = []byte(cfg.JWTSecret)
+	case cfg.JWKSURL != "":
+		keys, err := FetchKeySet(ctx, cfg.JWKSURL)
+		if err != nil {
+			return TokenService{}, err
+		}
+		ts.Keys = keys
+	default:
+		keys, err := LoadKeySet(cfg.JWTKeysPath, cfg.JWTAlg, cfg.JWTDuration)
+		if err != nil {
+			return TokenService{}, err
+		}
+		ts.Keys = keys
+	}
+	return ts, nil
+}
+
 func (ts TokenService) Sign(u *User) (string, time.Time, error) {
 	exp := time.Now().Add(ts.Duration)
 
@@ -37,6 +73,20 @@ func (ts TokenService) Sign(u *User) (string, time.Time, error) {
 		},
 	}
 
+	if ts.Keys != nil {
+		key := ts.Keys.Signing()
+		if key == nil {
+			return "", time.Time{}, errors.New("sign token: no active signing key")
+		}
+		token := jwt.NewWithClaims(signingMethod(key.Alg), claims)
+		token.Header["kid"] = key.ID
+		s, err := token.SignedString(key.private)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("sign token: %w", err)
+		}
+		return s, exp, nil
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	s, err := token.SignedString(ts.Secret)
 	if err != nil {
@@ -47,8 +97,20 @@ func (ts TokenService) Sign(u *User) (string, time.Time, error) {
 
 func (ts TokenService) Parse(tokenString string) (*Claims, error) {
 	tok, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (any, error) {
+		if kid, _ := token.Header["kid"].(string); kid != "" && ts.Keys != nil {
+			key, ok := ts.Keys.Verifier(kid)
+			if !ok {
+				return nil, fmt.Errorf("unknown or expired key id %q", kid)
+			}
+			// the key decides the algorithm, never the token header
+			if token.Method != signingMethod(key.Alg) {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return key.public, nil
+		}
+
 		// enforce HS256
-		if token.Method != jwt.SigningMethodHS256 {
+		if token.Method != jwt.SigningMethodHS256 || len(ts.Secret) == 0 {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return ts.Secret, nil
@@ -63,3 +125,14 @@ func (ts TokenService) Parse(tokenString string) (*Claims, error) {
 	}
 	return claims, nil
 }
+
+func signingMethod(alg string) jwt.SigningMethod {
+	switch alg {
+	case "RS256":
+		return jwt.SigningMethodRS256
+	case "EdDSA":
+		return jwt.SigningMethodEdDSA
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,598 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SigningKey is one asymmetric key in a KeySet. Verify-only keys (loaded from
+// a JWKS document) have no private half.
+type SigningKey struct {
+	ID        string
+	Alg       string // "RS256" or "EdDSA"
+	CreatedAt time.Time
+	RetiredAt time.Time // zero while the key is the active signer
+
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// KeySet holds the signing key plus any retired keys whose tokens may still be
+// in circulation. Retired keys keep verifying for grace after retirement; with
+// a grace of 0 they stop at once.
+type KeySet struct {
+	mu    sync.RWMutex
+	keys  []*SigningKey
+	alg   string
+	grace time.Duration
+
+	path    string // keyset file (signers)
+	jwksURL string // remote JWKS (verify-only)
+
+	// refetchMu serializes JWKS refetches for unknown key ids; lastRefetch
+	// rate-limits them
+	refetchMu   sync.Mutex
+	lastRefetch time.Time
+}
+
+// refetchInterval is how often a verify-only set may re-fetch its JWKS
+// because a token named a key id it does not know.
+const refetchInterval = 30 * time.Second
+
+// keyLockStale is how old a keyset lock file may get before it is taken to
+// be left over from a crashed process and removed.
+const keyLockStale = time.Minute
+
+// JWK is the public part of a key as published on /.well-known/jwks.json.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type keyFile struct {
+	Keys []keyFileEntry `json:"keys"`
+}
+
+type keyFileEntry struct {
+	ID         string    `json:"kid"`
+	Alg        string    `json:"alg"`
+	CreatedAt  time.Time `json:"created_at"`
+	RetiredAt  time.Time `json:"retired_at,omitempty"`
+	PrivateKey string    `json:"private_key"` // PKCS#8 PEM
+}
+
+// LoadKeySet reads the keyset file at path, creating it with a fresh key when
+// it does not exist or has no active key for alg. Replicas starting together
+// wait for whichever of them creates the key.
+func LoadKeySet(path, alg string, grace time.Duration) (*KeySet, error) {
+	ks := &KeySet{alg: alg, grace: grace, path: path}
+
+	keys, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ks.keys = keys
+
+	if ks.signingLocked() == nil {
+		unlock, err := ks.lock(keyLockStale)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+		if err := ks.reload(); err != nil {
+			return nil, err
+		}
+		if ks.Signing() == nil {
+			if err := ks.rotateLocked(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ks, nil
+}
+
+// FetchKeySet builds a verify-only KeySet from a remote JWKS document.
+func FetchKeySet(ctx context.Context, jwksURL string) (*KeySet, error) {
+	ks := &KeySet{jwksURL: jwksURL}
+	if err := ks.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// GenerateKey creates a new key for alg.
+func GenerateKey(alg string) (*SigningKey, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("generate kid: %w", err)
+	}
+
+	k := &SigningKey{ID: hex.EncodeToString(id), Alg: alg, CreatedAt: time.Now().UTC()}
+	switch alg {
+	case "RS256":
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("generate rsa key: %w", err)
+		}
+		k.private, k.public = priv, &priv.PublicKey
+	case "EdDSA":
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("generate ed25519 key: %w", err)
+		}
+		k.private, k.public = priv, pub
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %q", alg)
+	}
+	return k, nil
+}
+
+// Signing returns the active signing key, or nil for verify-only sets.
+func (ks *KeySet) Signing() *SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.signingLocked()
+}
+
+// Verifier returns the key for kid if it may still verify tokens. A
+// verify-only set that does not know kid re-fetches its JWKS first, at
+// most once per refetchInterval, so keys the issuer just rotated in are
+// accepted before the next scheduled refresh.
+func (ks *KeySet) Verifier(kid string) (*SigningKey, bool) {
+	k, known := ks.verifier(kid)
+	if known || ks.jwksURL == "" {
+		return k, k != nil
+	}
+
+	ks.refetchMu.Lock()
+	defer ks.refetchMu.Unlock()
+	// another caller may have fetched it while this one waited
+	if k, known = ks.verifier(kid); known {
+		return k, k != nil
+	}
+	if time.Since(ks.lastRefetch) < refetchInterval {
+		return nil, false
+	}
+	ks.lastRefetch = time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := ks.refresh(ctx); err != nil {
+		log.Printf("[auth] jwks refetch for kid %s failed: %v", kid, err)
+		return nil, false
+	}
+	k, _ = ks.verifier(kid)
+	return k, k != nil
+}
+
+// verifier looks kid up. known is false when the set has no such key; k is
+// nil too when the key's grace period has passed.
+func (ks *KeySet) verifier(kid string) (k *SigningKey, known bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := time.Now()
+	for _, k := range ks.keys {
+		if k.ID != kid {
+			continue
+		}
+		if ks.expiredLocked(k, now) {
+			return nil, true
+		}
+		return k, true
+	}
+	return nil, false
+}
+
+// Rotate generates a new signing key, retires the current one and drops keys
+// whose grace period has passed. The keyset file is re-read and rewritten
+// under its lock file, so rotations by other processes are kept.
+func (ks *KeySet) Rotate() error {
+	if ks.path == "" {
+		return errors.New("rotate: keyset is verify-only")
+	}
+	unlock, err := ks.lock(keyLockStale)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := ks.reload(); err != nil {
+		return err
+	}
+	return ks.rotateLocked()
+}
+
+// rotateLocked is Rotate for a caller holding the keyset lock file.
+func (ks *KeySet) rotateLocked() error {
+	next, err := GenerateKey(ks.alg)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	now := time.Now().UTC()
+	kept := make([]*SigningKey, 0, len(ks.keys)+1)
+	for _, k := range ks.keys {
+		if k.RetiredAt.IsZero() {
+			k.RetiredAt = now
+		}
+		if ks.expiredLocked(k, now) {
+			continue
+		}
+		kept = append(kept, k)
+	}
+	ks.keys = append(kept, next)
+
+	if err := ks.saveLocked(); err != nil {
+		return err
+	}
+	log.Printf("[auth] rotated signing key: kid=%s alg=%s", next.ID, next.Alg)
+	return nil
+}
+
+// RotateIfDue rotates when the active key is older than every. When
+// another process holds the keyset lock file it leaves the rotation to it
+// and returns false; a key another process already rotated in is picked up
+// instead of being rotated again.
+func (ks *KeySet) RotateIfDue(every time.Duration) (bool, error) {
+	if every <= 0 || ks.path == "" || !ks.due(every) {
+		return false, nil
+	}
+	unlock, err := ks.lock(0)
+	if errors.Is(err, errKeysLocked) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	if err := ks.reload(); err != nil {
+		return false, err
+	}
+	if !ks.due(every) {
+		return false, nil
+	}
+	if err := ks.rotateLocked(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (ks *KeySet) due(every time.Duration) bool {
+	active := ks.Signing()
+	return active == nil || time.Since(active.CreatedAt) >= every
+}
+
+var errKeysLocked = errors.New("keyset is locked by another process")
+
+// lock takes the keyset's lock file, waiting up to wait for another process
+// to release it; errKeysLocked means it did not. A lock file older than
+// keyLockStale is removed as abandoned.
+func (ks *KeySet) lock(wait time.Duration) (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(ks.path), 0o700); err != nil {
+		return nil, fmt.Errorf("keyset dir: %w", err)
+	}
+	name := ks.path + ".lock"
+	deadline := time.Now().Add(wait)
+	for {
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(name) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("lock keyset: %w", err)
+		}
+		if fi, err := os.Stat(name); err == nil && time.Since(fi.ModTime()) > keyLockStale {
+			log.Printf("[auth] removing stale keyset lock %s", name)
+			_ = os.Remove(name)
+			continue
+		}
+		if !time.Now().Before(deadline) {
+			return nil, errKeysLocked
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Maintain keeps the set current until ctx is done. With rotateEvery > 0 the
+// process rotates the keyset file on schedule; replicas sharing the file
+// take turns through its lock file, so one of them rotates and the rest
+// pick the new key up on their next re-read. With 0 it only re-reads the
+// file. JWKS-backed sets re-fetch the document so new keys are picked up.
+func (ks *KeySet) Maintain(ctx context.Context, rotateEvery time.Duration) {
+	tick := 5 * time.Minute
+	if ks.path != "" && rotateEvery > 0 && rotateEvery/4 < tick {
+		tick = rotateEvery / 4
+	}
+
+	t := time.NewTicker(tick)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		if ks.jwksURL != "" {
+			if err := ks.refresh(ctx); err != nil {
+				log.Printf("[auth] jwks refresh failed: %v", err)
+			}
+			continue
+		}
+		if err := ks.reload(); err != nil {
+			log.Printf("[auth] keyset reload failed: %v", err)
+		}
+		if rotateEvery <= 0 {
+			continue
+		}
+		if _, err := ks.RotateIfDue(rotateEvery); err != nil {
+			log.Printf("[auth] key rotation failed: %v", err)
+		}
+	}
+}
+
+// JWKS returns the public keys that may still verify tokens.
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := time.Now()
+	out := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, k := range ks.keys {
+		if ks.expiredLocked(k, now) {
+			continue
+		}
+		if jwk, err := publicJWK(k); err == nil {
+			out.Keys = append(out.Keys, jwk)
+		}
+	}
+	return out
+}
+
+// expiredLocked reports whether k was retired more than the grace period
+// before now. Active keys, and keys from a JWKS document, never expire here.
+func (ks *KeySet) expiredLocked(k *SigningKey, now time.Time) bool {
+	return !k.RetiredAt.IsZero() && !now.Before(k.RetiredAt.Add(ks.grace))
+}
+
+func (ks *KeySet) signingLocked() *SigningKey {
+	var active *SigningKey
+	for _, k := range ks.keys {
+		if k.private == nil || !k.RetiredAt.IsZero() || k.Alg != ks.alg {
+			continue
+		}
+		if active == nil || k.CreatedAt.After(active.CreatedAt) {
+			active = k
+		}
+	}
+	return active
+}
+
+func (ks *KeySet) saveLocked() error {
+	kf := keyFile{Keys: make([]keyFileEntry, 0, len(ks.keys))}
+	for _, k := range ks.keys {
+		der, err := x509.MarshalPKCS8PrivateKey(k.private)
+		if err != nil {
+			return fmt.Errorf("marshal key %s: %w", k.ID, err)
+		}
+		kf.Keys = append(kf.Keys, keyFileEntry{
+			ID:         k.ID,
+			Alg:        k.Alg,
+			CreatedAt:  k.CreatedAt,
+			RetiredAt:  k.RetiredAt,
+			PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		})
+	}
+	sort.Slice(kf.Keys, func(i, j int) bool { return kf.Keys[i].CreatedAt.Before(kf.Keys[j].CreatedAt) })
+
+	b, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode keyset: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(ks.path), 0o700); err != nil {
+		return fmt.Errorf("keyset dir: %w", err)
+	}
+
+	// write-then-rename so a crash never leaves a truncated keyset behind
+	tmp := ks.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("write keyset: %w", err)
+	}
+	if err := os.Rename(tmp, ks.path); err != nil {
+		return fmt.Errorf("replace keyset: %w", err)
+	}
+	return nil
+}
+
+func (ks *KeySet) reload() error {
+	keys, err := readKeyFile(ks.path)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+	return nil
+}
+
+func (ks *KeySet) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.jwksURL, nil)
+	if err != nil {
+		return fmt.Errorf("jwks request: %w", err)
+	}
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return fmt.Errorf("jwks fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("jwks fetch: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var doc JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("jwks decode: %w", err)
+	}
+
+	keys := make([]*SigningKey, 0, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		k, err := parseJWK(jwk)
+		if err != nil {
+			log.Printf("[auth] skipping jwks key %s: %v", jwk.Kid, err)
+			continue
+		}
+		keys = append(keys, k)
+	}
+
+	// the publisher only lists keys that are still valid, so the remote
+	// document is authoritative and replaces the local view wholesale
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+	return nil
+}
+
+func readKeyFile(path string) ([]*SigningKey, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read keyset: %w", err)
+	}
+
+	var kf keyFile
+	if err := json.Unmarshal(b, &kf); err != nil {
+		return nil, fmt.Errorf("decode keyset: %w", err)
+	}
+	keys := make([]*SigningKey, 0, len(kf.Keys))
+	for _, e := range kf.Keys {
+		k, err := decodeKeyEntry(e)
+		if err != nil {
+			return nil, fmt.Errorf("keyset key %s: %w", e.ID, err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func decodeKeyEntry(e keyFileEntry) (*SigningKey, error) {
+	block, _ := pem.Decode([]byte(e.PrivateKey))
+	if block == nil {
+		return nil, errors.New("invalid PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+
+	k := &SigningKey{ID: e.ID, Alg: e.Alg, CreatedAt: e.CreatedAt, RetiredAt: e.RetiredAt}
+	switch priv := parsed.(type) {
+	case *rsa.PrivateKey:
+		k.private, k.public = priv, &priv.PublicKey
+	case ed25519.PrivateKey:
+		k.private, k.public = priv, priv.Public()
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+	return k, nil
+}
+
+func publicJWK(k *SigningKey) (JWK, error) {
+	b64 := base64.RawURLEncoding
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: k.ID,
+			Alg: k.Alg,
+			Use: "sig",
+			N:   b64.EncodeToString(pub.N.Bytes()),
+			E:   b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: k.ID,
+			Alg: k.Alg,
+			Use: "sig",
+			Crv: "Ed25519",
+			X:   b64.EncodeToString(pub),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", k.public)
+	}
+}
+
+func parseJWK(jwk JWK) (*SigningKey, error) {
+	b64 := base64.RawURLEncoding
+	k := &SigningKey{ID: jwk.Kid, Alg: jwk.Alg}
+	switch jwk.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode n: %w", err)
+		}
+		e, err := b64.DecodeString(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode e: %w", err)
+		}
+		k.public = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if k.Alg == "" {
+			k.Alg = "RS256"
+		}
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := b64.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 public key")
+		}
+		k.public = ed25519.PublicKey(x)
+		if k.Alg == "" {
+			k.Alg = "EdDSA"
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+	return k, nil
+}
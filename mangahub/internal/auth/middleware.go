@@ -39,6 +39,68 @@ func AuthMiddleware(tokens TokenService, repo *Repo) gin.HandlerFunc {
 	}
 }
 
+// TokenSubprotocol is the WebSocket subprotocol that carries a token.
+// Browsers cannot set headers on WebSocket handshakes, so clients may offer
+// the protocols ["bearer", "<token>"]; the server selects "bearer".
+const TokenSubprotocol = "bearer"
+
+// ClaimsFromRequest returns a resolver for the claims behind an upgrade
+// request. The token is taken from the Authorization header, the
+// TokenSubprotocol pair or the "token" query parameter, in that order. It
+// yields nil when no valid token is present.
+func ClaimsFromRequest(tokens TokenService, repo *Repo) func(r *http.Request) *Claims {
+	return func(r *http.Request) *Claims {
+		raw := requestToken(r)
+		if raw == "" {
+			return nil
+		}
+
+		claims, err := tokens.Parse(raw)
+		if err != nil {
+			return nil
+		}
+		if repo != nil {
+			currentVersion, err := repo.GetTokenVersion(r.Context(), claims.UserID)
+			if err != nil || currentVersion != claims.TokenVersion {
+				return nil
+			}
+		}
+		return claims
+	}
+}
+
+// UserFromRequest is ClaimsFromRequest reduced to the user id; it yields ""
+// when no valid token is present.
+func UserFromRequest(tokens TokenService, repo *Repo) func(r *http.Request) string {
+	claimsOf := ClaimsFromRequest(tokens, repo)
+	return func(r *http.Request) string {
+		if claims := claimsOf(r); claims != nil {
+			return claims.UserID
+		}
+		return ""
+	}
+}
+
+func requestToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(strings.ToLower(h), "bearer ") {
+		return strings.TrimSpace(h[len("Bearer "):])
+	}
+
+	var protocols []string
+	for _, h := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(h, ",") {
+			protocols = append(protocols, strings.TrimSpace(p))
+		}
+	}
+	for i := 0; i+1 < len(protocols); i++ {
+		if protocols[i] == TokenSubprotocol {
+			return protocols[i+1]
+		}
+	}
+
+	return strings.TrimSpace(r.URL.Query().Get("token"))
+}
+
 func MustGetClaims(c *gin.Context) *Claims {
 	v, ok := c.Get(CtxClaimsKey)
 	if !ok {
@@ -3,11 +3,15 @@ package auth
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 )
 
+// ErrUserNotFound is returned when a lookup that must match a user does not.
+var ErrUserNotFound = errors.New("user not found")
+
 type User struct {
 	ID           string
 	Username     string
@@ -100,7 +104,8 @@ func (r *Repo) GetTokenVersion(ctx context.Context, id string) (int, error) {
 	var version int
 	if err := row.Scan(&version); err != nil {
 		if err == sql.ErrNoRows {
-			return 0, nil
+			// a deleted account must not keep validating old tokens
+			return 0, ErrUserNotFound
 		}
 		return 0, fmt.Errorf("get token version: %w", err)
 	}
@@ -158,3 +163,50 @@ func (r *Repo) BumpTokenVersion(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func (r *Repo) GetRole(ctx context.Context, id string) (string, error) {
+	var role string
+	err := r.DB.QueryRowContext(ctx, `SELECT role FROM users WHERE id = ?`, id).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("get role: %w", err)
+	}
+	return role, nil
+}
+
+func (r *Repo) SetRole(ctx context.Context, id, role string) error {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE users SET role = ? WHERE id = ? AND deleted_at IS NULL
+	`, role, id)
+	if err != nil {
+		return fmt.Errorf("set role: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("set role rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// PromoteAdmins makes the accounts with the given emails admins and returns
+// how many were changed. Emails without an account are skipped.
+func (r *Repo) PromoteAdmins(ctx context.Context, emails []string) (int, error) {
+	total := 0
+	for _, email := range emails {
+		res, err := r.DB.ExecContext(ctx, `
+			UPDATE users SET role = 'admin'
+			WHERE LOWER(email) = ? AND role <> 'admin' AND deleted_at IS NULL
+		`, strings.TrimSpace(strings.ToLower(email)))
+		if err != nil {
+			return total, fmt.Errorf("promote admin: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		total += int(n)
+	}
+	return total, nil
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Site-wide roles, stored in users.role. Moderators may moderate every chat
+// room; admins additionally manage roles and admin endpoints.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+func ValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// RequireRole lets through callers whose current role is one of roles. The
+// role is read from the database on every request, so promotions and
+// demotions apply without a new token. It must run after AuthMiddleware.
+func RequireRole(repo *Repo, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := MustGetClaims(c)
+		if claims == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			c.Abort()
+			return
+		}
+
+		role, err := repo.GetRole(c.Request.Context(), claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			c.Abort()
+			return
+		}
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		c.Abort()
+	}
+}
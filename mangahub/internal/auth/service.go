@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"mangahub/internal/apperr"
+)
+
+var (
+	ErrEmailTaken         = apperr.Conflict("email already exists")
+	ErrUsernameTaken      = apperr.Conflict("username already exists")
+	ErrInvalidCredentials = apperr.Unauthenticated("invalid credentials")
+)
+
+// Service holds the account rules shared by the HTTP handler and gRPC. Its
+// errors are apperr errors.
+type Service struct {
+	Repo *Repo
+
+	// OnRevoke hooks drop the live connections (sync and chat sockets and
+	// streams) of a user whose tokens were just revoked. Tokens are only
+	// checked when a connection opens, so without them those connections
+	// would outlive the revocation.
+	OnRevoke []func(userID string)
+}
+
+func NewService(repo *Repo) Service {
+	return Service{Repo: repo}
+}
+
+// Register validates and creates a user.
+func (s Service) Register(ctx context.Context, username, email, password string) (*User, error) {
+	username = strings.TrimSpace(username)
+	email = strings.TrimSpace(strings.ToLower(email))
+
+	if len(username) < 3 || len(username) > 30 {
+		return nil, apperr.Invalid("username must be 3-30 chars")
+	}
+	if !strings.Contains(email, "@") || len(email) > 255 {
+		return nil, apperr.Invalid("invalid email")
+	}
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
+
+	// uniqueness checks
+	if u, _ := s.Repo.GetByEmail(ctx, email); u != nil {
+		return nil, ErrEmailTaken
+	}
+	if u, _ := s.Repo.GetByUsername(ctx, username); u != nil {
+		return nil, ErrUsernameTaken
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, apperr.Internal("hash failed", err)
+	}
+
+	u := &User{
+		ID:           uuid.NewString(),
+		Username:     username,
+		Email:        email,
+		PasswordHash: string(hash),
+	}
+	// SQLite unique constraint will also trigger here in races
+	if err := s.Repo.CreateUser(ctx, *u); err != nil {
+		return nil, apperr.Internal("create user failed", err)
+	}
+	return u, nil
+}
+
+// Login checks credentials without revealing which part failed.
+func (s Service) Login(ctx context.Context, email, password string) (*User, error) {
+	email = strings.TrimSpace(strings.ToLower(email))
+	if email == "" || password == "" {
+		return nil, apperr.Invalid("email and password required")
+	}
+
+	u, err := s.Repo.GetByEmail(ctx, email)
+	if err != nil || u == nil {
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return u, nil
+}
+
+// ChangePassword replaces the password and revokes existing tokens.
+func (s Service) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error {
+	if oldPassword == "" || newPassword == "" {
+		return apperr.Invalid("old and new password required")
+	}
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	u, err := s.Repo.GetByID(ctx, userID)
+	if err != nil {
+		return apperr.Internal("update password failed", err)
+	}
+	if u == nil {
+		return apperr.Unauthenticated("invalid token")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(oldPassword)); err != nil {
+		return ErrInvalidCredentials
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return apperr.Internal("hash failed", err)
+	}
+	if err := s.Repo.UpdatePasswordAndBumpTokenVersion(ctx, u.ID, string(hash)); err != nil {
+		return apperr.Internal("update password failed", err)
+	}
+	s.revoked(u.ID)
+	return nil
+}
+
+// Logout revokes every token issued to the user so far.
+func (s Service) Logout(ctx context.Context, userID string) error {
+	if err := s.Repo.BumpTokenVersion(ctx, userID); err != nil {
+		return apperr.Internal("logout failed", err)
+	}
+	s.revoked(userID)
+	return nil
+}
+
+func (s Service) revoked(userID string) {
+	for _, fn := range s.OnRevoke {
+		fn(userID)
+	}
+}
+
+// SetRole changes userID's site role on behalf of actorID, who may not
+// change their own role.
+func (s Service) SetRole(ctx context.Context, actorID, userID, role string) error {
+	role = strings.TrimSpace(strings.ToLower(role))
+	if !ValidRole(role) {
+		return apperr.Invalid("role must be user, moderator or admin")
+	}
+	if actorID == userID {
+		return apperr.Invalid("you cannot change your own role")
+	}
+	if err := s.Repo.SetRole(ctx, userID, role); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return apperr.NotFound("user not found")
+		}
+		return apperr.Internal("set role failed", err)
+	}
+	return nil
+}
+
+func validatePassword(password string) error {
+	// bcrypt ignores anything past 72 bytes
+	if len(password) < 8 || len(password) > 72 {
+		return apperr.Invalid("password must be 8-72 chars")
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package bus carries hub traffic between api-server replicas, so chat and
+// library sync reach users whichever replica they are connected to.
+package bus
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/utils"
+)
+
+// Handler receives the payload of a message published on a topic it
+// subscribed to, and its sequence number: every process sharing a broker
+// sees the same number for the same message, and later messages get higher
+// ones (see Broker.Position), and each process is handed messages in the
+// order of their numbers. seq is 0 when the message could not be numbered
+// and only reached this process. Handlers run on the publisher's goroutine
+// for local messages and on the broker's for remote ones, with the
+// broker's delivery lock held, so they must not block or publish.
+type Handler func(seq int64, payload []byte)
+
+// Broker fans published payloads out to every subscriber of a topic: in
+// this process, and with a shared backend in every other process using it.
+// Publishers receive their own messages too, so a hub publishes and then
+// does its local fan-out from its subscription alone.
+type Broker interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+	Subscribe(topic string, h Handler) (unsubscribe func())
+	// Position names the stream messages are numbered in, and returns the
+	// number of the last message published before this broker started.
+	// Numbers from different streams are unrelated.
+	Position() (stream string, seq int64)
+	Close() error
+}
+
+// New returns the broker cfg asks for. The sqlite backend uses db unless
+// cfg.Path names a database of its own.
+func New(cfg utils.BusConfig, db *sql.DB) (Broker, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemory(), nil
+	case "sqlite":
+		own := false
+		if cfg.Path != "" {
+			var err error
+			if db, err = database.Open(database.Config{Path: cfg.Path}); err != nil {
+				return nil, fmt.Errorf("open bus database: %w", err)
+			}
+			own = true
+		}
+		b, err := NewSQLite(db, cfg.PollInterval, cfg.Retention)
+		if err != nil {
+			if own {
+				_ = db.Close()
+			}
+			return nil, err
+		}
+		b.ownDB = own
+		return b, nil
+	default:
+		return nil, fmt.Errorf("unknown bus backend %q (want memory or sqlite)", cfg.Backend)
+	}
+}
+
+// subscribers is the local half of every Broker.
+type subscribers struct {
+	mu     sync.RWMutex
+	next   int
+	topics map[string]map[int]Handler
+}
+
+func (s *subscribers) Subscribe(topic string, h Handler) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.topics == nil {
+		s.topics = make(map[string]map[int]Handler)
+	}
+	if s.topics[topic] == nil {
+		s.topics[topic] = make(map[int]Handler)
+	}
+	s.next++
+	id := s.next
+	s.topics[topic][id] = h
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.topics[topic], id)
+		})
+	}
+}
+
+// dispatch hands payload to the topic's handlers. A panicking handler is
+// logged and does not stop the others.
+func (s *subscribers) dispatch(topic string, seq int64, payload []byte) {
+	s.mu.RLock()
+	handlers := make([]Handler, 0, len(s.topics[topic]))
+	for _, h := range s.topics[topic] {
+		handlers = append(handlers, h)
+	}
+	s.mu.RUnlock()
+
+	for _, h := range handlers {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					log.Printf("[bus] %s handler panicked: %v", topic, p)
+				}
+			}()
+			h(seq, payload)
+		}()
+	}
+}
+
+// Memory is a Broker for a single process. Publish delivers synchronously.
+type Memory struct {
+	subscribers
+
+	stream string
+	mu     sync.Mutex // held from numbering a message until it is delivered
+	seq    int64
+}
+
+func NewMemory() *Memory {
+	return &Memory{stream: uuid.NewString()}
+}
+
+func (m *Memory) Publish(_ context.Context, topic string, payload []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	m.dispatch(topic, m.seq, payload)
+	return nil
+}
+
+// Position is a stream of this broker's own, so numbers from before a
+// restart are never mistaken for current ones.
+func (m *Memory) Position() (string, int64) { return m.stream, 0 }
+
+func (m *Memory) Close() error { return nil }
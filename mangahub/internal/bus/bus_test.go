@@ -0,0 +1,238 @@
+package bus
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"mangahub/pkg/database"
+)
+
+// openDB opens the database file at path the way a separate process would:
+// with a pool of its own.
+func openDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := database.Open(database.Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func newSQLite(t *testing.T, db *sql.DB, retention time.Duration) *SQLite {
+	t.Helper()
+	b, err := NewSQLite(db, 5*time.Millisecond, retention)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = b.Close() })
+	return b
+}
+
+// recorder collects what a subscription is handed.
+type recorder struct {
+	mu   sync.Mutex
+	seqs []int64
+	msgs []string
+}
+
+func (r *recorder) handle(seq int64, payload []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seqs = append(r.seqs, seq)
+	r.msgs = append(r.msgs, string(payload))
+}
+
+func (r *recorder) snapshot() ([]int64, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int64(nil), r.seqs...), append([]string(nil), r.msgs...)
+}
+
+// waitFor polls until r holds n messages, then waits a few more polls so
+// duplicates would show up too.
+func (r *recorder) waitFor(t *testing.T, n int) ([]int64, []string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		seqs, _ := r.snapshot()
+		if len(seqs) >= n {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d messages, want %d", len(seqs), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	return r.snapshot()
+}
+
+func TestSQLiteDeliversEachMessageOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bus.db")
+	a := newSQLite(t, openDB(t, path), 0)
+	b := newSQLite(t, openDB(t, path), 0)
+
+	var ra, rb recorder
+	a.Subscribe("t", ra.handle)
+	b.Subscribe("t", rb.handle)
+	b.Subscribe("other", func(int64, []byte) { t.Error("delivered to another topic") })
+
+	ctx := context.Background()
+	if err := a.Publish(ctx, "t", []byte("from a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(ctx, "t", []byte("from b")); err != nil {
+		t.Fatal(err)
+	}
+
+	seqsA, msgsA := ra.waitFor(t, 2)
+	seqsB, msgsB := rb.waitFor(t, 2)
+	if len(msgsA) != 2 || len(msgsB) != 2 {
+		t.Fatalf("a got %q, b got %q; want each message once", msgsA, msgsB)
+	}
+	for _, r := range []struct {
+		seqs []int64
+		msgs []string
+	}{{seqsA, msgsA}, {seqsB, msgsB}} {
+		if r.msgs[0] != "from a" || r.msgs[1] != "from b" || r.seqs[0] != 1 || r.seqs[1] != 2 {
+			t.Errorf("got %q numbered %v, want [from a from b] numbered [1 2]", r.msgs, r.seqs)
+		}
+	}
+}
+
+func TestSQLitePositionSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bus.db")
+	ctx := context.Background()
+
+	first, err := NewSQLite(openDB(t, path), 5*time.Millisecond, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, start := first.Position()
+	if start != 0 {
+		t.Fatalf("fresh database starts at %d", start)
+	}
+	for i := 0; i < 3; i++ {
+		if err := first.Publish(ctx, "t", []byte("old")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := newSQLite(t, openDB(t, path), 0)
+	gotStream, gotStart := restarted.Position()
+	if gotStream != stream || gotStart != 3 {
+		t.Fatalf("Position() = %q, %d; want %q, 3", gotStream, gotStart, stream)
+	}
+	var r recorder
+	restarted.Subscribe("t", r.handle)
+
+	other := newSQLite(t, openDB(t, path), 0)
+	if err := other.Publish(ctx, "t", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	seqs, msgs := r.waitFor(t, 1)
+	if len(msgs) != 1 || msgs[0] != "new" || seqs[0] != 4 {
+		t.Fatalf("got %q numbered %v, want only [new] numbered [4]", msgs, seqs)
+	}
+}
+
+func TestSQLitePrunesPastRetention(t *testing.T) {
+	db := openDB(t, filepath.Join(t.TempDir(), "bus.db"))
+	b := newSQLite(t, db, 40*time.Millisecond)
+	if err := b.Publish(context.Background(), "t", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM bus_messages`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d messages left after retention", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// ids keep counting up past pruned messages
+	_, start := newSQLite(t, db, 0).Position()
+	if start != 1 {
+		t.Fatalf("Position() after prune = %d, want 1", start)
+	}
+	if err := b.Publish(context.Background(), "t", []byte("y")); err != nil {
+		t.Fatal(err)
+	}
+	var id int64
+	if err := db.QueryRow(`SELECT MAX(id) FROM bus_messages`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	if id != 2 {
+		t.Fatalf("id after prune = %d, want 2", id)
+	}
+}
+
+func TestConcurrentPublishDeliversInOrder(t *testing.T) {
+	const workers, each = 8, 25
+	path := filepath.Join(t.TempDir(), "bus.db")
+	a := newSQLite(t, openDB(t, path), 0)
+	b := newSQLite(t, openDB(t, path), 0)
+
+	tests := []struct {
+		name       string
+		publishers []Broker
+		subscriber Broker
+	}{
+		{"memory", []Broker{NewMemory()}, nil},
+		{"sqlite", []Broker{a, b}, b},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := tt.subscriber
+			if sub == nil {
+				sub = tt.publishers[0]
+			}
+			var r recorder
+			sub.Subscribe("t", r.handle)
+
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				for _, p := range tt.publishers {
+					wg.Add(1)
+					go func(p Broker, w int) {
+						defer wg.Done()
+						for i := 0; i < each; i++ {
+							if err := p.Publish(context.Background(), "t", []byte(fmt.Sprint(w, i))); err != nil {
+								t.Error(err)
+							}
+						}
+					}(p, w)
+				}
+			}
+			wg.Wait()
+
+			want := workers * each * len(tt.publishers)
+			seqs, _ := r.waitFor(t, want)
+			if len(seqs) != want {
+				t.Fatalf("got %d messages, want %d", len(seqs), want)
+			}
+			for i := 1; i < len(seqs); i++ {
+				if seqs[i] <= seqs[i-1] {
+					t.Fatalf("seq %d delivered after %d", seqs[i], seqs[i-1])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package bus
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPollInterval = 100 * time.Millisecond
+	defaultRetention    = time.Minute
+	pollBatch           = 500
+)
+
+// SQLite is a Broker shared by every process using the same database file,
+// which is enough to run several replicas on one host or a shared volume.
+// Messages are appended to bus_messages, numbered by their row id, and
+// delivered to local subscribers at once, after any earlier ids this
+// process has not seen yet; other processes pick them up on their next
+// poll. Rows older than the retention are pruned, so a process that
+// stalls for longer misses them, just as a dropped connection would.
+type SQLite struct {
+	subscribers
+
+	db        *sql.DB
+	ownDB     bool // opened by New, closed by Close
+	origin    string
+	stream    string // bus_stream.id, kept for the life of the database
+	startID   int64  // last message published before this broker started
+	poll      time.Duration
+	retention time.Duration
+
+	mu        sync.Mutex // held from numbering a message until it is delivered; guards lastID
+	lastID    int64
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSQLite creates bus_messages in db if needed and starts polling it.
+// Only messages published from now on are delivered.
+func NewSQLite(db *sql.DB, poll, retention time.Duration) (*SQLite, error) {
+	if poll <= 0 {
+		poll = defaultPollInterval
+	}
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+
+	// the table lives here rather than in docs/schema.sql because the bus
+	// may have a database file of its own
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS bus_messages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			topic TEXT NOT NULL,
+			origin TEXT NOT NULL,
+			payload BLOB NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_bus_messages_created ON bus_messages(created_at);
+		CREATE TABLE IF NOT EXISTS bus_stream (
+			one INTEGER PRIMARY KEY CHECK (one = 1),
+			id TEXT NOT NULL
+		);
+	`); err != nil {
+		return nil, fmt.Errorf("create bus_messages: %w", err)
+	}
+	// names this database's message ids, so ids from a database that was
+	// replaced are not taken for current ones
+	if _, err := db.Exec(`INSERT OR IGNORE INTO bus_stream (one, id) VALUES (1, ?)`, uuid.NewString()); err != nil {
+		return nil, fmt.Errorf("create bus stream: %w", err)
+	}
+
+	b := &SQLite{
+		db:        db,
+		origin:    uuid.NewString(),
+		poll:      poll,
+		retention: retention,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if err := db.QueryRow(`SELECT id FROM bus_stream`).Scan(&b.stream); err != nil {
+		return nil, fmt.Errorf("bus stream: %w", err)
+	}
+	// the last id handed out, which outlives the row once it is pruned
+	if err := db.QueryRow(`
+		SELECT COALESCE((SELECT seq FROM sqlite_sequence WHERE name = 'bus_messages'), 0)
+	`).Scan(&b.lastID); err != nil {
+		return nil, fmt.Errorf("bus position: %w", err)
+	}
+	b.startID = b.lastID
+	go b.run()
+	return b, nil
+}
+
+// Publish stores payload for the other processes, then delivers it
+// locally under its row id, after the messages other processes numbered
+// before it. An error means only this process saw it, with seq 0.
+func (b *SQLite) Publish(ctx context.Context, topic string, payload []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	res, err := b.db.ExecContext(ctx, `
+		INSERT INTO bus_messages (topic, origin, payload, created_at) VALUES (?, ?, ?, ?)
+	`, topic, b.origin, payload, time.Now().UTC())
+	var id int64
+	if err == nil {
+		id, err = res.LastInsertId()
+	}
+	if err != nil {
+		b.dispatch(topic, 0, payload)
+		return fmt.Errorf("publish to bus: %w", err)
+	}
+	// ids are handed out as rows commit, so everything below id is readable
+	// by now. If it cannot be read, deliver anyway and leave lastID for the
+	// poll: it delivers the rest late rather than not at all, and skips
+	// this row as our own.
+	if err := b.fetch(id - 1); err != nil {
+		log.Printf("[bus] catch up before %d: %v", id, err)
+	} else {
+		b.lastID = id
+	}
+	b.dispatch(topic, id, payload)
+	return nil
+}
+
+// Position is the database's stream and the last message id when the
+// broker started.
+func (b *SQLite) Position() (string, int64) { return b.stream, b.startID }
+
+// Close stops polling. It does not close a database it was handed.
+func (b *SQLite) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.stop)
+		<-b.done
+	})
+	if b.ownDB {
+		return b.db.Close()
+	}
+	return nil
+}
+
+func (b *SQLite) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.poll)
+	defer ticker.Stop()
+	lastPrune := time.Now()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+		b.mu.Lock()
+		err := b.fetch(math.MaxInt64)
+		b.mu.Unlock()
+		if err != nil {
+			log.Printf("[bus] poll: %v", err)
+		}
+		if time.Since(lastPrune) >= b.retention/2 {
+			b.prune()
+			lastPrune = time.Now()
+		}
+	}
+}
+
+type busRow struct {
+	id      int64
+	topic   string
+	payload []byte
+}
+
+// fetch delivers what other processes published since the last poll, up
+// to id upTo. The caller holds b.mu.
+func (b *SQLite) fetch(upTo int64) error {
+	for {
+		rows, err := b.db.Query(`
+			SELECT id, topic, origin, payload FROM bus_messages
+			WHERE id > ? AND id <= ?
+			ORDER BY id
+			LIMIT ?
+		`, b.lastID, upTo, pollBatch)
+		if err != nil {
+			return err
+		}
+		var batch []busRow
+		n := 0
+		for rows.Next() {
+			var (
+				origin string
+				row    busRow
+			)
+			if err := rows.Scan(&row.id, &row.topic, &origin, &row.payload); err != nil {
+				rows.Close()
+				return err
+			}
+			n++
+			b.lastID = row.id
+			if origin != b.origin {
+				batch = append(batch, row)
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		// handlers may touch the database; never hold the rows open for them
+		for _, row := range batch {
+			b.dispatch(row.topic, row.id, row.payload)
+		}
+		if n < pollBatch {
+			return nil
+		}
+	}
+}
+
+func (b *SQLite) prune() {
+	cutoff := time.Now().UTC().Add(-b.retention)
+	if _, err := b.db.Exec(`DELETE FROM bus_messages WHERE created_at < ?`, cutoff); err != nil {
+		log.Printf("[bus] prune: %v", err)
+	}
+}
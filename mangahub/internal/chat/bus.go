@@ -0,0 +1,116 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+const busTopic = "chat"
+
+// busEvent is what a Hub publishes on its Broker. Every replica, the
+// publisher included, applies it to the connections it holds, so users see
+// each other whichever replica they are connected to.
+type busEvent struct {
+	// Kind is "room" (send Message to its room), "deliver" (send it to
+	// Users' connections anywhere), "kick", "remove_user" or "slow_mode".
+	Kind    string   `json:"kind"`
+	Message Message  `json:"message"`
+	Users   []string `json:"users,omitempty"`
+	ToRoom  bool     `json:"to_room,omitempty"` // deliver: and to the message's room
+	Except  string   `json:"except,omitempty"`  // room: not to this user's connections
+	Seconds int      `json:"seconds,omitempty"` // slow_mode
+}
+
+// publish hands ev to the Broker. It must not be called with h.mu held: a
+// local Broker applies ev before returning.
+func (h *Hub) publish(ev busEvent) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("[chat] encode %s event: %v", ev.Kind, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := h.Broker.Publish(ctx, busTopic, payload); err != nil {
+		log.Printf("[chat] publish %s event in %s: %v", ev.Kind, ev.Message.Room, err)
+	}
+}
+
+func (h *Hub) receive(_ int64, payload []byte) {
+	var ev busEvent
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		log.Printf("[chat] decode bus event: %v", err)
+		return
+	}
+	msg := ev.Message
+	switch ev.Kind {
+	case "room":
+		h.applyRoom(msg, ev.Except)
+	case "deliver":
+		room := ""
+		if ev.ToRoom {
+			room = msg.Room
+		}
+		h.deliverLocal(msg, room, ev.Users)
+	case "kick":
+		h.kickLocal(msg.Room, msg.UserID, msg.Text)
+	case "remove_user":
+		h.removeUserLocal(msg.UserID)
+	case "slow_mode":
+		h.mu.Lock()
+		if r, ok := h.rooms[msg.Room]; ok {
+			r.slowMode = time.Duration(ev.Seconds) * time.Second
+		}
+		h.mu.Unlock()
+	default:
+		log.Printf("[chat] unknown bus event %q", ev.Kind)
+	}
+}
+
+// applyRoom brings this replica's copy of the room up to date with msg and
+// sends it to the room's connections here.
+func (h *Hub) applyRoom(msg Message, except string) {
+	var chapters map[string]int
+	if msg.SpoilerChapter > 0 {
+		chapters = h.readers(msg.Room)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.rooms[msg.Room]
+	if !ok {
+		return
+	}
+
+	switch {
+	case stored(msg):
+		r.history = append(r.history, msg)
+		if len(r.history) > h.historySize {
+			r.history = r.history[len(r.history)-h.historySize:]
+		}
+	case msg.Type == "message_edited":
+		for i := range r.history {
+			if r.history[i].ID == msg.ID {
+				r.history[i].Text = msg.Text
+				r.history[i].EditedAt = msg.EditedAt
+			}
+		}
+	case msg.Type == "message_deleted":
+		kept := r.history[:0]
+		for _, m := range r.history {
+			if m.ID != msg.ID {
+				kept = append(kept, m)
+			}
+		}
+		r.history = kept
+	}
+	r.lastActive = time.Now()
+
+	if except != "" {
+		h.writeMatchingLocked(r, msg, func(m Member) bool { return m.UserID != except })
+		return
+	}
+	h.writeLocked(r, msg, chapters)
+}
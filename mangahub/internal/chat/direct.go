@@ -0,0 +1,308 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"mangahub/internal/apperr"
+)
+
+// DMRoomPrefix starts the room name of a direct conversation,
+// "dm:<user id>:<user id>" with the ids in ascending order. Direct
+// conversations are not joined; their messages reach every connection the
+// two users have open, whatever room it is in.
+const DMRoomPrefix = "dm:"
+
+// Unread is how far behind a user is in one room or direct conversation.
+type Unread struct {
+	Room          string `json:"room"`
+	UserID        string `json:"user_id,omitempty"` // the other user, in direct conversations
+	Username      string `json:"username,omitempty"`
+	Unread        int    `json:"unread"`
+	LastReadID    int64  `json:"last_read_id"`
+	LastMessageID int64  `json:"last_message_id"`
+}
+
+type UnreadSummary struct {
+	Total         int      `json:"total"`
+	Conversations []Unread `json:"conversations"`
+}
+
+// Conversation is one of a user's direct conversations.
+type Conversation struct {
+	Room        string  `json:"room"`
+	UserID      string  `json:"user_id"` // the other user
+	Username    string  `json:"username"`
+	Unread      int     `json:"unread"`
+	LastReadID  int64   `json:"last_read_id"`
+	LastMessage Message `json:"last_message"`
+}
+
+// DMRoom returns the room of the direct conversation between two users.
+func DMRoom(a, b string) string {
+	if b < a {
+		a, b = b, a
+	}
+	return DMRoomPrefix + a + ":" + b
+}
+
+// dmUsers returns the two users of a direct conversation room.
+func dmUsers(room string) (a, b string, ok bool) {
+	if !strings.HasPrefix(room, DMRoomPrefix) {
+		return "", "", false
+	}
+	a, b, ok = strings.Cut(strings.TrimPrefix(room, DMRoomPrefix), ":")
+	return a, b, ok && a != "" && b != ""
+}
+
+// dmPeer returns the other user in a direct conversation room, or "" when
+// room is not one of userID's conversations.
+func dmPeer(room, userID string) string {
+	a, b, ok := dmUsers(room)
+	switch {
+	case !ok || userID == "":
+		return ""
+	case a == userID:
+		return b
+	case b == userID:
+		return a
+	default:
+		return ""
+	}
+}
+
+// Direct sends a private message from one user to another. It is stored in
+// their conversation room and delivered to both users' connections.
+func (h *Hub) Direct(ctx context.Context, from Member, to, text string) error {
+	if from.Anonymous() {
+		return apperr.Unauthenticated("sign in to post")
+	}
+	if to == "" {
+		return apperr.Invalid("user_id required")
+	}
+	if to == from.UserID {
+		return apperr.Invalid("you cannot message yourself")
+	}
+	if text == "" {
+		return apperr.Invalid("text required")
+	}
+	text, err := h.Filter.Apply(text)
+	if err != nil {
+		return err
+	}
+
+	if h.Repo != nil {
+		name, err := h.Repo.Username(ctx, to)
+		if err != nil {
+			return apperr.Internal("send failed", err)
+		}
+		if name == "" {
+			return apperr.NotFound("user not found")
+		}
+	} else if !h.online(to) {
+		// without a Repo there is nowhere to keep it until they connect
+		return apperr.NotFound("user is not online")
+	}
+
+	msg := Message{
+		Type:   "dm",
+		Room:   DMRoom(from.UserID, to),
+		UserID: from.UserID,
+		User:   from.Username,
+		Text:   text,
+		At:     time.Now().UTC(),
+	}
+	if h.Repo != nil {
+		if msg, err = h.Repo.Insert(ctx, msg); err != nil {
+			return apperr.Internal("send failed", err)
+		}
+	} else {
+		h.mu.Lock()
+		h.lastID++
+		msg.ID = h.lastID
+		h.mu.Unlock()
+	}
+
+	h.deliver(msg, false, from.UserID, to)
+	return nil
+}
+
+// Typing tells others that by is typing: the rest of room, or only the user
+// to in their direct conversation. Typing frames are never stored.
+func (h *Hub) Typing(room string, by Member, to string) error {
+	if by.Anonymous() {
+		return apperr.Unauthenticated("sign in to post")
+	}
+	msg := Message{Type: "typing", Room: room, UserID: by.UserID, User: by.Username, At: time.Now().UTC()}
+	if to == "" {
+		h.publish(busEvent{Kind: "room", Message: msg, Except: by.UserID})
+		return nil
+	}
+	if to == by.UserID {
+		return apperr.Invalid("you cannot message yourself")
+	}
+	msg.Room = DMRoom(by.UserID, to)
+	h.deliver(msg, false, to)
+	return nil
+}
+
+// MarkRead records that by has read room, or one of their direct
+// conversations, up to message id. A "read" receipt goes to everyone in the
+// room, or to the other user of the conversation, and to by's other
+// connections so their unread counts stay in step.
+func (h *Hub) MarkRead(ctx context.Context, by Member, room string, id int64) error {
+	if by.Anonymous() {
+		return apperr.Unauthenticated("sign in to keep read markers")
+	}
+	if id <= 0 {
+		return apperr.Invalid("id must be a message id")
+	}
+	peer := dmPeer(room, by.UserID)
+	if _, _, dm := dmUsers(room); dm && peer == "" {
+		return apperr.PermissionDenied("not your conversation")
+	}
+	if h.Repo == nil {
+		return apperr.Unavailable("read markers need a database")
+	}
+
+	ok, err := h.Repo.InRoom(ctx, room, id)
+	if err != nil {
+		return apperr.Internal("mark read failed", err)
+	}
+	if !ok {
+		return apperr.NotFound("message not found")
+	}
+
+	now := time.Now().UTC()
+	if err := h.Repo.MarkRead(ctx, by.UserID, room, id, now); err != nil {
+		return apperr.Internal("mark read failed", err)
+	}
+
+	receipt := Message{ID: id, Type: "read", Room: room, UserID: by.UserID, User: by.Username, At: now}
+	if peer != "" {
+		h.deliver(receipt, false, by.UserID, peer)
+		return nil
+	}
+	h.deliver(receipt, true, by.UserID)
+	return nil
+}
+
+// Unread counts what by has not read yet in their rooms and direct
+// conversations.
+func (h *Hub) Unread(ctx context.Context, by Member) (UnreadSummary, error) {
+	out := UnreadSummary{Conversations: []Unread{}}
+	if h.Repo == nil {
+		return out, apperr.Unavailable("read markers need a database")
+	}
+	items, err := h.Repo.Unread(ctx, by.UserID)
+	if err != nil {
+		return out, apperr.Internal("unread failed", err)
+	}
+	for i := range items {
+		if peer := dmPeer(items[i].Room, by.UserID); peer != "" {
+			items[i].UserID = peer
+			items[i].Username = h.username(ctx, peer)
+		}
+		out.Total += items[i].Unread
+	}
+	out.Conversations = items
+	return out, nil
+}
+
+// Conversations lists by's direct conversations, most recent first.
+func (h *Hub) Conversations(ctx context.Context, by Member, limit int) ([]Conversation, error) {
+	if h.Repo == nil {
+		return nil, apperr.Unavailable("direct conversations need a database")
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	out, err := h.Repo.Conversations(ctx, by.UserID, limit)
+	if err != nil {
+		return nil, apperr.Internal("list conversations failed", err)
+	}
+	for i := range out {
+		out[i].UserID = dmPeer(out[i].Room, by.UserID)
+		out[i].Username = h.username(ctx, out[i].UserID)
+	}
+	return out, nil
+}
+
+// username looks up a user for display; failures leave it blank.
+func (h *Hub) username(ctx context.Context, userID string) string {
+	name, err := h.Repo.Username(ctx, userID)
+	if err != nil {
+		log.Printf("[chat] lookup user %s: %v", userID, err)
+	}
+	return name
+}
+
+// online reports whether userID has a connection open in any room.
+func (h *Hub) online(userID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.rooms {
+		if countLocked(r, userID) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver sends msg to every connection of userIDs, in any room and on any
+// replica, and with toRoom to everyone in msg.Room as well.
+func (h *Hub) deliver(msg Message, toRoom bool, userIDs ...string) {
+	h.publish(busEvent{Kind: "deliver", Message: msg, Users: userIDs, ToRoom: toRoom})
+}
+
+// deliverLocal writes msg once to each connection here that is in room (""
+// for none) or belongs to one of userIDs.
+func (h *Hub) deliverLocal(msg Message, room string, userIDs []string) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	users := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		users[id] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sent := make(map[Conn]bool)
+	for name, r := range h.rooms {
+		for ws, m := range r.connections {
+			if sent[ws] || (name != room && !users[m.UserID]) {
+				continue
+			}
+			sent[ws] = true
+			if err := ws.WriteMessage(websocket.TextMessage, payload); err != nil {
+				_ = ws.Close()
+				delete(r.connections, ws)
+			}
+		}
+	}
+}
+
+// writeMatchingLocked sends msg to the connections in r whose member
+// matches.
+func (h *Hub) writeMatchingLocked(r *Room, msg Message, match func(Member) bool) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	for ws, m := range r.connections {
+		if !match(m) {
+			continue
+		}
+		if err := ws.WriteMessage(websocket.TextMessage, payload); err != nil {
+			_ = ws.Close()
+			delete(r.connections, ws)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package chat
+
+import (
+	"regexp"
+	"strings"
+
+	"mangahub/internal/apperr"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9][a-z0-9-]*(?:\.[a-z0-9-]+)*\.(?:com|net|org|io|gg|me|co|ly|xyz|ru|tk)\b(?:/\S*)?`)
+
+// Filter screens message text before it is broadcast. Blocked words match
+// whole words, case-insensitively. Matches are masked, or the message is
+// rejected when Reject is set.
+type Filter struct {
+	words      *regexp.Regexp
+	blockLinks bool
+	reject     bool
+}
+
+// NewFilter returns nil when there is nothing to filter.
+func NewFilter(words []string, blockLinks, reject bool) *Filter {
+	var quoted []string
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+	if len(quoted) == 0 && !blockLinks {
+		return nil
+	}
+
+	f := &Filter{blockLinks: blockLinks, reject: reject}
+	if len(quoted) > 0 {
+		f.words = regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	}
+	return f
+}
+
+// Apply returns text with blocked words masked and links removed, or an
+// Invalid error in reject mode. A nil Filter lets everything through.
+func (f *Filter) Apply(text string) (string, error) {
+	if f == nil {
+		return text, nil
+	}
+	if f.words != nil && f.words.MatchString(text) {
+		if f.reject {
+			return "", apperr.Invalid("message contains a blocked word")
+		}
+		text = f.words.ReplaceAllStringFunc(text, func(w string) string {
+			return strings.Repeat("*", len([]rune(w)))
+		})
+	}
+	if f.blockLinks && linkPattern.MatchString(text) {
+		if f.reject {
+			return "", apperr.Invalid("links are not allowed")
+		}
+		text = linkPattern.ReplaceAllString(text, "[link removed]")
+	}
+	return text, nil
+}
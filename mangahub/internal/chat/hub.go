@@ -1,141 +1,550 @@
 package chat
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"log"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"mangahub/internal/apperr"
+	"mangahub/internal/bus"
 )
 
-const defaultHistorySize = 50
+const (
+	defaultHistorySize = 50
+	storeTimeout       = 5 * time.Second
+)
 
+// Message is the envelope of every chat frame. Type tells clients what it
+// carries: "message", "message_edited", "message_deleted", "system",
+// "user_join" and "user_leave" go to a room; "dm", "typing" and "read" may
+// arrive on any connection of the users concerned (see Direct); "kicked" and
+// "error" are for one connection.
 type Message struct {
-	Type string    `json:"type"`
-	Room string    `json:"room"`
-	User string    `json:"user"`
-	Text string    `json:"text,omitempty"`
-	At   time.Time `json:"at"`
+	ID       int64      `json:"id,omitempty"`
+	Type     string     `json:"type"`
+	Room     string     `json:"room"`
+	UserID   string     `json:"user_id,omitempty"`
+	User     string     `json:"user"`
+	Text     string     `json:"text,omitempty"`
+	At       time.Time  `json:"at"`
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+
+	// SpoilerChapter marks the text as a spoiler up to that chapter of the
+	// room's manga. Readers who are behind get a placeholder with
+	// SpoilerHidden set instead.
+	SpoilerChapter int  `json:"spoiler_chapter,omitempty"`
+	SpoilerHidden  bool `json:"spoiler_hidden,omitempty"`
+}
+
+// Member is the account behind a connection. The zero Member is an
+// anonymous, read-only reader.
+type Member struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+func (m Member) Anonymous() bool { return m.UserID == "" }
+
+// Conn is one member's connection to a room. *websocket.Conn satisfies it;
+// the gRPC Chat stream adapts to it.
+type Conn interface {
+	WriteMessage(messageType int, data []byte) error
+	Close() error
 }
 
 type Room struct {
-	connections map[*websocket.Conn]string
-	history     []Message
+	connections map[Conn]Member
+	history     []Message // most recent historySize messages
+	lastActive  time.Time
+
+	slowMode time.Duration
+	lastPost map[string]time.Time // by user id, for slow mode
 }
 
 type Hub struct {
 	mu          sync.Mutex
 	rooms       map[string]*Room
 	historySize int
+	lastID      int64 // message ids when there is no Repo
+
+	// Repo persists messages; nil keeps history in memory only, and it is
+	// lost when a room is evicted.
+	Repo *Repo
+	// Filter screens posts and edits; nil lets everything through.
+	Filter *Filter
+	// Broker carries everything sent to connections, so that other
+	// replicas sharing it reach their own. Rooms, presence and slow mode
+	// timing are still tracked per replica.
+	Broker bus.Broker
 }
 
-func NewHub(historySize int) *Hub {
+// NewHub returns a Hub subscribed to broker; nil keeps it to this process.
+func NewHub(historySize int, repo *Repo, broker bus.Broker) *Hub {
 	if historySize <= 0 {
 		historySize = defaultHistorySize
 	}
-	return &Hub{
+	if broker == nil {
+		broker = bus.NewMemory()
+	}
+	h := &Hub{
 		rooms:       make(map[string]*Room),
 		historySize: historySize,
+		Repo:        repo,
+		Broker:      broker,
 	}
+	broker.Subscribe(busTopic, h.receive)
+	return h
 }
 
-func (h *Hub) Join(room string, ws *websocket.Conn, user string) []Message {
+// Join adds ws to room and returns the room's recent history. A room that is
+// not in memory is loaded from the Repo first. user_join is announced for a
+// user's first connection only, so extra tabs do not repeat it; anonymous
+// readers are never announced.
+func (h *Hub) Join(room string, ws Conn, m Member) []Message {
+	h.mu.Lock()
+	_, loaded := h.rooms[room]
+	h.mu.Unlock()
+
+	var (
+		stored []Message
+		slow   int
+	)
+	if !loaded && h.Repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		var err error
+		stored, err = h.Repo.History(ctx, room, 0, h.historySize)
+		if err != nil {
+			log.Printf("[chat] load history for %s: %v", room, err)
+		}
+		if slow, err = h.Repo.SlowMode(ctx, room); err != nil {
+			log.Printf("[chat] load slow mode for %s: %v", room, err)
+		}
+		cancel()
+	}
+
 	var history []Message
 	h.mu.Lock()
-	r := h.roomLocked(room)
-	r.connections[ws] = user
+	r, ok := h.rooms[room]
+	if !ok {
+		r = h.roomLocked(room)
+		r.history = stored
+		r.slowMode = time.Duration(slow) * time.Second
+	}
+	first := !m.Anonymous() && countLocked(r, m.UserID) == 0
+	r.connections[ws] = m
+	r.lastActive = time.Now()
 	history = append(history, r.history...)
 	h.mu.Unlock()
 
-	h.Broadcast(Message{
-		Type: "user_join",
-		Room: room,
-		User: user,
-		At:   time.Now().UTC(),
-	})
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	history = h.visibleAll(ctx, room, history, m)
+	cancel()
+
+	if first {
+		h.Broadcast(Message{
+			Type:   "user_join",
+			Room:   room,
+			UserID: m.UserID,
+			User:   m.Username,
+			At:     time.Now().UTC(),
+		})
+	}
 
 	return history
 }
 
-func (h *Hub) Leave(room string, ws *websocket.Conn) {
-	var user string
+// Leave removes ws from room. user_leave is announced once the user's last
+// connection is gone.
+func (h *Hub) Leave(room string, ws Conn) {
+	var (
+		m    Member
+		last bool
+	)
 	h.mu.Lock()
 	if r, ok := h.rooms[room]; ok {
-		if u, exists := r.connections[ws]; exists {
-			user = u
+		if member, exists := r.connections[ws]; exists {
+			m = member
+			delete(r.connections, ws)
+			last = !m.Anonymous() && countLocked(r, m.UserID) == 0
 		}
-		delete(r.connections, ws)
+		r.lastActive = time.Now()
 	}
 	h.mu.Unlock()
 
 	_ = ws.Close()
 
-	if user != "" {
+	if last {
 		h.Broadcast(Message{
-			Type: "user_leave",
-			Room: room,
-			User: user,
-			At:   time.Now().UTC(),
+			Type:   "user_leave",
+			Room:   room,
+			UserID: m.UserID,
+			User:   m.Username,
+			At:     time.Now().UTC(),
 		})
 	}
 }
 
+// Members returns the signed-in users in room, once each however many
+// connections they have open.
+func (h *Hub) Members(room string) []Member {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := []Member{}
+	r, ok := h.rooms[room]
+	if !ok {
+		return out
+	}
+	seen := make(map[string]bool)
+	for _, m := range r.connections {
+		if m.Anonymous() || seen[m.UserID] {
+			continue
+		}
+		seen[m.UserID] = true
+		out = append(out, m)
+	}
+	return out
+}
+
+// RemoveUser closes every chat connection of userID, on every replica. The
+// connections' read loops then leave their rooms as usual.
+func (h *Hub) RemoveUser(userID string) {
+	if userID == "" {
+		return
+	}
+	h.publish(busEvent{Kind: "remove_user", Message: Message{UserID: userID}})
+}
+
+func (h *Hub) removeUserLocal(userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.rooms {
+		for ws, m := range r.connections {
+			if m.UserID == userID {
+				_ = ws.Close()
+			}
+		}
+	}
+}
+
+// Post validates msg as a new message from its author and broadcasts it.
+// The text goes through the Filter, and mutes and slow mode are enforced.
+func (h *Hub) Post(ctx context.Context, msg Message) error {
+	if msg.UserID == "" {
+		return apperr.Unauthenticated("sign in to post")
+	}
+	if msg.Text == "" {
+		return apperr.Invalid("text required")
+	}
+	if msg.SpoilerChapter < 0 {
+		return apperr.Invalid("spoiler_chapter must be positive")
+	}
+	if msg.SpoilerChapter > 0 && RoomManga(msg.Room) == "" {
+		return apperr.Invalid("spoilers can only be tagged in manga rooms")
+	}
+	text, err := h.Filter.Apply(msg.Text)
+	if err != nil {
+		return err
+	}
+	msg.Text = text
+	if err := h.checkPost(ctx, msg.Room, Member{UserID: msg.UserID, Username: msg.User}); err != nil {
+		return err
+	}
+	msg.Type = "message"
+	h.Broadcast(msg)
+	return nil
+}
+
+// Broadcast sends msg to everyone in the room, on every replica. New
+// "message" and "system" messages are stored first so they carry an ID.
 func (h *Hub) Broadcast(msg Message) {
 	if msg.At.IsZero() {
 		msg.At = time.Now().UTC()
 	}
 
-	payload, err := json.Marshal(msg)
+	if stored(msg) && msg.ID == 0 {
+		if h.Repo != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+			stored, err := h.Repo.Insert(ctx, msg)
+			cancel()
+			if err != nil {
+				log.Printf("[chat] store message in %s: %v", msg.Room, err)
+			} else {
+				msg = stored
+			}
+		} else {
+			h.mu.Lock()
+			h.lastID++
+			msg.ID = h.lastID
+			h.mu.Unlock()
+		}
+	}
+
+	h.publish(busEvent{Kind: "room", Message: msg})
+}
+
+// Edit replaces the text of one of by's messages in room.
+func (h *Hub) Edit(ctx context.Context, room string, id int64, by Member, text string) error {
+	if text == "" {
+		return apperr.Invalid("text required")
+	}
+	orig, err := h.find(ctx, room, id)
 	if err != nil {
-		return
+		return err
+	}
+	if by.Anonymous() || orig.UserID != by.UserID {
+		return apperr.PermissionDenied("only the author can change a message")
+	}
+	if text, err = h.Filter.Apply(text); err != nil {
+		return err
 	}
 
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	now := time.Now().UTC()
+	if h.Repo != nil {
+		if err := h.Repo.UpdateText(ctx, id, text, now); err != nil {
+			return apperr.Internal("edit failed", err)
+		}
+	}
 
-	r, ok := h.rooms[msg.Room]
-	if !ok {
-		return
+	h.publish(busEvent{Kind: "room", Message: Message{
+		ID:             id,
+		Type:           "message_edited",
+		Room:           room,
+		UserID:         by.UserID,
+		User:           by.Username,
+		Text:           text,
+		At:             now,
+		EditedAt:       &now,
+		SpoilerChapter: orig.SpoilerChapter,
+	}})
+	return nil
+}
+
+// Delete removes a message from room. Authors can delete their own
+// messages; room moderators can delete anyone's, which is audited.
+func (h *Hub) Delete(ctx context.Context, room string, id int64, by Member) error {
+	if by.Anonymous() {
+		return apperr.PermissionDenied("only the author or a moderator can delete a message")
+	}
+	msg, err := h.find(ctx, room, id)
+	if err != nil {
+		return err
+	}
+	moderated := msg.UserID != by.UserID
+	if moderated {
+		if h.Repo == nil {
+			return apperr.PermissionDenied("only the author can delete a message")
+		}
+		role, err := h.Repo.RoomRole(ctx, room, by.UserID)
+		if err != nil {
+			return apperr.Internal("delete failed", err)
+		}
+		if !role.CanModerate() {
+			return apperr.PermissionDenied("only the author or a moderator can delete a message")
+		}
 	}
 
-	if msg.Type == "message" {
-		r.history = append(r.history, msg)
-		if len(r.history) > h.historySize {
-			r.history = r.history[len(r.history)-h.historySize:]
+	now := time.Now().UTC()
+	if h.Repo != nil {
+		if err := h.Repo.Delete(ctx, id, now); err != nil {
+			return apperr.Internal("delete failed", err)
 		}
 	}
 
-	for ws := range r.connections {
-		if err := ws.WriteMessage(websocket.TextMessage, payload); err != nil {
-			_ = ws.Close()
-			delete(r.connections, ws)
+	h.publish(busEvent{Kind: "room", Message: Message{ID: id, Type: "message_deleted", Room: room, UserID: by.UserID, User: by.Username, At: now}})
+
+	if moderated {
+		h.record(ctx, room, by, "delete_message", msg.UserID,
+			fmt.Sprintf("%s removed a message by %s", by.Username, msg.User), now)
+	}
+	return nil
+}
+
+// Page returns up to limit messages of room older than the message id
+// before (the newest when before <= 0), oldest first, with spoilers gated
+// for viewer. Direct conversations are only shown to their two users, and
+// rooms are not shown to users banned from them.
+func (h *Hub) Page(ctx context.Context, room string, before int64, limit int, viewer Member) ([]Message, error) {
+	if _, _, dm := dmUsers(room); dm {
+		if dmPeer(room, viewer.UserID) == "" {
+			if viewer.Anonymous() {
+				return nil, apperr.Unauthenticated("sign in to read direct messages")
+			}
+			return nil, apperr.PermissionDenied("not your conversation")
 		}
+	} else if err := h.checkBan(ctx, room, viewer, "history failed"); err != nil {
+		return nil, err
+	}
+	msgs, err := h.page(ctx, room, before, limit)
+	if err != nil {
+		return nil, err
 	}
+	return h.visibleAll(ctx, room, msgs, viewer), nil
 }
 
-func (h *Hub) History(room string) []Message {
+func (h *Hub) page(ctx context.Context, room string, before int64, limit int) ([]Message, error) {
+	if h.Repo != nil {
+		msgs, err := h.Repo.History(ctx, room, before, limit)
+		if err != nil {
+			return nil, apperr.Internal("history failed", err)
+		}
+		return msgs, nil
+	}
+
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	if r, ok := h.rooms[room]; ok {
-		return append([]Message(nil), r.history...)
+	r, ok := h.rooms[room]
+	if !ok {
+		return []Message{}, nil
 	}
-	return nil
+	end := len(r.history)
+	if before > 0 {
+		for end > 0 && r.history[end-1].ID >= before {
+			end--
+		}
+	}
+	start := end - limit
+	if start < 0 {
+		start = 0
+	}
+	return append([]Message{}, r.history[start:end]...), nil
+}
+
+// SendTo writes msg to a single connection. Writes go through the hub lock
+// so they never race with broadcasts.
+func (h *Hub) SendTo(ws Conn, msg Message) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return sendLocked(ws, msg)
+}
+
+func sendLocked(ws Conn, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return ws.WriteMessage(websocket.TextMessage, payload)
 }
 
-func (h *Hub) User(room string, ws *websocket.Conn) string {
+// MemberOf returns who is behind ws in room.
+func (h *Hub) MemberOf(room string, ws Conn) Member {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	if r, ok := h.rooms[room]; ok {
 		return r.connections[ws]
 	}
-	return ""
+	return Member{}
+}
+
+// Run evicts rooms that have had no members and no traffic for idle, until
+// ctx is done. Stored history is reloaded when someone joins again.
+func (h *Hub) Run(ctx context.Context, idle time.Duration) {
+	ticker := time.NewTicker(idle / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.evictIdle(idle)
+		}
+	}
+}
+
+func (h *Hub) evictIdle(idle time.Duration) {
+	cutoff := time.Now().Add(-idle)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for name, r := range h.rooms {
+		if len(r.connections) == 0 && r.lastActive.Before(cutoff) {
+			delete(h.rooms, name)
+		}
+	}
+}
+
+// find returns the user message id in room.
+func (h *Hub) find(ctx context.Context, room string, id int64) (*Message, error) {
+	var msg *Message
+	if h.Repo != nil {
+		m, err := h.Repo.Get(ctx, id)
+		if err != nil {
+			return nil, apperr.Internal("lookup failed", err)
+		}
+		msg = m
+	} else {
+		h.mu.Lock()
+		if r, ok := h.rooms[room]; ok {
+			for _, m := range r.history {
+				if m.ID == id {
+					m := m
+					msg = &m
+					break
+				}
+			}
+		}
+		h.mu.Unlock()
+	}
+
+	if msg == nil || msg.Room != room || msg.Type != "message" {
+		return nil, apperr.NotFound("message not found")
+	}
+	return msg, nil
+}
+
+// stored reports whether msg belongs in room history.
+func stored(msg Message) bool {
+	return msg.Type == "message" || msg.Type == "system"
+}
+
+// writeLocked sends msg to every connection in r. A spoiler is rendered per
+// member from chapters, the progress readers reported.
+func (h *Hub) writeLocked(r *Room, msg Message, chapters map[string]int) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	for ws, m := range r.connections {
+		out := payload
+		if msg.SpoilerChapter > 0 {
+			if seen := visible(msg, m.UserID, chapters[m.UserID]); seen.SpoilerHidden {
+				if out, err = json.Marshal(seen); err != nil {
+					continue
+				}
+			}
+		}
+		if err := ws.WriteMessage(websocket.TextMessage, out); err != nil {
+			_ = ws.Close()
+			delete(r.connections, ws)
+		}
+	}
 }
 
 func (h *Hub) roomLocked(room string) *Room {
 	r, ok := h.rooms[room]
 	if !ok {
-		r = &Room{connections: make(map[*websocket.Conn]string)}
+		r = &Room{
+			connections: make(map[Conn]Member),
+			lastActive:  time.Now(),
+			lastPost:    make(map[string]time.Time),
+		}
 		h.rooms[room] = r
 	}
 	return r
 }
+
+func countLocked(r *Room, userID string) int {
+	n := 0
+	for _, m := range r.connections {
+		if m.UserID == userID {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,332 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"mangahub/internal/apperr"
+)
+
+const (
+	SanctionMute = "mute"
+	SanctionBan  = "ban"
+
+	maxSlowMode = time.Hour
+)
+
+// RoomRole is a user's standing in one room. Site moderators and admins
+// (users.role) moderate every room; a room's owner and the moderators they
+// appoint moderate that room only.
+type RoomRole struct {
+	Site      string
+	Owner     bool
+	Moderator bool
+}
+
+// rank orders roles for who may act on whom: a moderator can only act on
+// users ranked below them.
+func (r RoomRole) rank() int {
+	switch {
+	case r.Site == "admin":
+		return 4
+	case r.Site == "moderator":
+		return 3
+	case r.Owner:
+		return 2
+	case r.Moderator:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (r RoomRole) CanModerate() bool { return r.rank() > 0 }
+
+// CanAppoint reports whether r may add or remove room moderators.
+func (r RoomRole) CanAppoint() bool { return r.rank() >= 2 }
+
+// Sanction is an active mute or ban.
+type Sanction struct {
+	Kind   string     `json:"kind"`
+	Until  *time.Time `json:"until,omitempty"` // nil never expires
+	Reason string     `json:"reason,omitempty"`
+}
+
+func (s *Sanction) describe() string {
+	out := ""
+	if s.Until != nil {
+		out = " until " + s.Until.UTC().Format(time.RFC3339)
+	}
+	if s.Reason != "" {
+		out += ": " + s.Reason
+	}
+	return out
+}
+
+// Action is a moderator command. Type is one of mute, unmute, kick, ban,
+// unban, slow_mode, add_moderator or remove_moderator; UserID names the
+// target of all but slow_mode.
+type Action struct {
+	Type    string
+	UserID  string
+	Minutes int // mute and ban length; 0 is indefinite
+	Seconds int // slow_mode interval; 0 turns it off
+	Reason  string
+}
+
+// IsModeration reports whether a client frame type is an Action.
+func IsModeration(typ string) bool {
+	switch typ {
+	case "mute", "unmute", "kick", "ban", "unban", "slow_mode", "add_moderator", "remove_moderator":
+		return true
+	default:
+		return false
+	}
+}
+
+// ModerationEntry is one row of a room's moderation audit trail.
+type ModerationEntry struct {
+	ID       int64     `json:"id"`
+	Room     string    `json:"room"`
+	ActorID  string    `json:"actor_id"`
+	Action   string    `json:"action"`
+	TargetID string    `json:"target_id,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Moderate carries out a by the moderator by in room. Every action is
+// written to the audit trail and announced in the room as a "system"
+// message.
+func (h *Hub) Moderate(ctx context.Context, room string, by Member, a Action) error {
+	if by.Anonymous() {
+		return apperr.Unauthenticated("sign in to moderate")
+	}
+	if h.Repo == nil {
+		return apperr.Unavailable("moderation needs a database")
+	}
+	if !IsModeration(a.Type) {
+		return apperr.Invalid("unknown moderation action")
+	}
+	if a.Minutes < 0 {
+		return apperr.Invalid("minutes must not be negative")
+	}
+
+	actor, err := h.Repo.RoomRole(ctx, room, by.UserID)
+	if err != nil {
+		return apperr.Internal("moderation failed", err)
+	}
+	if !actor.CanModerate() {
+		return apperr.PermissionDenied("only room moderators can do that")
+	}
+
+	var target string
+	if a.Type != "slow_mode" {
+		if a.UserID == "" {
+			return apperr.Invalid("user_id required")
+		}
+		if a.UserID == by.UserID {
+			return apperr.Invalid("you cannot moderate yourself")
+		}
+		role, err := h.Repo.RoomRole(ctx, room, a.UserID)
+		if err != nil {
+			return apperr.Internal("moderation failed", err)
+		}
+		if role.rank() >= actor.rank() {
+			return apperr.PermissionDenied("you cannot moderate that user")
+		}
+		if target, err = h.Repo.Username(ctx, a.UserID); err != nil {
+			return apperr.Internal("moderation failed", err)
+		}
+		if target == "" {
+			return apperr.NotFound("user not found")
+		}
+	}
+
+	now := time.Now().UTC()
+	var text string
+	switch a.Type {
+	case "mute", "ban":
+		var until *time.Time
+		length := "indefinitely"
+		if a.Minutes > 0 {
+			t := now.Add(time.Duration(a.Minutes) * time.Minute)
+			until = &t
+			length = fmt.Sprintf("for %d minutes", a.Minutes)
+		}
+		if err := h.Repo.SetSanction(ctx, room, a.UserID, a.Type, until, by.UserID, a.Reason); err != nil {
+			return apperr.Internal("moderation failed", err)
+		}
+		verb := "muted"
+		if a.Type == "ban" {
+			verb = "banned"
+			h.kick(room, a.UserID, "you were banned from "+room)
+		}
+		text = fmt.Sprintf("%s %s %s %s", by.Username, verb, target, length)
+	case "unmute", "unban":
+		kind, past := SanctionMute, "muted"
+		if a.Type == "unban" {
+			kind, past = SanctionBan, "banned"
+		}
+		lifted, err := h.Repo.LiftSanction(ctx, room, a.UserID, kind)
+		if err != nil {
+			return apperr.Internal("moderation failed", err)
+		}
+		if !lifted {
+			return apperr.NotFound(fmt.Sprintf("%s is not %s", target, past))
+		}
+		text = fmt.Sprintf("%s un%s %s", by.Username, past, target)
+	case "kick":
+		h.kick(room, a.UserID, "you were kicked from "+room)
+		text = fmt.Sprintf("%s kicked %s", by.Username, target)
+	case "slow_mode":
+		interval := time.Duration(a.Seconds) * time.Second
+		if interval < 0 || interval > maxSlowMode {
+			return apperr.Invalid(fmt.Sprintf("seconds must be 0-%d", int(maxSlowMode.Seconds())))
+		}
+		if err := h.Repo.SetSlowMode(ctx, room, a.Seconds); err != nil {
+			return apperr.Internal("moderation failed", err)
+		}
+		h.publish(busEvent{Kind: "slow_mode", Message: Message{Room: room}, Seconds: a.Seconds})
+		text = fmt.Sprintf("%s turned slow mode off", by.Username)
+		if interval > 0 {
+			text = fmt.Sprintf("%s set slow mode to one message every %d seconds", by.Username, a.Seconds)
+		}
+	case "add_moderator", "remove_moderator":
+		if !actor.CanAppoint() {
+			return apperr.PermissionDenied("only the room owner can appoint moderators")
+		}
+		if a.Type == "add_moderator" {
+			if err := h.Repo.AddModerator(ctx, room, a.UserID, by.UserID); err != nil {
+				return apperr.Internal("moderation failed", err)
+			}
+			text = fmt.Sprintf("%s made %s a moderator", by.Username, target)
+		} else {
+			removed, err := h.Repo.RemoveModerator(ctx, room, a.UserID)
+			if err != nil {
+				return apperr.Internal("moderation failed", err)
+			}
+			if !removed {
+				return apperr.NotFound(target + " is not a moderator")
+			}
+			text = fmt.Sprintf("%s removed %s as moderator", by.Username, target)
+		}
+	}
+	if a.Reason != "" {
+		text += ": " + a.Reason
+	}
+
+	h.record(ctx, room, by, a.Type, a.UserID, text, now)
+	return nil
+}
+
+// ModerationLog returns room's audit trail, newest first, to its moderators.
+func (h *Hub) ModerationLog(ctx context.Context, room string, by Member, limit int) ([]ModerationEntry, error) {
+	if h.Repo == nil {
+		return nil, apperr.Unavailable("moderation needs a database")
+	}
+	role, err := h.Repo.RoomRole(ctx, room, by.UserID)
+	if err != nil {
+		return nil, apperr.Internal("moderation log failed", err)
+	}
+	if !role.CanModerate() {
+		return nil, apperr.PermissionDenied("only room moderators can read the moderation log")
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	entries, err := h.Repo.ModerationLog(ctx, room, limit)
+	if err != nil {
+		return nil, apperr.Internal("moderation log failed", err)
+	}
+	return entries, nil
+}
+
+// record audits an action and announces it in the room.
+func (h *Hub) record(ctx context.Context, room string, by Member, action, targetID, text string, at time.Time) {
+	log.Printf("[chat] %s: %s", room, text)
+	if h.Repo != nil {
+		err := h.Repo.LogAction(ctx, ModerationEntry{
+			Room:     room,
+			ActorID:  by.UserID,
+			Action:   action,
+			TargetID: targetID,
+			Detail:   text,
+			At:       at,
+		})
+		if err != nil {
+			log.Printf("[chat] audit %s in %s: %v", action, room, err)
+		}
+	}
+	h.Broadcast(Message{Type: "system", Room: room, UserID: by.UserID, User: by.Username, Text: text, At: at})
+}
+
+// checkPost enforces mutes and slow mode on a new message by m.
+func (h *Hub) checkPost(ctx context.Context, room string, m Member) error {
+	if h.Repo == nil {
+		return nil
+	}
+	mute, err := h.Repo.ActiveSanction(ctx, room, m.UserID, SanctionMute)
+	if err != nil {
+		return apperr.Internal("post failed", err)
+	}
+	if mute != nil {
+		return apperr.PermissionDenied("you are muted in this room" + mute.describe())
+	}
+
+	h.mu.Lock()
+	interval := time.Duration(0)
+	if r, ok := h.rooms[room]; ok {
+		interval = r.slowMode
+	}
+	h.mu.Unlock()
+	if interval == 0 {
+		return nil
+	}
+
+	role, err := h.Repo.RoomRole(ctx, room, m.UserID)
+	if err != nil {
+		return apperr.Internal("post failed", err)
+	}
+	if role.CanModerate() {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.rooms[room]
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	if wait := r.lastPost[m.UserID].Add(interval).Sub(now); wait > 0 {
+		return apperr.Invalid(fmt.Sprintf("slow mode is on; wait %d seconds", int(wait.Seconds())+1))
+	}
+	r.lastPost[m.UserID] = now
+	return nil
+}
+
+// kick closes userID's connections to room on every replica, after telling
+// them why. Their read loops then leave the room.
+func (h *Hub) kick(room, userID, reason string) {
+	h.publish(busEvent{Kind: "kick", Message: Message{Room: room, UserID: userID, Text: reason}})
+}
+
+func (h *Hub) kickLocal(room, userID, reason string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.rooms[room]
+	if !ok {
+		return
+	}
+	notice := Message{Type: "kicked", Room: room, Text: reason, At: time.Now().UTC()}
+	for ws, m := range r.connections {
+		if m.UserID != userID {
+			continue
+		}
+		_ = sendLocked(ws, notice)
+		_ = ws.Close()
+	}
+}
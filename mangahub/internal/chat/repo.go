@@ -0,0 +1,578 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Repo struct {
+	DB *sql.DB
+}
+
+func NewRepo(db *sql.DB) *Repo {
+	return &Repo{DB: db}
+}
+
+// Insert stores msg and returns it with its ID set.
+func (r *Repo) Insert(ctx context.Context, msg Message) (Message, error) {
+	var spoiler sql.NullInt64
+	if msg.SpoilerChapter > 0 {
+		spoiler = sql.NullInt64{Int64: int64(msg.SpoilerChapter), Valid: true}
+	}
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO chat_messages (room, type, user_id, username, text, spoiler_chapter, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, msg.Room, msg.Type, msg.UserID, msg.User, msg.Text, spoiler, msg.At)
+	if err != nil {
+		return msg, fmt.Errorf("insert chat message: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return msg, fmt.Errorf("last insert id: %w", err)
+	}
+	msg.ID = id
+
+	if _, err := r.DB.ExecContext(ctx, `
+		UPDATE chat_rooms SET last_message_at = ? WHERE name = ?
+	`, msg.At, msg.Room); err != nil {
+		return msg, fmt.Errorf("touch chat room: %w", err)
+	}
+	return msg, nil
+}
+
+// Get returns a message that has not been deleted, or nil.
+func (r *Repo) Get(ctx context.Context, id int64) (*Message, error) {
+	row := r.DB.QueryRowContext(ctx, `
+		SELECT id, room, type, user_id, username, text, spoiler_chapter, created_at, edited_at
+		FROM chat_messages
+		WHERE id = ? AND deleted_at IS NULL
+	`, id)
+
+	msg, err := scanMessage(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan chat message: %w", err)
+	}
+	return &msg, nil
+}
+
+func (r *Repo) UpdateText(ctx context.Context, id int64, text string, at time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE chat_messages SET text = ?, edited_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`, text, at, id)
+	if err != nil {
+		return fmt.Errorf("update chat message: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) Delete(ctx context.Context, id int64, at time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE chat_messages SET deleted_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`, at, id)
+	if err != nil {
+		return fmt.Errorf("delete chat message: %w", err)
+	}
+	return nil
+}
+
+// History returns up to limit messages of room older than before (all when
+// before <= 0), oldest first.
+func (r *Repo) History(ctx context.Context, room string, before int64, limit int) ([]Message, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	query := `
+		SELECT id, room, type, user_id, username, text, spoiler_chapter, created_at, edited_at
+		FROM chat_messages
+		WHERE room = ? AND deleted_at IS NULL`
+	args := []any{room}
+	if before > 0 {
+		query += ` AND id < ?`
+		args = append(args, before)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list chat history: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]Message, 0, limit)
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan chat history: %w", err)
+		}
+		out = append(out, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows chat history: %w", err)
+	}
+
+	// newest first from the query; clients want reading order
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+// EnsureRoom records room the first time it is used. mangaID is "" for
+// free-form rooms; ownerID, "" for none, only applies when the room is new.
+func (r *Repo) EnsureRoom(ctx context.Context, room, mangaID, ownerID string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT OR IGNORE INTO chat_rooms (name, manga_id, owner_id) VALUES (?, ?, ?)
+	`, room, nullString(mangaID), nullString(ownerID))
+	if err != nil {
+		return fmt.Errorf("ensure chat room: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) AddMember(ctx context.Context, room, userID string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT OR IGNORE INTO chat_room_members (room, user_id) VALUES (?, ?)
+	`, room, userID)
+	if err != nil {
+		return fmt.Errorf("add chat room member: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) MangaExists(ctx context.Context, mangaID string) (bool, error) {
+	var one int
+	err := r.DB.QueryRowContext(ctx, `SELECT 1 FROM manga WHERE id = ?`, mangaID).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("lookup manga: %w", err)
+	}
+	return true, nil
+}
+
+// Chapters returns the current chapter of mangaID for each of userIDs that
+// has it in their library. Missing users have read nothing.
+func (r *Repo) Chapters(ctx context.Context, mangaID string, userIDs []string) (map[string]int, error) {
+	out := make(map[string]int, len(userIDs))
+	if len(userIDs) == 0 {
+		return out, nil
+	}
+
+	args := []any{mangaID}
+	for _, id := range userIDs {
+		args = append(args, id)
+	}
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT user_id, current_chapter
+		FROM user_progress
+		WHERE manga_id = ? AND user_id IN (?`+strings.Repeat(", ?", len(userIDs)-1)+`)
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list reader chapters: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			userID  string
+			chapter int
+		)
+		if err := rows.Scan(&userID, &chapter); err != nil {
+			return nil, fmt.Errorf("scan reader chapter: %w", err)
+		}
+		out[userID] = chapter
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows reader chapters: %w", err)
+	}
+	return out, nil
+}
+
+// Rooms lists known rooms, most recently active first. mangaID narrows the
+// list to that manga's room.
+func (r *Repo) Rooms(ctx context.Context, mangaID string, limit, offset int) ([]RoomInfo, int, error) {
+	where := ""
+	args := []any{}
+	if mangaID != "" {
+		where = "WHERE r.manga_id = ?"
+		args = append(args, mangaID)
+	}
+
+	var total int
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM chat_rooms r `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count chat rooms: %w", err)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT r.name, r.manga_id, m.title, r.created_at, r.last_message_at,
+			(SELECT COUNT(*) FROM chat_room_members cm WHERE cm.room = r.name)
+		FROM chat_rooms r
+		LEFT JOIN manga m ON m.id = r.manga_id
+		`+where+`
+		ORDER BY r.last_message_at IS NULL, r.last_message_at DESC, r.name
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list chat rooms: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]RoomInfo, 0, limit)
+	for rows.Next() {
+		var (
+			info     RoomInfo
+			manga    sql.NullString
+			title    sql.NullString
+			lastPost sql.NullTime
+		)
+		if err := rows.Scan(&info.Name, &manga, &title, &info.CreatedAt, &lastPost, &info.Members); err != nil {
+			return nil, 0, fmt.Errorf("scan chat room: %w", err)
+		}
+		info.MangaID = manga.String
+		info.Title = title.String
+		if lastPost.Valid {
+			t := lastPost.Time
+			info.LastMessageAt = &t
+		}
+		out = append(out, info)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows chat rooms: %w", err)
+	}
+	return out, total, nil
+}
+
+// RoomRole returns userID's standing in room.
+func (r *Repo) RoomRole(ctx context.Context, room, userID string) (RoomRole, error) {
+	var role RoomRole
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT
+			COALESCE((SELECT role FROM users WHERE id = ?), 'user'),
+			EXISTS (SELECT 1 FROM chat_rooms WHERE name = ? AND owner_id = ?),
+			EXISTS (SELECT 1 FROM chat_room_moderators WHERE room = ? AND user_id = ?)
+	`, userID, room, userID, room, userID).Scan(&role.Site, &role.Owner, &role.Moderator)
+	if err != nil {
+		return role, fmt.Errorf("room role: %w", err)
+	}
+	return role, nil
+}
+
+// Username returns the current username of userID, or "" when there is no
+// such user.
+func (r *Repo) Username(ctx context.Context, userID string) (string, error) {
+	var name string
+	err := r.DB.QueryRowContext(ctx, `SELECT username FROM users WHERE id = ?`, userID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("lookup username: %w", err)
+	}
+	return name, nil
+}
+
+func (r *Repo) AddModerator(ctx context.Context, room, userID, by string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT OR IGNORE INTO chat_room_moderators (room, user_id, added_by) VALUES (?, ?, ?)
+	`, room, userID, by)
+	if err != nil {
+		return fmt.Errorf("add chat moderator: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) RemoveModerator(ctx context.Context, room, userID string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		DELETE FROM chat_room_moderators WHERE room = ? AND user_id = ?
+	`, room, userID)
+	if err != nil {
+		return false, fmt.Errorf("remove chat moderator: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// SetSanction mutes or bans userID in room, replacing an earlier sanction of
+// the same kind. A nil until never expires.
+func (r *Repo) SetSanction(ctx context.Context, room, userID, kind string, until *time.Time, by, reason string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT OR REPLACE INTO chat_sanctions (room, user_id, kind, until, by_user_id, reason)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, room, userID, kind, until, by, nullString(reason))
+	if err != nil {
+		return fmt.Errorf("set chat sanction: %w", err)
+	}
+	return nil
+}
+
+// LiftSanction reports whether an active sanction was removed.
+func (r *Repo) LiftSanction(ctx context.Context, room, userID, kind string) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		DELETE FROM chat_sanctions
+		WHERE room = ? AND user_id = ? AND kind = ? AND (until IS NULL OR until > ?)
+	`, room, userID, kind, time.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("lift chat sanction: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ActiveSanction returns the unexpired sanction of kind on userID in room,
+// or nil.
+func (r *Repo) ActiveSanction(ctx context.Context, room, userID, kind string) (*Sanction, error) {
+	var (
+		s      Sanction
+		until  sql.NullTime
+		reason sql.NullString
+	)
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT kind, until, reason
+		FROM chat_sanctions
+		WHERE room = ? AND user_id = ? AND kind = ? AND (until IS NULL OR until > ?)
+	`, room, userID, kind, time.Now().UTC()).Scan(&s.Kind, &until, &reason)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get chat sanction: %w", err)
+	}
+	if until.Valid {
+		t := until.Time
+		s.Until = &t
+	}
+	s.Reason = reason.String
+	return &s, nil
+}
+
+func (r *Repo) SetSlowMode(ctx context.Context, room string, seconds int) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE chat_rooms SET slow_mode_seconds = ? WHERE name = ?
+	`, seconds, room)
+	if err != nil {
+		return fmt.Errorf("set slow mode: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) SlowMode(ctx context.Context, room string) (int, error) {
+	var seconds int
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT slow_mode_seconds FROM chat_rooms WHERE name = ?
+	`, room).Scan(&seconds)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get slow mode: %w", err)
+	}
+	return seconds, nil
+}
+
+func (r *Repo) LogAction(ctx context.Context, e ModerationEntry) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO chat_moderation_log (room, actor_id, action, target_id, detail, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, e.Room, e.ActorID, e.Action, nullString(e.TargetID), nullString(e.Detail), e.At)
+	if err != nil {
+		return fmt.Errorf("log moderation action: %w", err)
+	}
+	return nil
+}
+
+// ModerationLog returns the newest limit actions taken in room, newest
+// first.
+func (r *Repo) ModerationLog(ctx context.Context, room string, limit int) ([]ModerationEntry, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, room, actor_id, action, target_id, detail, created_at
+		FROM chat_moderation_log
+		WHERE room = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`, room, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list moderation log: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]ModerationEntry, 0, limit)
+	for rows.Next() {
+		var (
+			e      ModerationEntry
+			target sql.NullString
+			detail sql.NullString
+		)
+		if err := rows.Scan(&e.ID, &e.Room, &e.ActorID, &e.Action, &target, &detail, &e.At); err != nil {
+			return nil, fmt.Errorf("scan moderation log: %w", err)
+		}
+		e.TargetID = target.String
+		e.Detail = detail.String
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows moderation log: %w", err)
+	}
+	return out, nil
+}
+
+// InRoom reports whether message id was posted in room, deleted or not.
+func (r *Repo) InRoom(ctx context.Context, room string, id int64) (bool, error) {
+	var one int
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT 1 FROM chat_messages WHERE id = ? AND room = ?
+	`, id, room).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("lookup chat message: %w", err)
+	}
+	return true, nil
+}
+
+// MarkRead moves userID's read marker in room forward to id. Markers never
+// move back, so a late frame from another tab cannot undo a newer one.
+func (r *Repo) MarkRead(ctx context.Context, userID, room string, id int64, at time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO chat_read_markers (user_id, room, last_read_id, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id, room) DO UPDATE SET
+			last_read_id = MAX(last_read_id, excluded.last_read_id),
+			updated_at = excluded.updated_at
+	`, userID, room, id, at)
+	if err != nil {
+		return fmt.Errorf("mark chat read: %w", err)
+	}
+	return nil
+}
+
+// Unread counts messages from others that userID has not read, in the
+// rooms they joined and their direct conversations. In a room with no read
+// marker only messages since joining count. Conversations with nothing
+// unread are left out.
+func (r *Repo) Unread(ctx context.Context, userID string) ([]Unread, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT m.room, COUNT(*), MAX(m.id), COALESCE(rm.last_read_id, 0)
+		FROM chat_messages m
+		LEFT JOIN chat_read_markers rm ON rm.user_id = ?1 AND rm.room = m.room
+		LEFT JOIN chat_room_members cm ON cm.user_id = ?1 AND cm.room = m.room
+		WHERE m.deleted_at IS NULL
+			AND m.type IN ('message', 'dm')
+			AND COALESCE(m.user_id, '') <> ?1
+			AND (cm.room IS NOT NULL OR m.room LIKE 'dm:' || ?1 || ':%' OR m.room LIKE 'dm:%:' || ?1)
+			AND m.id > COALESCE(rm.last_read_id, 0)
+			AND (rm.room IS NOT NULL OR cm.room IS NULL OR m.created_at >= cm.joined_at)
+		GROUP BY m.room
+		ORDER BY MAX(m.id) DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("count unread chat: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Unread{}
+	for rows.Next() {
+		var u Unread
+		if err := rows.Scan(&u.Room, &u.Unread, &u.LastMessageID, &u.LastReadID); err != nil {
+			return nil, fmt.Errorf("scan unread chat: %w", err)
+		}
+		out = append(out, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows unread chat: %w", err)
+	}
+	return out, nil
+}
+
+// Conversations returns userID's direct conversations, most recent first,
+// each with its latest message and how many of the peer's messages are
+// unread.
+func (r *Repo) Conversations(ctx context.Context, userID string, limit int) ([]Conversation, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT c.room, COALESCE(rm.last_read_id, 0),
+			m.id, m.room, m.type, m.user_id, m.username, m.text, m.spoiler_chapter, m.created_at, m.edited_at,
+			(SELECT COUNT(*) FROM chat_messages u
+				WHERE u.room = c.room AND u.type = 'dm' AND u.deleted_at IS NULL
+					AND COALESCE(u.user_id, '') <> ?1 AND u.id > COALESCE(rm.last_read_id, 0))
+		FROM (
+			SELECT room, MAX(id) AS last_id
+			FROM chat_messages
+			WHERE type = 'dm' AND deleted_at IS NULL
+				AND (room LIKE 'dm:' || ?1 || ':%' OR room LIKE 'dm:%:' || ?1)
+			GROUP BY room
+		) c
+		JOIN chat_messages m ON m.id = c.last_id
+		LEFT JOIN chat_read_markers rm ON rm.user_id = ?1 AND rm.room = c.room
+		ORDER BY c.last_id DESC
+		LIMIT ?2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list chat conversations: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]Conversation, 0, limit)
+	for rows.Next() {
+		var (
+			c        Conversation
+			authorID sql.NullString
+			spoiler  sql.NullInt64
+			edited   sql.NullTime
+		)
+		last := &c.LastMessage
+		if err := rows.Scan(&c.Room, &c.LastReadID,
+			&last.ID, &last.Room, &last.Type, &authorID, &last.User, &last.Text, &spoiler, &last.At, &edited,
+			&c.Unread); err != nil {
+			return nil, fmt.Errorf("scan chat conversation: %w", err)
+		}
+		last.UserID = authorID.String
+		last.SpoilerChapter = int(spoiler.Int64)
+		if edited.Valid {
+			t := edited.Time
+			last.EditedAt = &t
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows chat conversations: %w", err)
+	}
+	return out, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMessage(row scanner) (Message, error) {
+	var (
+		msg     Message
+		userID  sql.NullString
+		spoiler sql.NullInt64
+		edited  sql.NullTime
+	)
+	if err := row.Scan(&msg.ID, &msg.Room, &msg.Type, &userID, &msg.User, &msg.Text, &spoiler, &msg.At, &edited); err != nil {
+		return msg, err
+	}
+	msg.UserID = userID.String
+	msg.SpoilerChapter = int(spoiler.Int64)
+	if edited.Valid {
+		t := edited.Time
+		msg.EditedAt = &t
+	}
+	return msg, nil
+}
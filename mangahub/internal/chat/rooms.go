@@ -0,0 +1,258 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"mangahub/internal/apperr"
+)
+
+// MangaRoomPrefix starts the name of a manga's discussion room,
+// "manga:<manga id>". Any other name is a free-form room.
+const MangaRoomPrefix = "manga:"
+
+const maxRoomName = 128
+
+type RoomInfo struct {
+	Name          string     `json:"name"`
+	MangaID       string     `json:"manga_id,omitempty"`
+	Title         string     `json:"title,omitempty"`
+	Members       int        `json:"members"` // users who have joined at least once
+	Active        int        `json:"active"`  // signed-in users connected now
+	CreatedAt     time.Time  `json:"created_at"`
+	LastMessageAt *time.Time `json:"last_message_at,omitempty"`
+}
+
+type RoomList struct {
+	Total  int        `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+	Items  []RoomInfo `json:"items"`
+}
+
+// MangaRoom returns the discussion room of mangaID.
+func MangaRoom(mangaID string) string {
+	return MangaRoomPrefix + mangaID
+}
+
+// RoomManga returns the manga room belongs to, or "" for free-form rooms.
+func RoomManga(room string) string {
+	if !strings.HasPrefix(room, MangaRoomPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(room, MangaRoomPrefix)
+}
+
+// Open validates room before anyone joins it: manga rooms must name a manga
+// in the catalog, and banned users are turned away. The room is recorded on
+// first use, and m as one of its members unless anonymous.
+func (h *Hub) Open(ctx context.Context, room string, m Member) error {
+	if room == "" {
+		return apperr.Invalid("room is required")
+	}
+	if len(room) > maxRoomName {
+		return apperr.Invalid(fmt.Sprintf("room name must be at most %d chars", maxRoomName))
+	}
+	if strings.HasPrefix(room, DMRoomPrefix) {
+		return apperr.Invalid("direct conversations are not joined; send dm frames instead")
+	}
+	mangaID := RoomManga(room)
+	if strings.HasPrefix(room, MangaRoomPrefix) && mangaID == "" {
+		return apperr.Invalid("manga room needs a manga id")
+	}
+	if h.Repo == nil {
+		return nil
+	}
+
+	if mangaID != "" {
+		ok, err := h.Repo.MangaExists(ctx, mangaID)
+		if err != nil {
+			return apperr.Internal("room lookup failed", err)
+		}
+		if !ok {
+			return apperr.NotFound("manga not found")
+		}
+	}
+	// the first signed-in user to open a free-form room owns it
+	owner := ""
+	if mangaID == "" {
+		owner = m.UserID
+	}
+	if err := h.Repo.EnsureRoom(ctx, room, mangaID, owner); err != nil {
+		return apperr.Internal("open room failed", err)
+	}
+	if m.Anonymous() {
+		return nil
+	}
+
+	if err := h.checkBan(ctx, room, m, "open room failed"); err != nil {
+		return err
+	}
+	if err := h.Repo.AddMember(ctx, room, m.UserID); err != nil {
+		return apperr.Internal("open room failed", err)
+	}
+	return nil
+}
+
+// checkBan refuses m if they are banned from room; failed is the message
+// for a failed lookup. Anonymous members and hubs without a Repo pass.
+func (h *Hub) checkBan(ctx context.Context, room string, m Member, failed string) error {
+	if h.Repo == nil || m.Anonymous() {
+		return nil
+	}
+	ban, err := h.Repo.ActiveSanction(ctx, room, m.UserID, SanctionBan)
+	if err != nil {
+		return apperr.Internal(failed, err)
+	}
+	if ban != nil {
+		return apperr.PermissionDenied("you are banned from this room" + ban.describe())
+	}
+	return nil
+}
+
+// Rooms lists rooms for the directory. Without a Repo only rooms currently
+// in memory are known.
+func (h *Hub) Rooms(ctx context.Context, mangaID string, limit, offset int) (RoomList, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	out := RoomList{Limit: limit, Offset: offset}
+
+	if h.Repo != nil {
+		items, total, err := h.Repo.Rooms(ctx, mangaID, limit, offset)
+		if err != nil {
+			return out, apperr.Internal("list rooms failed", err)
+		}
+		out.Items, out.Total = items, total
+	} else {
+		out.Items = h.memoryRooms(mangaID)
+		out.Total = len(out.Items)
+		if offset > len(out.Items) {
+			offset = len(out.Items)
+		}
+		out.Items = out.Items[offset:]
+		if len(out.Items) > limit {
+			out.Items = out.Items[:limit]
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range out.Items {
+		if r, ok := h.rooms[out.Items[i].Name]; ok {
+			out.Items[i].Active = activeLocked(r)
+		}
+	}
+	return out, nil
+}
+
+func (h *Hub) memoryRooms(mangaID string) []RoomInfo {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := []RoomInfo{}
+	for name, r := range h.rooms {
+		if mangaID != "" && RoomManga(name) != mangaID {
+			continue
+		}
+		out = append(out, RoomInfo{
+			Name:      name,
+			MangaID:   RoomManga(name),
+			Members:   activeLocked(r),
+			CreatedAt: r.lastActive,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// visible returns msg as viewer, who has read up to chapter, may see it:
+// spoiler text is swapped for a placeholder unless viewer wrote the message
+// or has caught up.
+func visible(msg Message, viewer string, chapter int) Message {
+	if msg.SpoilerChapter <= 0 || chapter >= msg.SpoilerChapter {
+		return msg
+	}
+	if viewer != "" && msg.UserID == viewer {
+		return msg
+	}
+	msg.Text = fmt.Sprintf("[spoiler up to chapter %d]", msg.SpoilerChapter)
+	msg.SpoilerHidden = true
+	return msg
+}
+
+// visibleAll applies visible to msgs for one viewer.
+func (h *Hub) visibleAll(ctx context.Context, room string, msgs []Message, viewer Member) []Message {
+	spoilers := false
+	for _, m := range msgs {
+		if m.SpoilerChapter > 0 {
+			spoilers = true
+			break
+		}
+	}
+	if !spoilers {
+		return msgs
+	}
+
+	chapter := 0
+	if !viewer.Anonymous() {
+		chapter = h.chapters(ctx, room, []string{viewer.UserID})[viewer.UserID]
+	}
+	out := make([]Message, len(msgs))
+	for i, m := range msgs {
+		out[i] = visible(m, viewer.UserID, chapter)
+	}
+	return out
+}
+
+// readers returns how far each signed-in member of room has read the room's
+// manga, for gating a spoiler about to be sent to all of them.
+func (h *Hub) readers(room string) map[string]int {
+	h.mu.Lock()
+	var userIDs []string
+	if r, ok := h.rooms[room]; ok {
+		seen := make(map[string]bool)
+		for _, m := range r.connections {
+			if !m.Anonymous() && !seen[m.UserID] {
+				seen[m.UserID] = true
+				userIDs = append(userIDs, m.UserID)
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	return h.chapters(ctx, room, userIDs)
+}
+
+// chapters looks up reading progress. Lookup failures count as nothing
+// read, so spoilers stay hidden.
+func (h *Hub) chapters(ctx context.Context, room string, userIDs []string) map[string]int {
+	mangaID := RoomManga(room)
+	if h.Repo == nil || mangaID == "" || len(userIDs) == 0 {
+		return map[string]int{}
+	}
+	out, err := h.Repo.Chapters(ctx, mangaID, userIDs)
+	if err != nil {
+		log.Printf("[chat] load reader progress for %s: %v", room, err)
+		return map[string]int{}
+	}
+	return out
+}
+
+func activeLocked(r *Room) int {
+	seen := make(map[string]bool)
+	for _, m := range r.connections {
+		if !m.Anonymous() {
+			seen[m.UserID] = true
+		}
+	}
+	return len(seen)
+}
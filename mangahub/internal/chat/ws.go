@@ -1,29 +1,174 @@
 package chat
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+
+	"mangahub/internal/apperr"
+	"mangahub/internal/auth"
 )
 
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	Subprotocols:    []string{auth.TokenSubprotocol},
 	CheckOrigin: func(r *http.Request) bool {
 		return true
 	},
 }
 
+// incomingMessage is a client frame. Type defaults to "message"; "edit" and
+// "delete" act on the message with ID. Moderation frames (see Action) name
+// their target in UserID, as do "dm" and "typing" frames meant for one
+// user. "read" marks Room (the connection's room when empty) read up to ID.
 type incomingMessage struct {
-	Text string `json:"text"`
-	User string `json:"user"`
+	Type           string `json:"type"`
+	Room           string `json:"room"`
+	ID             int64  `json:"id"`
+	Text           string `json:"text"`
+	SpoilerChapter int    `json:"spoiler_chapter"`
+
+	UserID  string `json:"user_id"`
+	Minutes int    `json:"minutes"`
+	Seconds int    `json:"seconds"`
+	Reason  string `json:"reason"`
+}
+
+// RoomsHandler serves GET /chat/rooms?manga_id=&limit=&offset=, the room
+// directory.
+func RoomsHandler(hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.Query("limit"))
+		offset, _ := strconv.Atoi(c.Query("offset"))
+		list, err := hub.Rooms(c.Request.Context(), strings.TrimSpace(c.Query("manga_id")), limit, offset)
+		if err != nil {
+			apperr.JSON(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	}
+}
+
+type moderateReq struct {
+	Room    string `json:"room"`
+	Type    string `json:"type"`
+	UserID  string `json:"user_id"`
+	Minutes int    `json:"minutes"`
+	Seconds int    `json:"seconds"`
+	Reason  string `json:"reason"`
+}
+
+// ModerateHandler serves POST /chat/moderation, the REST form of the
+// moderation frames. It expects auth.AuthMiddleware in front.
+func ModerateHandler(hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := auth.MustGetClaims(c)
+		if claims == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		var req moderateReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+		room := strings.TrimSpace(req.Room)
+		if room == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "room is required"})
+			return
+		}
+
+		by := Member{UserID: claims.UserID, Username: claims.Username}
+		err := hub.Moderate(c.Request.Context(), room, by, Action{
+			Type:    req.Type,
+			UserID:  strings.TrimSpace(req.UserID),
+			Minutes: req.Minutes,
+			Seconds: req.Seconds,
+			Reason:  strings.TrimSpace(req.Reason),
+		})
+		if err != nil {
+			apperr.JSON(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// ModerationLogHandler serves GET /chat/moderation?room=&limit= to the
+// room's moderators. It expects auth.AuthMiddleware in front.
+func ModerationLogHandler(hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := auth.MustGetClaims(c)
+		if claims == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		room := strings.TrimSpace(c.Query("room"))
+		if room == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "room is required"})
+			return
+		}
+		limit, _ := strconv.Atoi(c.Query("limit"))
+
+		by := Member{UserID: claims.UserID, Username: claims.Username}
+		entries, err := hub.ModerationLog(c.Request.Context(), room, by, limit)
+		if err != nil {
+			apperr.JSON(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, entries)
+	}
 }
 
-func HistoryHandler(hub *Hub) gin.HandlerFunc {
+// UnreadHandler serves GET /chat/unread, the caller's unread counts per
+// room and direct conversation. It expects auth.AuthMiddleware in front.
+func UnreadHandler(hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := auth.MustGetClaims(c)
+		if claims == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		summary, err := hub.Unread(c.Request.Context(), Member{UserID: claims.UserID, Username: claims.Username})
+		if err != nil {
+			apperr.JSON(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, summary)
+	}
+}
+
+// ConversationsHandler serves GET /chat/conversations?limit=, the caller's
+// direct conversations. Their messages page through /chat/history with the
+// conversation's room. It expects auth.AuthMiddleware in front.
+func ConversationsHandler(hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := auth.MustGetClaims(c)
+		if claims == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		limit, _ := strconv.Atoi(c.Query("limit"))
+		list, err := hub.Conversations(c.Request.Context(), Member{UserID: claims.UserID, Username: claims.Username}, limit)
+		if err != nil {
+			apperr.JSON(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	}
+}
+
+// HistoryHandler serves GET /chat/history?room=&before=&limit=. Pass the
+// smallest id of a page as before to fetch the one preceding it. Spoilers
+// are gated for the caller when a token is sent, and hidden otherwise.
+func HistoryHandler(hub *Hub, identify func(r *http.Request) *auth.Claims) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		room := strings.TrimSpace(c.Query("room"))
 		if room == "" {
@@ -31,11 +176,37 @@ func HistoryHandler(hub *Hub) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, hub.History(room))
+		var before int64
+		if raw := strings.TrimSpace(c.Query("before")); raw != "" {
+			n, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || n <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "before must be a message id"})
+				return
+			}
+			before = n
+		}
+		limit, _ := strconv.Atoi(c.Query("limit"))
+
+		var viewer Member
+		if claims := identify(c.Request); claims != nil {
+			viewer = Member{UserID: claims.UserID, Username: claims.Username}
+		}
+
+		msgs, err := hub.Page(c.Request.Context(), room, before, limit, viewer)
+		if err != nil {
+			apperr.JSON(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, msgs)
 	}
 }
 
-func WSHandler(hub *Hub) gin.HandlerFunc {
+// WSHandler serves /ws/chat?room=. Manga rooms ("manga:<id>") must exist in
+// the catalog. Connections are identified by their token
+// (see auth.ClaimsFromRequest); messages are posted as that account. Without
+// a valid token the request is refused, unless allowAnonymous lets it in as
+// a read-only reader.
+func WSHandler(hub *Hub, identify func(r *http.Request) *auth.Claims, allowAnonymous bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		room := strings.TrimSpace(c.Query("room"))
 		if room == "" {
@@ -43,9 +214,16 @@ func WSHandler(hub *Hub) gin.HandlerFunc {
 			return
 		}
 
-		user := strings.TrimSpace(c.Query("user"))
-		if user == "" {
-			user = "anon"
+		var member Member
+		if claims := identify(c.Request); claims != nil {
+			member = Member{UserID: claims.UserID, Username: claims.Username}
+		} else if !allowAnonymous {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid token"})
+			return
+		}
+		if err := hub.Open(c.Request.Context(), room, member); err != nil {
+			apperr.JSON(c, err)
+			return
 		}
 
 		ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
@@ -53,9 +231,9 @@ func WSHandler(hub *Hub) gin.HandlerFunc {
 			return
 		}
 
-		history := hub.Join(room, ws, user)
+		history := hub.Join(room, ws, member)
 		for _, msg := range history {
-			_ = ws.WriteJSON(msg)
+			_ = hub.SendTo(ws, msg)
 		}
 
 		for {
@@ -66,37 +244,54 @@ func WSHandler(hub *Hub) gin.HandlerFunc {
 
 			var incoming incomingMessage
 			if err := json.Unmarshal(payload, &incoming); err != nil {
-				text := strings.TrimSpace(string(payload))
+				// plain text frames are posted as-is
+				incoming = incomingMessage{Text: string(payload)}
+			}
+			text := strings.TrimSpace(incoming.Text)
+
+			switch {
+			case member.Anonymous():
+				err = apperr.Unauthenticated("sign in to post")
+			case incoming.Type == "dm":
+				err = hub.Direct(context.Background(), member, strings.TrimSpace(incoming.UserID), text)
+			case incoming.Type == "typing":
+				err = hub.Typing(room, member, strings.TrimSpace(incoming.UserID))
+			case incoming.Type == "read":
+				target := strings.TrimSpace(incoming.Room)
+				if target == "" {
+					target = room
+				}
+				err = hub.MarkRead(context.Background(), member, target, incoming.ID)
+			case incoming.Type == "edit":
+				err = hub.Edit(context.Background(), room, incoming.ID, member, text)
+			case incoming.Type == "delete":
+				err = hub.Delete(context.Background(), room, incoming.ID, member)
+			case IsModeration(incoming.Type):
+				err = hub.Moderate(context.Background(), room, member, Action{
+					Type:    incoming.Type,
+					UserID:  strings.TrimSpace(incoming.UserID),
+					Minutes: incoming.Minutes,
+					Seconds: incoming.Seconds,
+					Reason:  strings.TrimSpace(incoming.Reason),
+				})
+			case incoming.Type == "" || incoming.Type == "message":
 				if text == "" {
 					continue
 				}
-				hub.Broadcast(Message{
-					Type: "message",
-					Room: room,
-					User: hub.User(room, ws),
-					Text: text,
-					At:   time.Now().UTC(),
+				err = hub.Post(context.Background(), Message{
+					Room:           room,
+					UserID:         member.UserID,
+					User:           member.Username,
+					Text:           text,
+					At:             time.Now().UTC(),
+					SpoilerChapter: incoming.SpoilerChapter,
 				})
-				continue
-			}
-
-			text := strings.TrimSpace(incoming.Text)
-			if text == "" {
-				continue
+			default:
+				err = apperr.Invalid("unknown message type")
 			}
-
-			msgUser := strings.TrimSpace(incoming.User)
-			if msgUser == "" {
-				msgUser = hub.User(room, ws)
+			if err != nil {
+				_ = hub.SendTo(ws, Message{Type: "error", Room: room, Text: apperr.Message(err), At: time.Now().UTC()})
 			}
-
-			hub.Broadcast(Message{
-				Type: "message",
-				Room: room,
-				User: msgUser,
-				Text: text,
-				At:   time.Now().UTC(),
-			})
 		}
 
 		hub.Leave(room, ws)
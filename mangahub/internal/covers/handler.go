@@ -0,0 +1,85 @@
+package covers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Store *Store
+	Repo  *Repo
+}
+
+func NewHandler(store *Store, repo *Repo) *Handler {
+	return &Handler{Store: store, Repo: repo}
+}
+
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/:hash", h.get) // GET /covers/:hash?size=small|medium|large
+	rg.HEAD("/:hash", h.get)
+}
+
+// get serves a mirrored cover. A hash names fixed bytes, so responses may
+// be cached for good. When the file is gone, the client is sent to the
+// URL it was mirrored from; when a thumbnail cannot be made, it gets the
+// original.
+func (h *Handler) get(c *gin.Context) {
+	hash := c.Param("hash")
+	if !ValidHash(hash) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cover not found"})
+		return
+	}
+	size := c.Query("size")
+	if _, ok := Sizes[size]; size != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size must be small, medium or large"})
+		return
+	}
+
+	ctype, source, found, err := h.Repo.ByHash(c.Request.Context(), hash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	path, etag := h.Store.Path(hash), `"`+hash+`"`
+	if _, err := os.Stat(path); err != nil {
+		if found {
+			c.Header("Cache-Control", "no-cache")
+			c.Redirect(http.StatusFound, source)
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "cover not found"})
+		return
+	}
+	if size != "" {
+		thumb, err := h.Store.Thumb(hash, size)
+		switch {
+		case err == nil:
+			path, etag, ctype = thumb, `"`+hash+"-"+size+`"`, "image/jpeg"
+		case !errors.Is(err, errNoThumb):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	if ctype != "" {
+		c.Header("Content-Type", ctype)
+	}
+	http.ServeContent(c.Writer, c.Request, "", st.ModTime(), f)
+}
@@ -0,0 +1,124 @@
+package covers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mirror downloads the covers of stored titles into a Store.
+type Mirror struct {
+	Store *Store
+	Repo  *Repo
+	// Fetch downloads one image.
+	Fetch func(ctx context.Context, url string) ([]byte, error)
+	// Refresh is how long a mirrored cover is kept before it is fetched
+	// again; default 30 days.
+	Refresh time.Duration
+	// Workers is how many downloads run at once; default 4.
+	Workers int
+	// MaxBytes rejects larger images; default DefaultMaxBytes. Fetch
+	// should stop reading past it too.
+	MaxBytes int
+}
+
+// DefaultMaxBytes is the largest cover mirrored when Mirror.MaxBytes is
+// not set.
+const DefaultMaxBytes = 5 << 20
+
+// MirrorStats counts what one Mirror.Run did.
+type MirrorStats struct {
+	Fetched int
+	Failed  int
+}
+
+// Run fetches every cover that is due. A failed download keeps the copy
+// mirrored before, if any, and is retried later (see Repo.Failed).
+func (m *Mirror) Run(ctx context.Context) (MirrorStats, error) {
+	var stats MirrorStats
+	if m.Refresh <= 0 {
+		m.Refresh = 30 * 24 * time.Hour
+	}
+	if m.Workers <= 0 {
+		m.Workers = 4
+	}
+	if m.MaxBytes <= 0 {
+		m.MaxBytes = DefaultMaxBytes
+	}
+
+	urls, err := m.Repo.Due(ctx, time.Now().UTC())
+	if err != nil {
+		return stats, err
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		jobs = make(chan string)
+	)
+	for range m.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range jobs {
+				err := m.mirror(ctx, url)
+				mu.Lock()
+				if err != nil {
+					stats.Failed++
+				} else {
+					stats.Fetched++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, url := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- url
+	}
+	close(jobs)
+	wg.Wait()
+	return stats, nil
+}
+
+func (m *Mirror) mirror(ctx context.Context, url string) error {
+	now := time.Now().UTC()
+	hash, ctype, err := m.download(ctx, url)
+	if err == nil {
+		err = m.Repo.Saved(ctx, url, hash, ctype, now, now.Add(m.Refresh))
+	}
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		// cut off by the caller: not the host's fault
+		return err
+	}
+	log.Printf("[covers] %s: %v", url, err)
+	if ferr := m.Repo.Failed(ctx, url, err, now); ferr != nil {
+		log.Printf("[covers] %v", ferr)
+	}
+	return err
+}
+
+func (m *Mirror) download(ctx context.Context, url string) (hash, contentType string, err error) {
+	data, err := m.Fetch(ctx, url)
+	if err != nil {
+		return "", "", err
+	}
+	if len(data) > m.MaxBytes {
+		return "", "", fmt.Errorf("image is %d bytes, over the %d byte limit", len(data), m.MaxBytes)
+	}
+	contentType = http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", "", fmt.Errorf("not an image (%s)", contentType)
+	}
+	hash, err = m.Store.Put(data)
+	return hash, contentType, err
+}
@@ -0,0 +1,107 @@
+package covers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Repo tracks which cover URLs are mirrored, as what, and when to check
+// them again.
+type Repo struct {
+	DB *sql.DB
+}
+
+func NewRepo(db *sql.DB) *Repo {
+	return &Repo{DB: db}
+}
+
+// Due lists the cover URLs of stored titles that were never mirrored or
+// whose next check has come.
+func (r *Repo) Due(ctx context.Context, now time.Time) ([]string, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT DISTINCT m.cover_url
+		FROM manga m
+		LEFT JOIN covers c ON c.url = m.cover_url
+		WHERE COALESCE(m.cover_url, '') != '' AND (c.url IS NULL OR c.next_check_at <= ?)
+		ORDER BY m.cover_url
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("list due covers: %w", err)
+	}
+	defer rows.Close()
+	var urls []string
+	for rows.Next() {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			return nil, fmt.Errorf("scan due cover: %w", err)
+		}
+		urls = append(urls, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list due covers: %w", err)
+	}
+	return urls, nil
+}
+
+// Saved records that url was downloaded as hash.
+func (r *Repo) Saved(ctx context.Context, url, hash, contentType string, now, next time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO covers (url, hash, content_type, fetched_at, checked_at, next_check_at, failures, error)
+		VALUES (?, ?, ?, ?, ?, ?, 0, NULL)
+		ON CONFLICT(url) DO UPDATE SET
+		  hash = excluded.hash,
+		  content_type = excluded.content_type,
+		  fetched_at = excluded.fetched_at,
+		  checked_at = excluded.checked_at,
+		  next_check_at = excluded.next_check_at,
+		  failures = 0,
+		  error = NULL
+	`, url, hash, contentType, now, now, next)
+	if err != nil {
+		return fmt.Errorf("save cover %s: %w", url, err)
+	}
+	return nil
+}
+
+// Failed records a failed download of url and when to try again: an hour
+// later for each failure in a row, at most a day. Whatever was mirrored
+// before stays in place.
+func (r *Repo) Failed(ctx context.Context, url string, cause error, now time.Time) error {
+	var failures int
+	err := r.DB.QueryRowContext(ctx, `SELECT failures FROM covers WHERE url = ?`, url).Scan(&failures)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("load cover %s: %w", url, err)
+	}
+	failures++
+	retry := now.Add(time.Duration(min(failures, 24)) * time.Hour)
+	_, err = r.DB.ExecContext(ctx, `
+		INSERT INTO covers (url, checked_at, next_check_at, failures, error)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET
+		  checked_at = excluded.checked_at,
+		  next_check_at = excluded.next_check_at,
+		  failures = excluded.failures,
+		  error = excluded.error
+	`, url, now, retry, failures, cause.Error())
+	if err != nil {
+		return fmt.Errorf("save cover %s: %w", url, err)
+	}
+	return nil
+}
+
+// ByHash returns the content type of the image with hash and a URL it
+// was mirrored from. found is false when no cover has that hash.
+func (r *Repo) ByHash(ctx context.Context, hash string) (contentType, url string, found bool, err error) {
+	err = r.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(content_type, ''), url FROM covers WHERE hash = ? ORDER BY fetched_at DESC LIMIT 1
+	`, hash).Scan(&contentType, &url)
+	if err == sql.ErrNoRows {
+		return "", "", false, nil
+	}
+	if err != nil {
+		return "", "", false, fmt.Errorf("load cover %s: %w", hash, err)
+	}
+	return contentType, url, true, nil
+}
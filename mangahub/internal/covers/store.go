@@ -0,0 +1,89 @@
+package covers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Store keeps cover images on disk by the SHA-256 of their bytes, so an
+// image is stored once however many titles or URLs share it, and a hash
+// always means the same bytes:
+//
+//	<dir>/ab/ab12…ef           originals
+//	<dir>/thumbs/small/ab/ab12…ef.jpg
+type Store struct {
+	Dir string
+
+	thumbs singleflight.Group // thumbnails being made, by path
+}
+
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// Put stores data unless it is there already and returns its hash.
+func (s *Store) Put(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path := s.Path(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := writeFile(path, data); err != nil {
+		return "", fmt.Errorf("store cover %s: %w", hash, err)
+	}
+	return hash, nil
+}
+
+// Path is where the original image with hash lives.
+func (s *Store) Path(hash string) string {
+	return filepath.Join(s.Dir, hash[:2], hash)
+}
+
+// thumbPath is where the thumbnail of hash at size is cached.
+func (s *Store) thumbPath(hash, size string) string {
+	return filepath.Join(s.Dir, "thumbs", size, hash[:2], hash+".jpg")
+}
+
+// ValidHash reports whether h looks like a hash Put returns, so it is safe
+// to build paths from.
+func ValidHash(h string) bool {
+	if len(h) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range h {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// writeFile writes through a temporary file, so readers never see part
+// of an image.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if err := errors.Join(werr, cerr); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package covers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"os"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Sizes are the thumbnail widths served by name (?size=small); the height
+// keeps the aspect ratio.
+var Sizes = map[string]int{
+	"small":  160,
+	"medium": 320,
+	"large":  640,
+}
+
+// maxThumbPixels is the largest image thumbnails are made from. A small
+// file can claim huge dimensions, and decoding it would take that much
+// memory.
+const maxThumbPixels = 24 << 20
+
+// errNoThumb means the original should be served instead: it is in a
+// format we cannot decode, no wider than the thumbnail, or too large to
+// decode.
+var errNoThumb = errors.New("no thumbnail")
+
+// Thumb returns the path of hash's thumbnail at size, making it on first
+// use. Concurrent requests for the same thumbnail share one build.
+func (s *Store) Thumb(hash, size string) (string, error) {
+	width, ok := Sizes[size]
+	if !ok {
+		return "", fmt.Errorf("unknown size %q", size)
+	}
+	path := s.thumbPath(hash, size)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	_, err, _ := s.thumbs.Do(path, func() (any, error) {
+		return nil, s.makeThumb(hash, path, width)
+	})
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// makeThumb scales the original with hash down to width and stores it at
+// path.
+func (s *Store) makeThumb(hash, path string, width int) error {
+	if _, err := os.Stat(path); err == nil {
+		// made by a build that finished just before this one started
+		return nil
+	}
+	data, err := os.ReadFile(s.Path(hash))
+	if err != nil {
+		return err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width <= width || cfg.Width*cfg.Height > maxThumbPixels {
+		return errNoThumb
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return errNoThumb
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scale(src, width), &jpeg.Options{Quality: 85}); err != nil {
+		return fmt.Errorf("encode thumbnail: %w", err)
+	}
+	if err := writeFile(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("store thumbnail: %w", err)
+	}
+	return nil
+}
+
+// scale shrinks src to width, averaging each block of source pixels into
+// one.
+func scale(src image.Image, width int) image.Image {
+	sb := src.Bounds()
+	height := max(1, sb.Dy()*width/sb.Dx())
+	rgba := image.NewRGBA(image.Rect(0, 0, sb.Dx(), sb.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, sb.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sb.Dy()/height, max((y+1)*sb.Dy()/height, y*sb.Dy()/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sb.Dx()/width, max((x+1)*sb.Dx()/width, x*sb.Dx()/width+1)
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r, g, b, a = r+int(p[0]), g+int(p[1]), b+int(p[2]), a+int(p[3])
+					n++
+				}
+			}
+			i := y*dst.Stride + x*4
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
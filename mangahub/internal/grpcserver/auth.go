@@ -0,0 +1,66 @@
+package grpcserver
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"mangahub/internal/apperr"
+	"mangahub/internal/auth"
+	"mangahub/pkg/grpc/mangapb"
+)
+
+func (s *Server) Register(ctx context.Context, req *mangapb.RegisterRequest) (*mangapb.AuthResponse, error) {
+	u, err := s.Auth.Register(ctx, req.GetUsername(), req.GetEmail(), req.GetPassword())
+	if err != nil {
+		return nil, apperr.GRPC(err)
+	}
+	return s.authResponse(u)
+}
+
+func (s *Server) Login(ctx context.Context, req *mangapb.LoginRequest) (*mangapb.AuthResponse, error) {
+	u, err := s.Auth.Login(ctx, req.GetEmail(), req.GetPassword())
+	if err != nil {
+		return nil, apperr.GRPC(err)
+	}
+	return s.authResponse(u)
+}
+
+func (s *Server) ChangePassword(ctx context.Context, req *mangapb.ChangePasswordRequest) (*mangapb.ChangePasswordResponse, error) {
+	userID, err := callerID(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Auth.ChangePassword(ctx, userID, req.GetOldPassword(), req.GetNewPassword()); err != nil {
+		return nil, apperr.GRPC(err)
+	}
+	return &mangapb.ChangePasswordResponse{}, nil
+}
+
+func (s *Server) Logout(ctx context.Context, _ *mangapb.LogoutRequest) (*mangapb.LogoutResponse, error) {
+	userID, err := callerID(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Auth.Logout(ctx, userID); err != nil {
+		return nil, apperr.GRPC(err)
+	}
+	return &mangapb.LogoutResponse{}, nil
+}
+
+func (s *Server) authResponse(u *auth.User) (*mangapb.AuthResponse, error) {
+	token, exp, err := s.Tokens.Sign(u)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "token failed")
+	}
+	return &mangapb.AuthResponse{
+		User: &mangapb.User{
+			Id:       u.ID,
+			Username: u.Username,
+			Email:    u.Email,
+		},
+		Token:         token,
+		ExpiresAtUnix: exp.Unix(),
+	}, nil
+}
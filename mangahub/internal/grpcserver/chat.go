@@ -0,0 +1,249 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	stdsync "sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"mangahub/internal/apperr"
+	"mangahub/internal/auth"
+	"mangahub/internal/chat"
+	"mangahub/pkg/grpc/mangapb"
+)
+
+func (s *Server) History(ctx context.Context, req *mangapb.ChatHistoryRequest) (*mangapb.ChatHistoryResponse, error) {
+	if s.ChatHub == nil {
+		return nil, status.Error(codes.Unavailable, "chat is only available from the api-server process")
+	}
+	room := strings.TrimSpace(req.GetRoom())
+	if room == "" {
+		return nil, status.Error(codes.InvalidArgument, "room required")
+	}
+
+	history, err := s.ChatHub.Page(ctx, room, req.GetBefore(), int(req.GetLimit()), chatMember(ctx))
+	if err != nil {
+		return nil, apperr.GRPC(err)
+	}
+	resp := &mangapb.ChatHistoryResponse{Messages: make([]*mangapb.ChatMessage, 0, len(history))}
+	for _, msg := range history {
+		resp.Messages = append(resp.Messages, chatToProto(msg))
+	}
+	return resp, nil
+}
+
+// Chat joins the room named in the first request and posts the text of every
+// request as the caller. The stream receives the room's history followed by
+// live messages, exactly like a /ws/chat connection.
+func (s *Server) Chat(stream mangapb.ChatService_ChatServer) error {
+	if s.ChatHub == nil {
+		return status.Error(codes.Unavailable, "chat is only available from the api-server process")
+	}
+	claims := auth.ClaimsFromContext(stream.Context())
+	if claims == nil {
+		return status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	room := strings.TrimSpace(first.GetRoom())
+	if room == "" {
+		return status.Error(codes.InvalidArgument, "room required on the first message")
+	}
+	member := chat.Member{UserID: claims.UserID, Username: claims.Username}
+	if err := s.ChatHub.Open(stream.Context(), room, member); err != nil {
+		return apperr.GRPC(err)
+	}
+
+	conn := &chatStream{stream: stream, done: make(chan struct{})}
+	history := s.ChatHub.Join(room, conn, member)
+	defer s.ChatHub.Leave(room, conn)
+
+	for _, msg := range history {
+		if err := conn.send(msg); err != nil {
+			return err
+		}
+	}
+
+	// Recv runs apart so a kick or ban (which closes conn) ends the stream
+	// without waiting for the client's next message
+	reqs := make(chan *mangapb.ChatRequest)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case reqs <- req:
+			case <-conn.done:
+				return
+			}
+		}
+	}()
+
+	req := first
+	for {
+		text := strings.TrimSpace(req.GetText())
+		var err error
+		switch typ := req.GetType(); {
+		case chat.IsModeration(typ):
+			err = s.ChatHub.Moderate(stream.Context(), room, member, chat.Action{
+				Type:    typ,
+				UserID:  strings.TrimSpace(req.GetUserId()),
+				Minutes: int(req.GetMinutes()),
+				Seconds: int(req.GetSeconds()),
+				Reason:  strings.TrimSpace(req.GetReason()),
+			})
+		case typ == "dm":
+			err = s.ChatHub.Direct(stream.Context(), member, strings.TrimSpace(req.GetUserId()), text)
+		case typ == "typing":
+			err = s.ChatHub.Typing(room, member, strings.TrimSpace(req.GetUserId()))
+		case typ == "read":
+			target := room
+			if req != first && strings.TrimSpace(req.GetRoom()) != "" {
+				target = strings.TrimSpace(req.GetRoom())
+			}
+			err = s.ChatHub.MarkRead(stream.Context(), member, target, req.GetId())
+		case typ == "edit":
+			err = s.ChatHub.Edit(stream.Context(), room, req.GetId(), member, text)
+		case typ == "delete":
+			err = s.ChatHub.Delete(stream.Context(), room, req.GetId(), member)
+		case typ == "" || typ == "message":
+			if text != "" {
+				err = s.ChatHub.Post(stream.Context(), chat.Message{
+					Room:           room,
+					UserID:         member.UserID,
+					User:           member.Username,
+					Text:           text,
+					At:             time.Now().UTC(),
+					SpoilerChapter: int(req.GetSpoilerChapter()),
+				})
+			}
+		default:
+			err = apperr.Invalid("unknown message type")
+		}
+		if err != nil {
+			_ = conn.send(chat.Message{Type: "error", Room: room, Text: apperr.Message(err), At: time.Now().UTC()})
+		}
+
+		select {
+		case req = <-reqs:
+		case err := <-recvErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case <-conn.done:
+			if s.tokenRevoked(stream.Context()) {
+				return status.Error(codes.Unauthenticated, "token revoked")
+			}
+			return status.Error(codes.PermissionDenied, "removed from the room")
+		}
+	}
+}
+
+func (s *Server) ListRooms(ctx context.Context, req *mangapb.ListChatRoomsRequest) (*mangapb.ListChatRoomsResponse, error) {
+	if s.ChatHub == nil {
+		return nil, status.Error(codes.Unavailable, "chat is only available from the api-server process")
+	}
+	list, err := s.ChatHub.Rooms(ctx, strings.TrimSpace(req.GetMangaId()), int(req.GetLimit()), int(req.GetOffset()))
+	if err != nil {
+		return nil, apperr.GRPC(err)
+	}
+	resp := &mangapb.ListChatRoomsResponse{
+		Rooms: make([]*mangapb.ChatRoom, 0, len(list.Items)),
+		Total: int32(list.Total),
+	}
+	for _, r := range list.Items {
+		room := &mangapb.ChatRoom{
+			Name:          r.Name,
+			MangaId:       r.MangaID,
+			Title:         r.Title,
+			Members:       int32(r.Members),
+			Active:        int32(r.Active),
+			CreatedAtUnix: r.CreatedAt.Unix(),
+		}
+		if r.LastMessageAt != nil {
+			room.LastMessageAtUnix = r.LastMessageAt.Unix()
+		}
+		resp.Rooms = append(resp.Rooms, room)
+	}
+	return resp, nil
+}
+
+// chatMember is the caller of a public chat method; anonymous without a
+// token.
+func chatMember(ctx context.Context) chat.Member {
+	if claims := auth.ClaimsFromContext(ctx); claims != nil {
+		return chat.Member{UserID: claims.UserID, Username: claims.Username}
+	}
+	return chat.Member{}
+}
+
+// chatStream adapts a Chat stream to chat.Conn. The hub hands it the same
+// JSON frames it writes to WebSockets.
+type chatStream struct {
+	mu     stdsync.Mutex
+	stream mangapb.ChatService_ChatServer
+	closed bool
+	done   chan struct{} // closed by Close
+}
+
+func (c *chatStream) WriteMessage(_ int, data []byte) error {
+	var msg chat.Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	return c.send(msg)
+}
+
+func (c *chatStream) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+	return nil
+}
+
+func (c *chatStream) send(msg chat.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return io.ErrClosedPipe
+	}
+	return c.stream.Send(chatToProto(msg))
+}
+
+func chatToProto(msg chat.Message) *mangapb.ChatMessage {
+	out := &mangapb.ChatMessage{
+		Id:             msg.ID,
+		Type:           msg.Type,
+		Room:           msg.Room,
+		UserId:         msg.UserID,
+		User:           msg.User,
+		Text:           msg.Text,
+		AtUnix:         msg.At.Unix(),
+		SpoilerChapter: int32(msg.SpoilerChapter),
+		SpoilerHidden:  msg.SpoilerHidden,
+	}
+	if msg.EditedAt != nil {
+		out.EditedAtUnix = msg.EditedAt.Unix()
+	}
+	return out
+}
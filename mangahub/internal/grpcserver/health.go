@@ -0,0 +1,70 @@
+package grpcserver
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Health serves grpc.health.v1 and mirrors the API's /ready check: the
+// server ("") and every registered service report SERVING while the database
+// answers a ping, NOT_SERVING otherwise.
+type Health struct {
+	*health.Server
+	DB *sql.DB
+
+	services []string
+}
+
+func NewHealth(db *sql.DB) *Health {
+	return &Health{Server: health.NewServer(), DB: db}
+}
+
+// RegisterService adds the health service to gs. Call it after the other
+// services are registered so their names get a status too.
+func (h *Health) RegisterService(gs *grpc.Server) {
+	h.services = []string{""}
+	for name := range gs.GetServiceInfo() {
+		h.services = append(h.services, name)
+	}
+	healthpb.RegisterHealthServer(gs, h.Server)
+}
+
+// Run checks the database immediately and then every interval until ctx is
+// done.
+func (h *Health) Run(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		st := h.check(ctx)
+		if st != last {
+			log.Printf("[grpc] health: %s", st)
+			last = st
+		}
+		for _, name := range h.services {
+			h.SetServingStatus(name, st)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *Health) check(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := h.DB.PingContext(ctx); err != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,69 @@
+package grpcserver
+
+import (
+	"context"
+
+	"mangahub/internal/apperr"
+	"mangahub/pkg/grpc/mangapb"
+	"mangahub/pkg/models"
+)
+
+func (s *Server) ListHistory(ctx context.Context, req *mangapb.ListHistoryRequest) (*mangapb.ListHistoryResponse, error) {
+	userID, err := callerID(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.Progress.List(ctx, userID, req.GetMangaId(), int(req.GetLimit()), int(req.GetOffset()))
+	if err != nil {
+		return nil, apperr.GRPC(err)
+	}
+
+	resp := &mangapb.ListHistoryResponse{
+		Total:  int32(res.Total),
+		Limit:  int32(res.Limit),
+		Offset: int32(res.Offset),
+		Items:  make([]*mangapb.HistoryEntry, 0, len(res.Items)),
+	}
+	for _, item := range res.Items {
+		resp.Items = append(resp.Items, historyToProto(item))
+	}
+	return resp, nil
+}
+
+func (s *Server) AddHistory(ctx context.Context, req *mangapb.AddHistoryRequest) (*mangapb.AddHistoryResponse, error) {
+	userID, err := callerID(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	entry := models.ProgressHistory{
+		UserID:  userID,
+		MangaID: req.GetMangaId(),
+		Chapter: int(req.GetChapter()),
+	}
+	if req.Volume != nil {
+		v := int(req.GetVolume())
+		entry.Volume = &v
+	}
+
+	saved, err := s.Progress.Add(ctx, entry)
+	if err != nil {
+		return nil, apperr.GRPC(err)
+	}
+	return &mangapb.AddHistoryResponse{Entry: historyToProto(saved)}, nil
+}
+
+func historyToProto(e models.ProgressHistory) *mangapb.HistoryEntry {
+	out := &mangapb.HistoryEntry{
+		UserId:  e.UserID,
+		MangaId: e.MangaID,
+		Chapter: int32(e.Chapter),
+		AtUnix:  e.At.Unix(),
+	}
+	if e.Volume != nil {
+		v := int32(*e.Volume)
+		out.Volume = &v
+	}
+	return out
+}
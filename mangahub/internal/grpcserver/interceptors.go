@@ -0,0 +1,270 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"mangahub/internal/auth"
+)
+
+const requestIDHeader = "x-request-id"
+
+// publicMethods may be called without a bearer token: whole services (ending
+// in "/") or single methods. A valid token is still attached to the context
+// when one is sent; an invalid one is ignored and the call served
+// anonymously.
+var publicMethods = []string{
+	"/mangahub.v1.MangaService/",
+	"/mangahub.v1.AuthService/Register",
+	"/mangahub.v1.AuthService/Login",
+	"/mangahub.v1.ReviewService/ListReviews",
+	"/mangahub.v1.ChatService/History",
+	"/mangahub.v1.ChatService/ListRooms",
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.v1.ServerReflection/",
+	"/grpc.reflection.v1alpha.ServerReflection/",
+}
+
+// callInfo is attached by the outermost interceptor and filled in by inner
+// ones, so the logging interceptor can report who made the call.
+type callInfo struct {
+	requestID string
+	userID    string
+}
+
+type callInfoCtxKey struct{}
+
+// RequestID returns the id assigned to the current call by the interceptor chain.
+func RequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(callInfoCtxKey{}).(*callInfo); ok {
+		return info.requestID
+	}
+	return ""
+}
+
+// Authenticator validates bearer tokens from call metadata.
+type Authenticator struct {
+	Tokens auth.TokenService
+	Users  *auth.Repo
+}
+
+// UnaryInterceptors returns the chain for unary calls, outermost first:
+// request id, logging, panic recovery, authentication.
+func UnaryInterceptors(a Authenticator) []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		unaryRequestID,
+		unaryLogging,
+		unaryRecovery,
+		a.unary,
+	}
+}
+
+// StreamInterceptors mirrors UnaryInterceptors for streaming calls.
+func StreamInterceptors(a Authenticator) []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		streamRequestID,
+		streamLogging,
+		streamRecovery,
+		a.stream,
+	}
+}
+
+// ServerOptions wires both chains into grpc.NewServer options.
+func ServerOptions(a Authenticator) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryInterceptors(a)...),
+		grpc.ChainStreamInterceptor(StreamInterceptors(a)...),
+	}
+}
+
+func unaryRequestID(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	id := incomingRequestID(ctx)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, id))
+	return handler(context.WithValue(ctx, callInfoCtxKey{}, &callInfo{requestID: id}), req)
+}
+
+func streamRequestID(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	id := incomingRequestID(ss.Context())
+	_ = ss.SetHeader(metadata.Pairs(requestIDHeader, id))
+	return handler(srv, &wrappedStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), callInfoCtxKey{}, &callInfo{requestID: id})})
+}
+
+func unaryLogging(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func streamLogging(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logCall(ss.Context(), info.FullMethod, start, err)
+	return err
+}
+
+func unaryRecovery(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[grpc] panic in %s (request %s): %v\n%s", info.FullMethod, RequestID(ctx), r, debug.Stack())
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func streamRecovery(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[grpc] panic in %s (request %s): %v\n%s", info.FullMethod, RequestID(ss.Context()), r, debug.Stack())
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(srv, ss)
+}
+
+func (a Authenticator) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a Authenticator) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate validates the bearer token (signature, expiry and
+// token_version) and stores the caller's claims in the context.
+func (a Authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	public := isPublic(method)
+
+	raw := bearerToken(ctx)
+	if raw == "" {
+		if public {
+			return ctx, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	claims, err := a.Tokens.Parse(raw)
+	if err == nil && a.Users != nil {
+		var current int
+		current, err = a.Users.GetTokenVersion(ctx, claims.UserID)
+		if err == nil && current != claims.TokenVersion {
+			err = status.Error(codes.Unauthenticated, "token revoked")
+		}
+	}
+	if err != nil {
+		if public {
+			// a stale token must not lock a client out of public calls
+			return ctx, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	if info, ok := ctx.Value(callInfoCtxKey{}).(*callInfo); ok {
+		info.userID = claims.UserID
+	}
+	return auth.WithClaims(ctx, claims), nil
+}
+
+// callerID returns the authenticated user, rejecting requests that name a
+// different user_id. An empty requested id means "the caller".
+//
+// The token behind it is checked once, when the call starts. Streams may
+// outlive it: Subscribe and Chat end when the hubs drop the user on
+// logout, password change or account deletion (auth.Service.OnRevoke),
+// and check tokenRevoked then, but any other stream keeps its caller
+// until it ends.
+func callerID(ctx context.Context, requested string) (string, error) {
+	claims := auth.ClaimsFromContext(ctx)
+	if claims == nil {
+		return "", status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	requested = strings.TrimSpace(requested)
+	if requested != "" && requested != claims.UserID {
+		return "", status.Error(codes.PermissionDenied, "user_id does not match caller")
+	}
+	return claims.UserID, nil
+}
+
+// tokenRevoked reports whether the token the call was authenticated with
+// has been revoked since, or its account deleted.
+func (s *Server) tokenRevoked(ctx context.Context) bool {
+	claims := auth.ClaimsFromContext(ctx)
+	if claims == nil || s.Auth.Repo == nil {
+		return false
+	}
+	current, err := s.Auth.Repo.GetTokenVersion(ctx, claims.UserID)
+	if errors.Is(err, auth.ErrUserNotFound) {
+		return true
+	}
+	return err == nil && current != claims.TokenVersion
+}
+
+func isPublic(method string) bool {
+	for _, prefix := range publicMethods {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, v := range md.Get("authorization") {
+		if strings.HasPrefix(strings.ToLower(v), "bearer ") {
+			return strings.TrimSpace(v[len("Bearer "):])
+		}
+	}
+	return ""
+}
+
+func incomingRequestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDHeader); len(v) > 0 && strings.TrimSpace(v[0]) != "" {
+			return strings.TrimSpace(v[0])
+		}
+	}
+	return uuid.NewString()
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	user, requestID := "-", ""
+	if info, ok := ctx.Value(callInfoCtxKey{}).(*callInfo); ok {
+		requestID = info.requestID
+		if info.userID != "" {
+			user = info.userID
+		}
+	}
+	log.Printf("[grpc] %s code=%s dur=%s user=%s request_id=%s",
+		method, status.Code(err), time.Since(start).Round(time.Microsecond), user, requestID)
+}
+
+// wrappedStream overrides the context of a server stream.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context { return w.ctx }
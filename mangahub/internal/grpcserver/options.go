@@ -0,0 +1,44 @@
+package grpcserver
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+
+	"mangahub/pkg/utils"
+)
+
+// TransportOptions applies the message size and keepalive settings from cfg.
+func TransportOptions(cfg utils.GrpcConfig) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(cfg.MaxRecvMsgBytes),
+		grpc.MaxSendMsgSize(cfg.MaxSendMsgBytes),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    cfg.KeepaliveTime,
+			Timeout: cfg.KeepaliveTimeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime: cfg.MinPingInterval,
+			// Subscribe and Chat streams may sit idle between events
+			PermitWithoutStream: true,
+		}),
+	}
+}
+
+// Stop drains in-flight calls with GracefulStop and falls back to Stop once
+// timeout passes, since Subscribe and Chat streams never finish on their own.
+func Stop(gs *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		gs.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		gs.Stop()
+		<-stopped
+	}
+}
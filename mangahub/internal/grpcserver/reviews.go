@@ -0,0 +1,62 @@
+package grpcserver
+
+import (
+	"context"
+
+	"mangahub/internal/apperr"
+	"mangahub/pkg/grpc/mangapb"
+	"mangahub/pkg/models"
+)
+
+func (s *Server) ListReviews(ctx context.Context, req *mangapb.ListReviewsRequest) (*mangapb.ListReviewsResponse, error) {
+	res, err := s.Reviews.List(ctx, req.GetMangaId(), int(req.GetLimit()), int(req.GetOffset()))
+	if err != nil {
+		return nil, apperr.GRPC(err)
+	}
+
+	resp := &mangapb.ListReviewsResponse{
+		Limit:  int32(res.Limit),
+		Offset: int32(res.Offset),
+		Items:  make([]*mangapb.Review, 0, len(res.Items)),
+	}
+	for _, item := range res.Items {
+		resp.Items = append(resp.Items, reviewToProto(item))
+	}
+	return resp, nil
+}
+
+func (s *Server) CreateReview(ctx context.Context, req *mangapb.CreateReviewRequest) (*mangapb.CreateReviewResponse, error) {
+	userID, err := callerID(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := s.Reviews.Create(ctx, userID, req.GetMangaId(), int(req.GetRating()), req.GetText())
+	if err != nil {
+		return nil, apperr.GRPC(err)
+	}
+	return &mangapb.CreateReviewResponse{Review: reviewToProto(*review)}, nil
+}
+
+func (s *Server) DeleteReview(ctx context.Context, req *mangapb.DeleteReviewRequest) (*mangapb.DeleteReviewResponse, error) {
+	userID, err := callerID(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Reviews.Delete(ctx, req.GetId(), userID); err != nil {
+		return nil, apperr.GRPC(err)
+	}
+	return &mangapb.DeleteReviewResponse{Deleted: true}, nil
+}
+
+func reviewToProto(r models.Review) *mangapb.Review {
+	return &mangapb.Review{
+		Id:            r.ID,
+		UserId:        r.UserID,
+		MangaId:       r.MangaID,
+		Rating:        int32(r.Rating),
+		Text:          r.Text,
+		TimestampUnix: r.Timestamp.Unix(),
+	}
+}
@@ -2,185 +2,151 @@ package grpcserver
 
 import (
 	"context"
-	"strings"
 
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"google.golang.org/grpc"
 
+	"mangahub/internal/apperr"
+	"mangahub/internal/auth"
+	"mangahub/internal/chat"
 	"mangahub/internal/library"
 	"mangahub/internal/manga"
+	"mangahub/internal/progress"
+	"mangahub/internal/reviews"
+	"mangahub/internal/sync"
 	"mangahub/pkg/grpc/mangapb"
 	"mangahub/pkg/models"
 )
 
+// Deps are the services and hubs the RPCs are built on. ChatHub is nil in
+// the standalone grpc-server, and Hub too unless it shares a bus with the
+// api-server; the services that need them answer UNAVAILABLE there.
+type Deps struct {
+	Manga    *manga.Service
+	Library  *library.Service
+	Reviews  *reviews.Service
+	Progress *progress.Service
+	Auth     auth.Service
+	Tokens   auth.TokenService
+	Hub      *sync.Hub
+	ChatHub  *chat.Hub
+}
+
 type Server struct {
 	mangapb.UnimplementedMangaServiceServer
 	mangapb.UnimplementedProgressServiceServer
-	MangaRepo   *manga.Repo
-	LibraryRepo *library.Repo
+	mangapb.UnimplementedSyncServiceServer
+	mangapb.UnimplementedAuthServiceServer
+	mangapb.UnimplementedReviewServiceServer
+	mangapb.UnimplementedHistoryServiceServer
+	mangapb.UnimplementedChatServiceServer
+	Deps
 }
 
-func NewServer(mangaRepo *manga.Repo, libraryRepo *library.Repo) *Server {
-	return &Server{MangaRepo: mangaRepo, LibraryRepo: libraryRepo}
+func NewServer(d Deps) *Server {
+	return &Server{Deps: d}
+}
+
+// RegisterServices attaches every service implemented by s to gs.
+func (s *Server) RegisterServices(gs *grpc.Server) {
+	mangapb.RegisterMangaServiceServer(gs, s)
+	mangapb.RegisterProgressServiceServer(gs, s)
+	mangapb.RegisterSyncServiceServer(gs, s)
+	mangapb.RegisterAuthServiceServer(gs, s)
+	mangapb.RegisterReviewServiceServer(gs, s)
+	mangapb.RegisterHistoryServiceServer(gs, s)
+	mangapb.RegisterChatServiceServer(gs, s)
 }
 
 func (s *Server) ListManga(ctx context.Context, req *mangapb.ListMangaRequest) (*mangapb.ListMangaResponse, error) {
-	if req == nil {
-		return nil, status.Error(codes.InvalidArgument, "request required")
-	}
-	query := manga.ListQuery{
-		Q:      strings.TrimSpace(req.GetQ()),
+	res, err := s.Manga.List(ctx, manga.ListQuery{
+		Q:      req.GetQ(),
 		Genres: req.GetGenres(),
-		Status: strings.TrimSpace(req.GetStatus()),
+		Status: req.GetStatus(),
 		Limit:  int(req.GetLimit()),
 		Offset: int(req.GetOffset()),
-	}
-
-	total, err := s.MangaRepo.Count(ctx, query)
-	if err != nil {
-		return nil, status.Error(codes.Internal, "count failed")
-	}
-
-	items, err := s.MangaRepo.List(ctx, query)
+	})
 	if err != nil {
-		return nil, status.Error(codes.Internal, "list failed")
+		return nil, apperr.GRPC(err)
 	}
 
 	resp := &mangapb.ListMangaResponse{
-		Total:  int32(total),
-		Limit:  int32(query.Limit),
-		Offset: int32(query.Offset),
-		Items:  make([]*mangapb.Manga, 0, len(items)),
+		Total:  int32(res.Total),
+		Limit:  int32(res.Limit),
+		Offset: int32(res.Offset),
+		Items:  make([]*mangapb.Manga, 0, len(res.Items)),
 	}
-	for _, item := range items {
+	for _, item := range res.Items {
 		resp.Items = append(resp.Items, mangaToProto(item))
 	}
 	return resp, nil
 }
 
 func (s *Server) GetManga(ctx context.Context, req *mangapb.GetMangaRequest) (*mangapb.GetMangaResponse, error) {
-	if req == nil || strings.TrimSpace(req.GetId()) == "" {
-		return nil, status.Error(codes.InvalidArgument, "id required")
-	}
-
-	item, err := s.MangaRepo.GetByID(ctx, strings.TrimSpace(req.GetId()))
+	item, err := s.Manga.Get(ctx, req.GetId())
 	if err != nil {
-		return nil, status.Error(codes.Internal, "get failed")
-	}
-	if item == nil {
-		return nil, status.Error(codes.NotFound, "not found")
+		return nil, apperr.GRPC(err)
 	}
-
 	return &mangapb.GetMangaResponse{Manga: mangaToProto(*item)}, nil
 }
 
 func (s *Server) ListProgress(ctx context.Context, req *mangapb.ListProgressRequest) (*mangapb.ListProgressResponse, error) {
-	if req == nil || strings.TrimSpace(req.GetUserId()) == "" {
-		return nil, status.Error(codes.InvalidArgument, "user_id required")
-	}
-
-	statusFilter := normalizeStatus(req.GetStatus())
-	if req.GetStatus() != "" && statusFilter == "" {
-		return nil, status.Error(codes.InvalidArgument, "invalid status filter")
+	userID, err := callerID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
 	}
 
-	items, total, err := s.LibraryRepo.List(
-		ctx,
-		strings.TrimSpace(req.GetUserId()),
-		statusFilter,
-		int(req.GetLimit()),
-		int(req.GetOffset()),
-	)
+	res, err := s.Library.List(ctx, userID, req.GetStatus(), int(req.GetLimit()), int(req.GetOffset()))
 	if err != nil {
-		return nil, status.Error(codes.Internal, "list failed")
+		return nil, apperr.GRPC(err)
 	}
 
 	resp := &mangapb.ListProgressResponse{
-		Total:  int32(total),
-		Limit:  int32(req.GetLimit()),
-		Offset: int32(req.GetOffset()),
-		Items:  make([]*mangapb.ProgressItem, 0, len(items)),
+		Total:  int32(res.Total),
+		Limit:  int32(res.Limit),
+		Offset: int32(res.Offset),
+		Items:  make([]*mangapb.ProgressItem, 0, len(res.Items)),
 	}
-	for _, item := range items {
+	for _, item := range res.Items {
 		resp.Items = append(resp.Items, progressToProto(item))
 	}
 	return resp, nil
 }
 
 func (s *Server) GetProgress(ctx context.Context, req *mangapb.GetProgressRequest) (*mangapb.GetProgressResponse, error) {
-	userID := strings.TrimSpace(req.GetUserId())
-	mangaID := strings.TrimSpace(req.GetMangaId())
-	if userID == "" || mangaID == "" {
-		return nil, status.Error(codes.InvalidArgument, "user_id and manga_id required")
+	userID, err := callerID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
 	}
 
-	item, err := s.LibraryRepo.Get(ctx, userID, mangaID)
+	item, err := s.Library.Get(ctx, userID, req.GetMangaId())
 	if err != nil {
-		return nil, status.Error(codes.Internal, "get failed")
+		return nil, apperr.GRPC(err)
 	}
-	if item == nil {
-		return nil, status.Error(codes.NotFound, "not found")
-	}
-
 	return &mangapb.GetProgressResponse{Item: progressToProto(*item)}, nil
 }
 
 func (s *Server) UpsertProgress(ctx context.Context, req *mangapb.UpsertProgressRequest) (*mangapb.UpsertProgressResponse, error) {
-	if req == nil {
-		return nil, status.Error(codes.InvalidArgument, "request required")
-	}
-	userID := strings.TrimSpace(req.GetUserId())
-	mangaID := strings.TrimSpace(req.GetMangaId())
-	if userID == "" || mangaID == "" {
-		return nil, status.Error(codes.InvalidArgument, "user_id and manga_id required")
-	}
-
-	statusValue := normalizeStatus(req.GetStatus())
-	if statusValue == "" {
-		return nil, status.Error(codes.InvalidArgument, "invalid status")
-	}
-
-	if req.GetCurrentChapter() < 0 {
-		return nil, status.Error(codes.InvalidArgument, "current_chapter must be >= 0")
-	}
-
-	item := models.LibraryItem{
-		UserID:         userID,
-		MangaID:        mangaID,
-		CurrentChapter: int(req.GetCurrentChapter()),
-		Status:         statusValue,
-	}
-
-	if err := s.LibraryRepo.Upsert(ctx, item); err != nil {
-		return nil, status.Error(codes.Internal, "save failed")
+	userID, err := callerID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
 	}
 
-	saved, err := s.LibraryRepo.Get(ctx, userID, mangaID)
+	saved, err := s.Library.Upsert(ctx, userID, req.GetMangaId(), int(req.GetCurrentChapter()), req.GetStatus())
 	if err != nil {
-		return nil, status.Error(codes.Internal, "fetch failed")
+		return nil, apperr.GRPC(err)
 	}
-	if saved == nil {
-		return nil, status.Error(codes.Internal, "saved item not found")
-	}
-
 	return &mangapb.UpsertProgressResponse{Item: progressToProto(*saved)}, nil
 }
 
 func (s *Server) DeleteProgress(ctx context.Context, req *mangapb.DeleteProgressRequest) (*mangapb.DeleteProgressResponse, error) {
-	userID := strings.TrimSpace(req.GetUserId())
-	mangaID := strings.TrimSpace(req.GetMangaId())
-	if userID == "" || mangaID == "" {
-		return nil, status.Error(codes.InvalidArgument, "user_id and manga_id required")
-	}
-
-	deleted, err := s.LibraryRepo.Delete(ctx, userID, mangaID)
+	userID, err := callerID(ctx, req.GetUserId())
 	if err != nil {
-		return nil, status.Error(codes.Internal, "delete failed")
-	}
-	if !deleted {
-		return nil, status.Error(codes.NotFound, "not found")
+		return nil, err
 	}
 
+	if err := s.Library.Delete(ctx, userID, req.GetMangaId()); err != nil {
+		return nil, apperr.GRPC(err)
+	}
 	return &mangapb.DeleteProgressResponse{Deleted: true}, nil
 }
 
@@ -206,20 +172,3 @@ func progressToProto(item models.LibraryItem) *mangapb.ProgressItem {
 		UpdatedAtUnix:  item.UpdatedAt.Unix(),
 	}
 }
-
-func normalizeStatus(value string) string {
-	switch strings.ToLower(strings.TrimSpace(value)) {
-	case "reading":
-		return "reading"
-	case "completed":
-		return "completed"
-	case "wish list", "wish_list", "wishlist":
-		return "wish_list"
-	case "blacklist", "black_list", "black list":
-		return "blacklist"
-	case "":
-		return ""
-	default:
-		return ""
-	}
-}
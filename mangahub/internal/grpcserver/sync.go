@@ -0,0 +1,67 @@
+package grpcserver
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"mangahub/internal/sync"
+	"mangahub/pkg/grpc/mangapb"
+)
+
+// Subscribe streams the caller's library events from the in-process sync hub,
+// first replaying anything after the requested cursor.
+func (s *Server) Subscribe(req *mangapb.SubscribeRequest, stream mangapb.SyncService_SubscribeServer) error {
+	if s.Hub == nil {
+		return status.Error(codes.Unavailable, "sync events are only available from the api-server, or with MANGAHUB_BUS=sqlite")
+	}
+	userID, err := callerID(stream.Context(), "")
+	if err != nil {
+		return err
+	}
+
+	sub, replay, err := s.Hub.Subscribe(userID, req.GetCursor())
+	if errors.Is(err, sync.ErrCursorExpired) {
+		return status.Error(codes.OutOfRange, "cursor expired; resubscribe without a cursor")
+	}
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	defer s.Hub.Unsubscribe(sub)
+
+	for _, ev := range replay {
+		if err := stream.Send(eventToProto(ev)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev, ok := <-sub.C:
+			if !ok {
+				if errors.Is(sub.Err(), sync.ErrUserRemoved) || s.tokenRevoked(stream.Context()) {
+					return status.Error(codes.Unauthenticated, "account removed or signed out")
+				}
+				return status.Error(codes.ResourceExhausted, "subscriber fell behind; resume from the last cursor")
+			}
+			if err := stream.Send(eventToProto(ev)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func eventToProto(ev sync.LibraryEvent) *mangapb.LibraryEvent {
+	return &mangapb.LibraryEvent{
+		Type:           ev.Type,
+		UserId:         ev.UserID,
+		MangaId:        ev.MangaID,
+		CurrentChapter: int32(ev.CurrentChapter),
+		Status:         ev.Status,
+		AtUnix:         ev.At.Unix(),
+		Cursor:         ev.Cursor,
+	}
+}
@@ -4,22 +4,19 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"mangahub/internal/apperr"
 	"mangahub/internal/auth"
-	"mangahub/internal/sync"
-	"mangahub/pkg/models"
 )
 
 type Handler struct {
-	Repo *Repo
-	Hub  *sync.Hub
+	Service *Service
 }
 
-func NewHandler(repo *Repo, hub *sync.Hub) *Handler {
-	return &Handler{Repo: repo, Hub: hub}
+func NewHandler(svc *Service) *Handler {
+	return &Handler{Service: svc}
 }
 
 func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
@@ -51,65 +48,14 @@ func (h *Handler) addOrUpdate(c *gin.Context) {
 
 	mangaID := strings.TrimSpace(req.MangaID)
 	if mangaID == "" {
-		mangaID = strings.TrimSpace(c.Param("manga_id"))
-	}
-	if mangaID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "manga_id required"})
-		return
-	}
-
-	status := normalizeStatus(req.Status)
-	if status == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "status must be one of: reading, completed, wish_list, blacklist",
-		})
-		return
-	}
-
-	if req.CurrentChapter < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "current_chapter must be >= 0"})
-		return
-	}
-
-	if status == "blacklist" && req.CurrentChapter != 0 {
-		req.CurrentChapter = 0
+		mangaID = c.Param("manga_id")
 	}
 
-	item := authToItem(claims.UserID, mangaID, req.CurrentChapter, status)
-	if err := h.Repo.Upsert(c.Request.Context(), item); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "save failed"})
-		return
-	}
-
-	// Return canonical stored row including updated_at
-	saved, err := h.Repo.Get(c.Request.Context(), claims.UserID, mangaID)
+	saved, err := h.Service.Upsert(c.Request.Context(), claims.UserID, mangaID, req.CurrentChapter, req.Status)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "fetch saved failed"})
+		apperr.JSON(c, err)
 		return
 	}
-	if saved == nil {
-		// should not happen, but safe
-		saved = &models.LibraryItem{
-			UserID:         claims.UserID,
-			MangaID:        mangaID,
-			CurrentChapter: req.CurrentChapter,
-			Status:         status,
-			UpdatedAt:      time.Now().UTC(),
-		}
-	}
-
-	if h.Hub != nil {
-		ev := sync.LibraryEvent{
-			Type:           "library.update",
-			UserID:         claims.UserID,
-			MangaID:        mangaID,
-			CurrentChapter: saved.CurrentChapter,
-			Status:         saved.Status,
-			At:             time.Now().UTC(),
-		}
-		go h.Hub.BroadcastJSON(ev)
-	}
-
 	c.JSON(http.StatusOK, saved)
 }
 
@@ -120,29 +66,21 @@ func (h *Handler) list(c *gin.Context) {
 		return
 	}
 
-	status := strings.TrimSpace(c.Query("status"))
-	if status != "" {
-		status = normalizeStatus(status)
-		if status == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status filter"})
-			return
-		}
-	}
-
-	limit := parseInt(c.Query("limit"), 20)
-	offset := parseInt(c.Query("offset"), 0)
-
-	items, total, err := h.Repo.List(c.Request.Context(), claims.UserID, status, limit, offset)
+	res, err := h.Service.List(c.Request.Context(), claims.UserID,
+		c.Query("status"),
+		parseInt(c.Query("limit"), 20),
+		parseInt(c.Query("offset"), 0),
+	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "list failed"})
+		apperr.JSON(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"total":  total,
-		"limit":  limit,
-		"offset": offset,
-		"items":  items,
+		"total":  res.Total,
+		"limit":  res.Limit,
+		"offset": res.Offset,
+		"items":  res.Items,
 	})
 }
 
@@ -153,32 +91,10 @@ func (h *Handler) remove(c *gin.Context) {
 		return
 	}
 
-	mangaID := strings.TrimSpace(c.Param("manga_id"))
-	if mangaID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "manga_id required"})
-		return
-	}
-
-	ok, err := h.Repo.Delete(c.Request.Context(), claims.UserID, mangaID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+	if err := h.Service.Delete(c.Request.Context(), claims.UserID, c.Param("manga_id")); err != nil {
+		apperr.JSON(c, err)
 		return
 	}
-	if !ok {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
-		return
-	}
-
-	if h.Hub != nil {
-		ev := sync.LibraryEvent{
-			Type:    "library.delete",
-			UserID:  claims.UserID,
-			MangaID: mangaID,
-			At:      time.Now().UTC(),
-		}
-		go h.Hub.BroadcastJSON(ev)
-	}
-
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
 
@@ -189,47 +105,14 @@ func (h *Handler) getOne(c *gin.Context) {
 		return
 	}
 
-	mangaID := strings.TrimSpace(c.Param("manga_id"))
-	if mangaID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "manga_id required"})
-		return
-	}
-
-	it, err := h.Repo.Get(c.Request.Context(), claims.UserID, mangaID)
+	it, err := h.Service.Get(c.Request.Context(), claims.UserID, c.Param("manga_id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "get failed"})
-		return
-	}
-	if it == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		apperr.JSON(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, it)
 }
 
-func normalizeStatus(s string) string {
-	switch strings.ToLower(strings.TrimSpace(s)) {
-	case "reading":
-		return "reading"
-	case "completed":
-		return "completed"
-	case "wish list", "wish_list", "wishlist":
-		return "wish_list"
-	case "blacklist", "black_list", "black list":
-		return "blacklist"
-	default:
-		return ""
-	}
-}
-
-func authToItem(userID, mangaID string, chapter int, status string) (it models.LibraryItem) {
-	it.UserID = userID
-	it.MangaID = mangaID
-	it.CurrentChapter = chapter
-	it.Status = status
-	return
-}
-
 func parseInt(s string, def int) int {
 	s = strings.TrimSpace(s)
 	if s == "" {
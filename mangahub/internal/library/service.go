@@ -0,0 +1,176 @@
+package library
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"mangahub/internal/apperr"
+	"mangahub/internal/manga"
+	"mangahub/internal/sync"
+	"mangahub/pkg/models"
+)
+
+// Service is the library API shared by the HTTP handler and gRPC. Writes
+// publish to the sync hub whichever transport they came from.
+type Service struct {
+	Repo  *Repo
+	Manga *manga.Repo
+	Hub   *sync.Hub // nil disables publishing
+}
+
+func NewService(repo *Repo, mangaRepo *manga.Repo, hub *sync.Hub) *Service {
+	return &Service{Repo: repo, Manga: mangaRepo, Hub: hub}
+}
+
+type ListResult struct {
+	Total  int
+	Limit  int
+	Offset int
+	Items  []models.LibraryItem
+}
+
+// List pages through a user's library, optionally filtered by status.
+func (s *Service) List(ctx context.Context, userID, status string, limit, offset int) (ListResult, error) {
+	if strings.TrimSpace(status) != "" {
+		status = NormalizeStatus(status)
+		if status == "" {
+			return ListResult{}, apperr.Invalid("invalid status filter")
+		}
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	items, total, err := s.Repo.List(ctx, userID, status, limit, offset)
+	if err != nil {
+		return ListResult{}, apperr.Internal("list failed", err)
+	}
+	return ListResult{Total: total, Limit: limit, Offset: offset, Items: items}, nil
+}
+
+func (s *Service) Get(ctx context.Context, userID, mangaID string) (*models.LibraryItem, error) {
+	mangaID = strings.TrimSpace(mangaID)
+	if mangaID == "" {
+		return nil, apperr.Invalid("manga_id required")
+	}
+
+	it, err := s.Repo.Get(ctx, userID, mangaID)
+	if err != nil {
+		return nil, apperr.Internal("get failed", err)
+	}
+	if it == nil {
+		return nil, apperr.NotFound("not found")
+	}
+	return it, nil
+}
+
+// Upsert validates and saves a library entry, then publishes library.update.
+func (s *Service) Upsert(ctx context.Context, userID, mangaID string, chapter int, status string) (*models.LibraryItem, error) {
+	mangaID = strings.TrimSpace(mangaID)
+	if mangaID == "" {
+		return nil, apperr.Invalid("manga_id required")
+	}
+
+	status = NormalizeStatus(status)
+	if status == "" {
+		return nil, apperr.Invalid("status must be one of: reading, completed, wish_list, blacklist")
+	}
+	if chapter < 0 {
+		return nil, apperr.Invalid("current_chapter must be >= 0")
+	}
+	if status == "blacklist" {
+		chapter = 0
+	}
+
+	if s.Manga != nil {
+		m, err := s.Manga.GetByID(ctx, mangaID)
+		if err != nil {
+			return nil, apperr.Internal("save failed", err)
+		}
+		if m == nil {
+			return nil, apperr.NotFound("manga not found")
+		}
+	}
+
+	item := models.LibraryItem{
+		UserID:         userID,
+		MangaID:        mangaID,
+		CurrentChapter: chapter,
+		Status:         status,
+	}
+	if err := s.Repo.Upsert(ctx, item); err != nil {
+		return nil, apperr.Internal("save failed", err)
+	}
+
+	// Return canonical stored row including updated_at
+	saved, err := s.Repo.Get(ctx, userID, mangaID)
+	if err != nil {
+		return nil, apperr.Internal("fetch saved failed", err)
+	}
+	if saved == nil {
+		// should not happen, but safe
+		item.UpdatedAt = time.Now().UTC()
+		saved = &item
+	}
+
+	s.publish(sync.LibraryEvent{
+		Type:           "library.update",
+		UserID:         userID,
+		MangaID:        mangaID,
+		CurrentChapter: saved.CurrentChapter,
+		Status:         saved.Status,
+		At:             time.Now().UTC(),
+	})
+	return saved, nil
+}
+
+// Delete removes a library entry and publishes library.delete.
+func (s *Service) Delete(ctx context.Context, userID, mangaID string) error {
+	mangaID = strings.TrimSpace(mangaID)
+	if mangaID == "" {
+		return apperr.Invalid("manga_id required")
+	}
+
+	ok, err := s.Repo.Delete(ctx, userID, mangaID)
+	if err != nil {
+		return apperr.Internal("delete failed", err)
+	}
+	if !ok {
+		return apperr.NotFound("not found")
+	}
+
+	s.publish(sync.LibraryEvent{
+		Type:    "library.delete",
+		UserID:  userID,
+		MangaID: mangaID,
+		At:      time.Now().UTC(),
+	})
+	return nil
+}
+
+func (s *Service) publish(ev sync.LibraryEvent) {
+	if s.Hub != nil {
+		s.Hub.Publish(ev)
+	}
+}
+
+// NormalizeStatus maps accepted spellings to the stored status, or "" when
+// the value is not a known status.
+func NormalizeStatus(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "reading":
+		return "reading"
+	case "completed":
+		return "completed"
+	case "wish list", "wish_list", "wishlist":
+		return "wish_list"
+	case "blacklist", "black_list", "black list":
+		return "blacklist"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,440 @@
+package manga
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"mangahub/internal/apperr"
+	"mangahub/pkg/models"
+)
+
+// Editable fields, named as in the manga JSON and in manga_locks.
+var editableFields = map[string]string{ // field -> column
+	"title":          "title",
+	"author":         "author",
+	"genres":         "genres",
+	"status":         "status",
+	"total_chapters": "total_chapters",
+	"description":    "description",
+	"cover_url":      "cover_url",
+}
+
+var validStatuses = map[string]bool{"ongoing": true, "completed": true, "hiatus": true, "cancelled": true}
+
+// EditRequest changes some fields of a title. Fields left nil are not
+// touched. Changed fields are locked against the scraper and importers
+// unless Lock is false.
+type EditRequest struct {
+	Title         *string   `json:"title"`
+	Author        *string   `json:"author"`
+	Genres        *[]string `json:"genres"`
+	Status        *string   `json:"status"`
+	TotalChapters *int      `json:"total_chapters"`
+	Description   *string   `json:"description"`
+	CoverURL      *string   `json:"cover_url"`
+	Lock          *bool     `json:"lock"` // default true
+	Note          string    `json:"note"`
+}
+
+// Edit is one entry in a title's audit trail. OldValue and NewValue are
+// JSON. A revert is itself an edit, pointing at the one it undid.
+type Edit struct {
+	ID         int64           `json:"id"`
+	MangaID    string          `json:"manga_id"`
+	Field      string          `json:"field"`
+	OldValue   json.RawMessage `json:"old_value"`
+	NewValue   json.RawMessage `json:"new_value"`
+	WasLocked  bool            `json:"was_locked"`
+	Locked     bool            `json:"locked"`
+	Note       string          `json:"note,omitempty"`
+	EditedBy   string          `json:"edited_by,omitempty"`
+	EditedAt   time.Time       `json:"edited_at"`
+	RevertOf   *int64          `json:"revert_of,omitempty"`
+	RevertedBy *int64          `json:"reverted_by,omitempty"` // the edit that undid this one
+}
+
+// changes lists the fields req sets, with their new values.
+func (req EditRequest) changes() (map[string]any, error) {
+	out := make(map[string]any)
+	if req.Title != nil {
+		t := strings.TrimSpace(*req.Title)
+		if t == "" {
+			return nil, apperr.Invalid("title cannot be empty")
+		}
+		out["title"] = t
+	}
+	if req.Author != nil {
+		out["author"] = strings.TrimSpace(*req.Author)
+	}
+	if req.Genres != nil {
+		genres := make([]string, 0, len(*req.Genres))
+		for _, g := range *req.Genres {
+			if g = strings.TrimSpace(g); g != "" {
+				genres = append(genres, g)
+			}
+		}
+		out["genres"] = genres
+	}
+	if req.Status != nil {
+		s := strings.ToLower(strings.TrimSpace(*req.Status))
+		if s != "" && !validStatuses[s] {
+			return nil, apperr.Invalid("status must be ongoing, completed, hiatus or cancelled")
+		}
+		out["status"] = s
+	}
+	if req.TotalChapters != nil {
+		if *req.TotalChapters < 0 {
+			return nil, apperr.Invalid("total_chapters cannot be negative")
+		}
+		out["total_chapters"] = *req.TotalChapters
+	}
+	if req.Description != nil {
+		out["description"] = strings.TrimSpace(*req.Description)
+	}
+	if req.CoverURL != nil {
+		out["cover_url"] = strings.TrimSpace(*req.CoverURL)
+	}
+	if len(out) == 0 {
+		return nil, apperr.Invalid("no fields to change")
+	}
+	return out, nil
+}
+
+// fieldValues are the current values of m's editable fields.
+func fieldValues(m *models.MangaDB) map[string]any {
+	genres := m.Genres
+	if genres == nil {
+		genres = []string{}
+	}
+	return map[string]any{
+		"title":          m.Title,
+		"author":         m.Author,
+		"genres":         genres,
+		"status":         m.Status,
+		"total_chapters": m.TotalChapters,
+		"description":    m.Description,
+		"cover_url":      m.CoverURL,
+	}
+}
+
+// columnValue is v as stored in the manga table.
+func columnValue(field string, v any) (any, error) {
+	if field == "genres" {
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+	return v, nil
+}
+
+// Edit applies req to title id on behalf of actorID and records each
+// change in the audit trail.
+func (s *Service) Edit(ctx context.Context, actorID, id string, req EditRequest) (*models.MangaDB, []Edit, error) {
+	changes, err := req.changes()
+	if err != nil {
+		return nil, nil, err
+	}
+	lock := req.Lock == nil || *req.Lock
+
+	tx, err := s.Repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, apperr.Internal("edit failed", err)
+	}
+	defer tx.Rollback()
+
+	m, err := getByID(ctx, tx, id)
+	if err != nil {
+		return nil, nil, apperr.Internal("edit failed", err)
+	}
+	if m == nil {
+		return nil, nil, apperr.NotFound("not found")
+	}
+	locks, err := lockedFields(ctx, tx, id)
+	if err != nil {
+		return nil, nil, apperr.Internal("edit failed", err)
+	}
+
+	current := fieldValues(m)
+	fields := make([]string, 0, len(changes))
+	for f := range changes {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	now := time.Now().UTC()
+	var edits []Edit
+	for _, f := range fields {
+		oldJSON, _ := json.Marshal(current[f])
+		newJSON, _ := json.Marshal(changes[f])
+		if string(oldJSON) == string(newJSON) && locks[f] == lock {
+			continue
+		}
+		e := Edit{MangaID: id, Field: f, OldValue: oldJSON, NewValue: newJSON,
+			WasLocked: locks[f], Locked: lock, Note: req.Note, EditedBy: actorID, EditedAt: now}
+		if err := applyEdit(ctx, tx, &e, changes[f]); err != nil {
+			return nil, nil, apperr.Internal("edit failed", err)
+		}
+		edits = append(edits, e)
+	}
+
+	if m, err = getByID(ctx, tx, id); err != nil {
+		return nil, nil, apperr.Internal("edit failed", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, apperr.Internal("edit failed", err)
+	}
+	return m, edits, nil
+}
+
+// Revert undoes edit editID of title id: the field gets its old value and
+// lock state back. Only the latest edit of a field can be reverted.
+func (s *Service) Revert(ctx context.Context, actorID, id string, editID int64) (*Edit, error) {
+	tx, err := s.Repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, apperr.Internal("revert failed", err)
+	}
+	defer tx.Rollback()
+
+	orig, err := scanEdit(tx.QueryRowContext(ctx, `SELECT `+editColumns+` FROM manga_edits WHERE id = ? AND manga_id = ?`, editID, id))
+	if err == sql.ErrNoRows {
+		return nil, apperr.NotFound("edit not found")
+	}
+	if err != nil {
+		return nil, apperr.Internal("revert failed", err)
+	}
+	if orig.RevertedBy != nil {
+		return nil, apperr.Conflict("edit already reverted")
+	}
+	var later int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM manga_edits WHERE manga_id = ? AND field = ? AND id > ?
+	`, id, orig.Field, editID).Scan(&later); err != nil {
+		return nil, apperr.Internal("revert failed", err)
+	}
+	if later > 0 {
+		return nil, apperr.Conflict("field was edited again since; revert the later edit first")
+	}
+
+	m, err := getByID(ctx, tx, id)
+	if err != nil {
+		return nil, apperr.Internal("revert failed", err)
+	}
+	if m == nil {
+		return nil, apperr.NotFound("not found")
+	}
+	currentJSON, _ := json.Marshal(fieldValues(m)[orig.Field])
+
+	// decode the old value into the field's Go type so it is stored as it was
+	var restored any
+	switch orig.Field {
+	case "genres":
+		var g []string
+		err = json.Unmarshal(orig.OldValue, &g)
+		restored = g
+	case "total_chapters":
+		var n int
+		err = json.Unmarshal(orig.OldValue, &n)
+		restored = n
+	default:
+		var str string
+		err = json.Unmarshal(orig.OldValue, &str)
+		restored = str
+	}
+	if err != nil {
+		return nil, apperr.Internal("revert failed", fmt.Errorf("decode edit %d: %w", editID, err))
+	}
+
+	e := Edit{MangaID: id, Field: orig.Field, OldValue: currentJSON, NewValue: orig.OldValue,
+		WasLocked: orig.Locked, Locked: orig.WasLocked, EditedBy: actorID, EditedAt: time.Now().UTC(),
+		Note: fmt.Sprintf("revert of edit %d", editID), RevertOf: &editID}
+	if err := applyEdit(ctx, tx, &e, restored); err != nil {
+		return nil, apperr.Internal("revert failed", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE manga_edits SET reverted_by = ? WHERE id = ?`, e.ID, editID); err != nil {
+		return nil, apperr.Internal("revert failed", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, apperr.Internal("revert failed", err)
+	}
+	return &e, nil
+}
+
+// Unlock hands field of title id back to the scraper and importers,
+// keeping its current value until they next write it.
+func (s *Service) Unlock(ctx context.Context, actorID, id, field string) (*Edit, error) {
+	if _, ok := editableFields[field]; !ok {
+		return nil, apperr.Invalid("unknown field")
+	}
+	tx, err := s.Repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, apperr.Internal("unlock failed", err)
+	}
+	defer tx.Rollback()
+
+	m, err := getByID(ctx, tx, id)
+	if err != nil {
+		return nil, apperr.Internal("unlock failed", err)
+	}
+	if m == nil {
+		return nil, apperr.NotFound("not found")
+	}
+	locks, err := lockedFields(ctx, tx, id)
+	if err != nil {
+		return nil, apperr.Internal("unlock failed", err)
+	}
+	if !locks[field] {
+		return nil, apperr.Conflict("field is not locked")
+	}
+
+	v := fieldValues(m)[field]
+	cur, _ := json.Marshal(v)
+	e := Edit{MangaID: id, Field: field, OldValue: cur, NewValue: cur, WasLocked: true,
+		EditedBy: actorID, EditedAt: time.Now().UTC(), Note: "unlock"}
+	if err := applyEdit(ctx, tx, &e, v); err != nil {
+		return nil, apperr.Internal("unlock failed", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, apperr.Internal("unlock failed", err)
+	}
+	return &e, nil
+}
+
+// History returns the locked fields of title id and its edits, newest
+// first.
+func (s *Service) History(ctx context.Context, id string) ([]string, []Edit, error) {
+	m, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, apperr.Internal("history failed", err)
+	}
+	if m == nil {
+		return nil, nil, apperr.NotFound("not found")
+	}
+	locks, err := lockedFields(ctx, s.Repo.DB, id)
+	if err != nil {
+		return nil, nil, apperr.Internal("history failed", err)
+	}
+	fields := make([]string, 0, len(locks))
+	for f := range locks {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	rows, err := s.Repo.DB.QueryContext(ctx, `SELECT `+editColumns+` FROM manga_edits WHERE manga_id = ? ORDER BY id DESC`, id)
+	if err != nil {
+		return nil, nil, apperr.Internal("history failed", err)
+	}
+	defer rows.Close()
+	edits := make([]Edit, 0)
+	for rows.Next() {
+		e, err := scanEdit(rows)
+		if err != nil {
+			return nil, nil, apperr.Internal("history failed", err)
+		}
+		edits = append(edits, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, apperr.Internal("history failed", err)
+	}
+	return fields, edits, nil
+}
+
+// applyEdit writes e's field value and lock state, marks the field as set
+// by hand (or forgets its source once unlocked), and records e.
+func applyEdit(ctx context.Context, tx *sql.Tx, e *Edit, value any) error {
+	col, err := columnValue(e.Field, value)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", e.Field, err)
+	}
+	// the column name comes from editableFields, never from the request
+	if _, err := tx.ExecContext(ctx, `UPDATE manga SET `+editableFields[e.Field]+` = ? WHERE id = ?`, col, e.MangaID); err != nil {
+		return fmt.Errorf("update %s: %w", e.Field, err)
+	}
+
+	if e.Locked {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO manga_locks (manga_id, field, locked_by, locked_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(manga_id, field) DO UPDATE SET locked_by = excluded.locked_by, locked_at = excluded.locked_at
+		`, e.MangaID, e.Field, e.EditedBy, e.EditedAt)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO manga_provenance (manga_id, field, source, value, updated_at) VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT(manga_id, field) DO UPDATE SET
+				  source = excluded.source, value = excluded.value, updated_at = excluded.updated_at
+			`, e.MangaID, e.Field, models.ManualSource, string(e.NewValue), e.EditedAt)
+		}
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM manga_locks WHERE manga_id = ? AND field = ?`, e.MangaID, e.Field)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `DELETE FROM manga_provenance WHERE manga_id = ? AND field = ?`, e.MangaID, e.Field)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("lock %s: %w", e.Field, err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO manga_edits (manga_id, field, old_value, new_value, was_locked, locked, note, edited_by, edited_at, revert_of)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.MangaID, e.Field, string(e.OldValue), string(e.NewValue), e.WasLocked, e.Locked, e.Note, e.EditedBy, e.EditedAt, e.RevertOf)
+	if err != nil {
+		return fmt.Errorf("record edit: %w", err)
+	}
+	e.ID, _ = res.LastInsertId()
+	return nil
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func lockedFields(ctx context.Context, q querier, id string) (map[string]bool, error) {
+	rows, err := q.QueryContext(ctx, `SELECT field FROM manga_locks WHERE manga_id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("locks query: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]bool)
+	for rows.Next() {
+		var f string
+		if err := rows.Scan(&f); err != nil {
+			return nil, fmt.Errorf("locks scan: %w", err)
+		}
+		out[f] = true
+	}
+	return out, rows.Err()
+}
+
+const editColumns = `id, manga_id, field, old_value, new_value, was_locked, locked, COALESCE(note, ''),
+	COALESCE(edited_by, ''), edited_at, revert_of, reverted_by`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEdit(s scanner) (*Edit, error) {
+	var (
+		e             Edit
+		oldV, newV    string
+		revertOf, rby sql.NullInt64
+	)
+	if err := s.Scan(&e.ID, &e.MangaID, &e.Field, &oldV, &newV, &e.WasLocked, &e.Locked, &e.Note,
+		&e.EditedBy, &e.EditedAt, &revertOf, &rby); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan edit: %w", err)
+	}
+	e.OldValue, e.NewValue = json.RawMessage(oldV), json.RawMessage(newV)
+	if revertOf.Valid {
+		e.RevertOf = &revertOf.Int64
+	}
+	if rby.Valid {
+		e.RevertedBy = &rby.Int64
+	}
+	return &e, nil
+}
@@ -6,19 +6,31 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"mangahub/internal/apperr"
+	"mangahub/internal/auth"
 )
 
 type Handler struct {
-	Repo *Repo
+	Service *Service
 }
 
-func NewHandler(repo *Repo) *Handler {
-	return &Handler{Repo: repo}
+func NewHandler(svc *Service) *Handler {
+	return &Handler{Service: svc}
 }
 
 func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET("", h.list)        // GET /manga
-	rg.GET("/:id", h.getByID) // GET /manga/:id
+	rg.GET("/:id", h.getByID) // GET /manga/:id?provenance=true
+}
+
+// RegisterAdminRoutes expects a group behind AuthMiddleware and
+// RequireRole(RoleAdmin).
+func (h *Handler) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.PATCH("/manga/:id", h.edit)                     // PATCH /admin/manga/:id
+	rg.GET("/manga/:id/edits", h.history)              // GET /admin/manga/:id/edits
+	rg.POST("/manga/:id/edits/:edit/revert", h.revert) // POST /admin/manga/:id/edits/:edit/revert
+	rg.DELETE("/manga/:id/locks/:field", h.unlock)     // DELETE /admin/manga/:id/locks/:field
 }
 
 func (h *Handler) list(c *gin.Context) {
@@ -28,6 +40,8 @@ func (h *Handler) list(c *gin.Context) {
 		Limit:  parseInt(c.Query("limit"), 20),
 		Offset: parseInt(c.Query("offset"), 0),
 	}
+	// ?include_delisted=true also lists titles no source returns any more
+	q.IncludeDelisted, _ = strconv.ParseBool(c.Query("include_delisted"))
 
 	// genres=Action,Drama OR genres=Action&genres=Drama
 	genres := c.QueryArray("genres")
@@ -38,38 +52,99 @@ func (h *Handler) list(c *gin.Context) {
 	}
 	q.Genres = genres
 
-	total, err := h.Repo.Count(c.Request.Context(), q)
+	res, err := h.Service.List(c.Request.Context(), q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "count failed"})
+		apperr.JSON(c, err)
 		return
 	}
 
-	items, err := h.Repo.List(c.Request.Context(), q)
+	c.JSON(http.StatusOK, gin.H{
+		"total":  res.Total,
+		"limit":  res.Limit,
+		"offset": res.Offset,
+		"items":  res.Items,
+	})
+}
+
+func (h *Handler) getByID(c *gin.Context) {
+	get := h.Service.Get
+	// ?provenance=true adds the source of each field
+	if ok, _ := strconv.ParseBool(c.Query("provenance")); ok {
+		get = h.Service.GetWithProvenance
+	}
+	m, err := get(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "list failed"})
+		apperr.JSON(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, m)
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"total":  total,
-		"limit":  q.Limit,
-		"offset": q.Offset,
-		"items":  items,
-	})
+func (h *Handler) edit(c *gin.Context) {
+	claims := auth.MustGetClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req EditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+
+	m, edits, err := h.Service.Edit(c.Request.Context(), claims.UserID, c.Param("id"), req)
+	if err != nil {
+		apperr.JSON(c, err)
+		return
+	}
+	if edits == nil {
+		edits = []Edit{}
+	}
+	c.JSON(http.StatusOK, gin.H{"manga": m, "edits": edits})
 }
 
-func (h *Handler) getByID(c *gin.Context) {
-	id := c.Param("id")
-	m, err := h.Repo.GetByID(c.Request.Context(), id)
+func (h *Handler) history(c *gin.Context) {
+	locks, edits, err := h.Service.History(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "get failed"})
+		apperr.JSON(c, err)
 		return
 	}
-	if m == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	c.JSON(http.StatusOK, gin.H{"locked": locks, "items": edits})
+}
+
+func (h *Handler) revert(c *gin.Context) {
+	claims := auth.MustGetClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
-	c.JSON(http.StatusOK, m)
+	editID, err := strconv.ParseInt(c.Param("edit"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid edit id"})
+		return
+	}
+
+	e, err := h.Service.Revert(c.Request.Context(), claims.UserID, c.Param("id"), editID)
+	if err != nil {
+		apperr.JSON(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+func (h *Handler) unlock(c *gin.Context) {
+	claims := auth.MustGetClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	e, err := h.Service.Unlock(c.Request.Context(), claims.UserID, c.Param("id"), c.Param("field"))
+	if err != nil {
+		apperr.JSON(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, e)
 }
 
 func parseInt(s string, def int) int {
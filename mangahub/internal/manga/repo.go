@@ -18,8 +18,10 @@ type ListQuery struct {
 	Q      string   // keyword search in title/author
 	Genres []string // any-match
 	Status string
-	Limit  int
-	Offset int
+	// IncludeDelisted lists titles no source returns any more, too.
+	IncludeDelisted bool
+	Limit           int
+	Offset          int
 }
 
 func NewRepo(db *sql.DB) *Repo {
@@ -27,8 +29,13 @@ func NewRepo(db *sql.DB) *Repo {
 }
 
 func (r *Repo) GetByID(ctx context.Context, id string) (*models.MangaDB, error) {
-	row := r.DB.QueryRowContext(ctx, `
-		SELECT id, title, author, genres, status, total_chapters, description, cover_url
+	return getByID(ctx, r.DB, id)
+}
+
+func getByID(ctx context.Context, q querier, id string) (*models.MangaDB, error) {
+	row := q.QueryRowContext(ctx, `
+		SELECT id, title, author, genres, status, total_chapters, description, cover_url,
+		       (SELECT hash FROM covers WHERE url = manga.cover_url) AS cover_hash, delisted_at
 		FROM manga
 		WHERE id = ?
 	`, id)
@@ -41,10 +48,12 @@ func (r *Repo) GetByID(ctx context.Context, id string) (*models.MangaDB, error)
 		chapters    sql.NullInt64
 		description sql.NullString
 		coverURL    sql.NullString
+		coverHash   sql.NullString
+		delistedAt  sql.NullTime
 	)
 
 	if err := row.Scan(
-		&m.ID, &m.Title, &author, &genresJSON, &status, &chapters, &description, &coverURL,
+		&m.ID, &m.Title, &author, &genresJSON, &status, &chapters, &description, &coverURL, &coverHash, &delistedAt,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -59,11 +68,51 @@ func (r *Repo) GetByID(ctx context.Context, id string) (*models.MangaDB, error)
 	}
 	m.Description = description.String
 	m.CoverURL = coverURL.String
+	m.Cover = coverPath(coverHash)
+	if delistedAt.Valid {
+		m.DelistedAt = &delistedAt.Time
+	}
 
 	_ = json.Unmarshal([]byte(genresJSON), &m.Genres)
 	return &m, nil
 }
 
+// coverPath is where the api-server serves a mirrored cover, or "" when
+// the cover has not been mirrored.
+func coverPath(hash sql.NullString) string {
+	if !hash.Valid || hash.String == "" {
+		return ""
+	}
+	return "/covers/" + hash.String
+}
+
+// Provenance returns the recorded source of each field of manga id.
+func (r *Repo) Provenance(ctx context.Context, id string) (map[string]models.FieldSource, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT field, source, updated_at FROM manga_provenance WHERE manga_id = ?
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("provenance query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]models.FieldSource)
+	for rows.Next() {
+		var (
+			field string
+			fs    models.FieldSource
+		)
+		if err := rows.Scan(&field, &fs.Source, &fs.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("provenance scan: %w", err)
+		}
+		out[field] = fs
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows err: %w", err)
+	}
+	return out, nil
+}
+
 func (r *Repo) Count(ctx context.Context, q ListQuery) (int, error) {
 	sqlStr, args := buildListSQL(q, true)
 	row := r.DB.QueryRowContext(ctx, sqlStr, args...)
@@ -93,10 +142,12 @@ func (r *Repo) List(ctx context.Context, q ListQuery) ([]models.MangaDB, error)
 			chapters    sql.NullInt64
 			description sql.NullString
 			coverURL    sql.NullString
+			coverHash   sql.NullString
+			delistedAt  sql.NullTime
 		)
 
 		if err := rows.Scan(
-			&m.ID, &m.Title, &author, &genresJSON, &status, &chapters, &description, &coverURL,
+			&m.ID, &m.Title, &author, &genresJSON, &status, &chapters, &description, &coverURL, &coverHash, &delistedAt,
 		); err != nil {
 			return nil, fmt.Errorf("list scan: %w", err)
 		}
@@ -108,6 +159,10 @@ func (r *Repo) List(ctx context.Context, q ListQuery) ([]models.MangaDB, error)
 		}
 		m.Description = description.String
 		m.CoverURL = coverURL.String
+		m.Cover = coverPath(coverHash)
+		if delistedAt.Valid {
+			m.DelistedAt = &delistedAt.Time
+		}
 
 		_ = json.Unmarshal([]byte(genresJSON), &m.Genres)
 		out = append(out, m)
@@ -122,7 +177,8 @@ func (r *Repo) List(ctx context.Context, q ListQuery) ([]models.MangaDB, error)
 // genres filter is "any-match" by doing LIKE searches inside stored JSON text.
 func buildListSQL(q ListQuery, countOnly bool) (string, []any) {
 	baseSelect := `
-		SELECT id, title, author, genres, status, total_chapters, description, cover_url
+		SELECT id, title, author, genres, status, total_chapters, description, cover_url,
+		       (SELECT hash FROM covers WHERE url = manga.cover_url) AS cover_hash, delisted_at
 		FROM manga
 	`
 	if countOnly {
@@ -132,6 +188,10 @@ func buildListSQL(q ListQuery, countOnly bool) (string, []any) {
 	var where []string
 	var args []any
 
+	if !q.IncludeDelisted {
+		where = append(where, "delisted_at IS NULL")
+	}
+
 	if strings.TrimSpace(q.Q) != "" {
 		where = append(where, "(LOWER(title) LIKE ? OR LOWER(author) LIKE ?)")
 		kw := "%" + strings.ToLower(strings.TrimSpace(q.Q)) + "%"
@@ -0,0 +1,76 @@
+package manga
+
+import (
+	"context"
+	"strings"
+
+	"mangahub/internal/apperr"
+	"mangahub/pkg/models"
+)
+
+// Service is the catalog API shared by the HTTP handler and gRPC.
+type Service struct {
+	Repo *Repo
+}
+
+func NewService(repo *Repo) *Service {
+	return &Service{Repo: repo}
+}
+
+type ListResult struct {
+	Total  int
+	Limit  int
+	Offset int
+	Items  []models.MangaDB
+}
+
+// List searches the catalog. Limit and offset are clamped the same way for
+// every caller (1-100, default 20).
+func (s *Service) List(ctx context.Context, q ListQuery) (ListResult, error) {
+	q.Q = strings.TrimSpace(q.Q)
+	q.Status = strings.TrimSpace(q.Status)
+	if q.Limit <= 0 || q.Limit > 100 {
+		q.Limit = 20
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+
+	total, err := s.Repo.Count(ctx, q)
+	if err != nil {
+		return ListResult{}, apperr.Internal("count failed", err)
+	}
+	items, err := s.Repo.List(ctx, q)
+	if err != nil {
+		return ListResult{}, apperr.Internal("list failed", err)
+	}
+	return ListResult{Total: total, Limit: q.Limit, Offset: q.Offset, Items: items}, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*models.MangaDB, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, apperr.Invalid("id required")
+	}
+
+	m, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperr.Internal("get failed", err)
+	}
+	if m == nil {
+		return nil, apperr.NotFound("not found")
+	}
+	return m, nil
+}
+
+// GetWithProvenance is Get plus where each field's value came from.
+func (s *Service) GetWithProvenance(ctx context.Context, id string) (*models.MangaDB, error) {
+	m, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if m.Provenance, err = s.Repo.Provenance(ctx, m.ID); err != nil {
+		return nil, apperr.Internal("get failed", err)
+	}
+	return m, nil
+}
@@ -67,7 +67,9 @@ type Server struct {
 	addr     string
 	registry *Registry
 	logger   *log.Logger
-	conn     *net.UDPConn
+
+	mu   sync.Mutex // guards conn, set by Run and used by Close and broadcasts
+	conn *net.UDPConn
 }
 
 func NewServer(addr string, registry *Registry, logger *log.Logger) *Server {
@@ -86,8 +88,15 @@ func (s *Server) Run() error {
 	if err != nil {
 		return err
 	}
+	s.mu.Lock()
 	s.conn = conn
-	defer conn.Close()
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+		conn.Close()
+	}()
 
 	s.logger.Printf("UDP notify server listening on %s", s.addr)
 
@@ -95,6 +104,9 @@ func (s *Server) Run() error {
 	for {
 		n, addr, err := conn.ReadFromUDP(buffer)
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
 			return err
 		}
 		msg, err := parseRegisterMessage(buffer[:n])
@@ -110,8 +122,24 @@ func (s *Server) Run() error {
 	}
 }
 
+func (s *Server) Close() error {
+	conn := s.listener()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
+// listener returns the socket Run listens on, or nil when it is not running.
+func (s *Server) listener() *net.UDPConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn
+}
+
 func (s *Server) BroadcastNewChapter(mangaID string, chapter int) {
-	if s.conn == nil {
+	conn := s.listener()
+	if conn == nil {
 		s.logger.Printf("UDP notify server not running")
 		return
 	}
@@ -127,25 +155,25 @@ func (s *Server) BroadcastNewChapter(mangaID string, chapter int) {
 
 	clients := s.registry.Snapshot()
 	for _, client := range clients {
-		s.sendWithRetry(client, payload)
+		s.sendWithRetry(conn, client, payload)
 	}
 }
 
-func (s *Server) sendWithRetry(client Client, payload []byte) {
-	if err := s.sendOnce(client, payload); err == nil {
+func (s *Server) sendWithRetry(conn *net.UDPConn, client Client, payload []byte) {
+	if err := sendOnce(conn, client, payload); err == nil {
 		return
 	}
-	if err := s.sendOnce(client, payload); err != nil {
+	if err := sendOnce(conn, client, payload); err != nil {
 		s.logger.Printf("failed to notify user %s at %s: %v", client.UserID, client.Addr, err)
 		s.registry.Remove(client.UserID)
 	}
 }
 
-func (s *Server) sendOnce(client Client, payload []byte) error {
+func sendOnce(conn *net.UDPConn, client Client, payload []byte) error {
 	if client.Addr == nil {
 		return errors.New("missing client address")
 	}
-	_, err := s.conn.WriteToUDP(payload, client.Addr)
+	_, err := conn.WriteToUDP(payload, client.Addr)
 	return err
 }
 
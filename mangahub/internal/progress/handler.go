@@ -4,20 +4,20 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"mangahub/internal/apperr"
 	"mangahub/internal/auth"
 	"mangahub/pkg/models"
 )
 
 type Handler struct {
-	Repo *Repo
+	Service *Service
 }
 
-func NewHandler(repo *Repo) *Handler {
-	return &Handler{Repo: repo}
+func NewHandler(svc *Service) *Handler {
+	return &Handler{Service: svc}
 }
 
 func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
@@ -44,30 +44,14 @@ func (h *Handler) add(c *gin.Context) {
 		return
 	}
 
-	mangaID := strings.TrimSpace(req.MangaID)
-	if mangaID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "manga_id required"})
-		return
-	}
-	if req.Chapter < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "chapter must be >= 0"})
-		return
-	}
-	if req.Volume != nil && *req.Volume < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "volume must be >= 0"})
-		return
-	}
-
-	entry := models.ProgressHistory{
+	entry, err := h.Service.Add(c.Request.Context(), models.ProgressHistory{
 		UserID:  claims.UserID,
-		MangaID: mangaID,
+		MangaID: req.MangaID,
 		Chapter: req.Chapter,
 		Volume:  req.Volume,
-		At:      time.Now().UTC(),
-	}
-
-	if err := h.Repo.Add(c.Request.Context(), entry); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "save failed"})
+	})
+	if err != nil {
+		apperr.JSON(c, err)
 		return
 	}
 
@@ -81,26 +65,21 @@ func (h *Handler) list(c *gin.Context) {
 		return
 	}
 
-	mangaID := strings.TrimSpace(c.Query("manga_id"))
-	if mangaID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "manga_id required"})
-		return
-	}
-
-	limit := parseInt(c.Query("limit"), 50)
-	offset := parseInt(c.Query("offset"), 0)
-
-	items, total, err := h.Repo.List(c.Request.Context(), claims.UserID, mangaID, limit, offset)
+	res, err := h.Service.List(c.Request.Context(), claims.UserID,
+		c.Query("manga_id"),
+		parseInt(c.Query("limit"), 50),
+		parseInt(c.Query("offset"), 0),
+	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "list failed"})
+		apperr.JSON(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"total":  total,
-		"limit":  limit,
-		"offset": offset,
-		"items":  items,
+		"total":  res.Total,
+		"limit":  res.Limit,
+		"offset": res.Offset,
+		"items":  res.Items,
 	})
 }
 
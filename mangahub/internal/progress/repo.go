@@ -37,14 +37,9 @@ func (r *Repo) Add(ctx context.Context, entry models.ProgressHistory) error {
 	return nil
 }
 
+// List returns a page of history and the total; Service.List clamps the
+// page.
 func (r *Repo) List(ctx context.Context, userID, mangaID string, limit, offset int) ([]models.ProgressHistory, int, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 50
-	}
-	if offset < 0 {
-		offset = 0
-	}
-
 	var total int
 	if err := r.DB.QueryRowContext(ctx, `
 		SELECT COUNT(*) FROM user_progress_history
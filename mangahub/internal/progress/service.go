@@ -0,0 +1,68 @@
+package progress
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"mangahub/internal/apperr"
+	"mangahub/pkg/models"
+)
+
+// Service is the reading history API shared by the HTTP handler and gRPC.
+type Service struct {
+	Repo *Repo
+}
+
+func NewService(repo *Repo) *Service {
+	return &Service{Repo: repo}
+}
+
+type ListResult struct {
+	Total  int
+	Limit  int
+	Offset int
+	Items  []models.ProgressHistory
+}
+
+// List pages through a user's history for one manga, newest first. Limit
+// and offset are clamped the same way for every caller (1-100, default 50).
+func (s *Service) List(ctx context.Context, userID, mangaID string, limit, offset int) (ListResult, error) {
+	mangaID = strings.TrimSpace(mangaID)
+	if mangaID == "" {
+		return ListResult{}, apperr.Invalid("manga_id required")
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	items, total, err := s.Repo.List(ctx, userID, mangaID, limit, offset)
+	if err != nil {
+		return ListResult{}, apperr.Internal("list failed", err)
+	}
+	return ListResult{Total: total, Limit: limit, Offset: offset, Items: items}, nil
+}
+
+// Add records that entry.UserID read entry.Chapter now, and returns the
+// entry as stored.
+func (s *Service) Add(ctx context.Context, entry models.ProgressHistory) (models.ProgressHistory, error) {
+	entry.MangaID = strings.TrimSpace(entry.MangaID)
+	if entry.MangaID == "" {
+		return entry, apperr.Invalid("manga_id required")
+	}
+	if entry.Chapter < 0 {
+		return entry, apperr.Invalid("chapter must be >= 0")
+	}
+	if entry.Volume != nil && *entry.Volume < 0 {
+		return entry, apperr.Invalid("volume must be >= 0")
+	}
+	entry.At = time.Now().UTC()
+
+	if err := s.Repo.Add(ctx, entry); err != nil {
+		return entry, apperr.Internal("save failed", err)
+	}
+	return entry, nil
+}
@@ -7,15 +7,16 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"mangahub/internal/apperr"
 	"mangahub/internal/auth"
 )
 
 type Handler struct {
-	Repo *Repo
+	Service *Service
 }
 
-func NewHandler(repo *Repo) *Handler {
-	return &Handler{Repo: repo}
+func NewHandler(svc *Service) *Handler {
+	return &Handler{Service: svc}
 }
 
 func (h *Handler) RegisterPublicRoutes(rg *gin.RouterGroup) {
@@ -46,20 +47,9 @@ func (h *Handler) create(c *gin.Context) {
 		return
 	}
 
-	mangaID := strings.TrimSpace(req.MangaID)
-	if mangaID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "manga_id required"})
-		return
-	}
-
-	if req.Rating < 1 || req.Rating > 5 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "rating must be between 1 and 5"})
-		return
-	}
-
-	review, err := h.Repo.Create(c.Request.Context(), claims.UserID, mangaID, req.Rating, strings.TrimSpace(req.Text))
+	review, err := h.Service.Create(c.Request.Context(), claims.UserID, req.MangaID, req.Rating, req.Text)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "create failed"})
+		apperr.JSON(c, err)
 		return
 	}
 
@@ -73,19 +63,19 @@ func (h *Handler) listByManga(c *gin.Context) {
 		return
 	}
 
-	limit := parseInt(c.Query("limit"), 20)
-	offset := parseInt(c.Query("offset"), 0)
-
-	reviews, err := h.Repo.ListByManga(c.Request.Context(), mangaID, limit, offset)
+	res, err := h.Service.List(c.Request.Context(), mangaID,
+		parseInt(c.Query("limit"), 20),
+		parseInt(c.Query("offset"), 0),
+	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "list failed"})
+		apperr.JSON(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"limit":  limit,
-		"offset": offset,
-		"items":  reviews,
+		"limit":  res.Limit,
+		"offset": res.Offset,
+		"items":  res.Items,
 	})
 }
 
@@ -103,18 +93,13 @@ func (h *Handler) delete(c *gin.Context) {
 	}
 
 	id, err := strconv.ParseInt(idRaw, 10, 64)
-	if err != nil || id <= 0 {
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
 
-	ok, err := h.Repo.Delete(c.Request.Context(), id, claims.UserID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
-		return
-	}
-	if !ok {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	if err := h.Service.Delete(c.Request.Context(), id, claims.UserID); err != nil {
+		apperr.JSON(c, err)
 		return
 	}
 
@@ -56,14 +56,8 @@ func (r *Repo) GetByID(ctx context.Context, id int64) (*models.Review, error) {
 	return &review, nil
 }
 
+// ListByManga returns a page of reviews; Service.List clamps the page.
 func (r *Repo) ListByManga(ctx context.Context, mangaID string, limit, offset int) ([]models.Review, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 20
-	}
-	if offset < 0 {
-		offset = 0
-	}
-
 	rows, err := r.DB.QueryContext(ctx, `
 		SELECT id, user_id, manga_id, rating, text, timestamp
 		FROM reviews
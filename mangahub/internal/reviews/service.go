@@ -0,0 +1,77 @@
+package reviews
+
+import (
+	"context"
+	"strings"
+
+	"mangahub/internal/apperr"
+	"mangahub/pkg/models"
+)
+
+// Service is the reviews API shared by the HTTP handler and gRPC.
+type Service struct {
+	Repo *Repo
+}
+
+func NewService(repo *Repo) *Service {
+	return &Service{Repo: repo}
+}
+
+type ListResult struct {
+	Limit  int
+	Offset int
+	Items  []models.Review
+}
+
+// List pages through a manga's reviews, newest first. Limit and offset are
+// clamped the same way for every caller (1-100, default 20).
+func (s *Service) List(ctx context.Context, mangaID string, limit, offset int) (ListResult, error) {
+	mangaID = strings.TrimSpace(mangaID)
+	if mangaID == "" {
+		return ListResult{}, apperr.Invalid("manga_id required")
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	items, err := s.Repo.ListByManga(ctx, mangaID, limit, offset)
+	if err != nil {
+		return ListResult{}, apperr.Internal("list failed", err)
+	}
+	return ListResult{Limit: limit, Offset: offset, Items: items}, nil
+}
+
+func (s *Service) Create(ctx context.Context, userID, mangaID string, rating int, text string) (*models.Review, error) {
+	mangaID = strings.TrimSpace(mangaID)
+	if mangaID == "" {
+		return nil, apperr.Invalid("manga_id required")
+	}
+	if rating < 1 || rating > 5 {
+		return nil, apperr.Invalid("rating must be between 1 and 5")
+	}
+
+	review, err := s.Repo.Create(ctx, userID, mangaID, rating, strings.TrimSpace(text))
+	if err != nil {
+		return nil, apperr.Internal("create failed", err)
+	}
+	return review, nil
+}
+
+// Delete removes one of userID's own reviews.
+func (s *Service) Delete(ctx context.Context, id int64, userID string) error {
+	if id <= 0 {
+		return apperr.Invalid("invalid id")
+	}
+
+	deleted, err := s.Repo.Delete(ctx, id, userID)
+	if err != nil {
+		return apperr.Internal("delete failed", err)
+	}
+	if !deleted {
+		return apperr.NotFound("not found")
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package scraper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config lists the sources a scrape runs, as read from a YAML or JSON file:
+//
+//	sources:
+//	  - name: mangadex
+//	    type: mangadex
+//	    priority: 10
+//	    limits: {max_items: 200, page_size: 50}
+//	  - name: mirror
+//	    type: mirror
+//	    base_url: ${MIRROR_BASE_URL:-http://localhost:9000}
+//
+// ${VAR} and ${VAR:-default} are replaced from the environment before the
+// file is parsed, so credentials can stay out of it.
+type Config struct {
+	Fetch FetchConfig `yaml:"fetch" json:"fetch"`
+	Match MatchConfig `yaml:"match" json:"match"`
+	Merge MergeConfig `yaml:"merge" json:"merge"`
+	// Covers controls mirroring cover images after each run.
+	Covers CoversConfig `yaml:"covers" json:"covers"`
+	// Delist says when titles no source returns any more are delisted.
+	Delist DelistConfig `yaml:"delist" json:"delist"`
+	// Schedule is how often cmd/worker fetches sources that set none.
+	Schedule Schedule       `yaml:"schedule" json:"schedule"`
+	Sources  []SourceConfig `yaml:"sources" json:"sources"`
+}
+
+// SourceConfig configures one source instance. Type picks the registered
+// implementation; Name identifies the instance in logs and SourceIDs and
+// defaults to Type, so one type can be listed twice under different names.
+type SourceConfig struct {
+	Name    string `yaml:"name" json:"name"`
+	Type    string `yaml:"type" json:"type"`
+	Enabled *bool  `yaml:"enabled" json:"enabled"` // default true
+	BaseURL string `yaml:"base_url" json:"base_url"`
+	// Priority orders the sources: higher goes first, so its values are the
+	// base other sources are merged into. Ties keep the file order.
+	Priority    int         `yaml:"priority" json:"priority"`
+	Limits      Limits      `yaml:"limits" json:"limits"`
+	Credentials Credentials `yaml:"credentials" json:"credentials"`
+	Schedule    Schedule    `yaml:"schedule" json:"schedule"`
+
+	// JSON configures sources of type "json".
+	JSON *JSONFeedConfig `yaml:"json" json:"json"`
+	// HTML configures sources of type "html".
+	HTML *HTMLSiteConfig `yaml:"html" json:"html"`
+
+	shared *fetchShared // set by Build
+}
+
+// Limits bound what one source may fetch. Zero values leave the source's
+// own defaults in place.
+type Limits struct {
+	MaxItems       int `yaml:"max_items" json:"max_items"`
+	PageSize       int `yaml:"page_size" json:"page_size"`
+	TimeoutSeconds int `yaml:"timeout_seconds" json:"timeout_seconds"` // per request
+	// DeadlineSeconds bounds the source's whole fetch; default 60.
+	DeadlineSeconds int `yaml:"deadline_seconds" json:"deadline_seconds"`
+	// MaxFailures is how many failed pages the source skips before it
+	// gives up; 0 stops at the first one.
+	MaxFailures int `yaml:"max_failures" json:"max_failures"`
+}
+
+// DefaultDeadline bounds a source's fetch when its config sets none.
+const DefaultDeadline = 60 * time.Second
+
+// Deadlines maps each source name to how long its fetch may take.
+func (c Config) Deadlines() map[string]time.Duration {
+	out := make(map[string]time.Duration, len(c.Sources))
+	for _, s := range c.Sources {
+		if s.Limits.DeadlineSeconds > 0 {
+			out[s.Name] = time.Duration(s.Limits.DeadlineSeconds) * time.Second
+		}
+	}
+	return out
+}
+
+// Schedule sets how often cmd/worker fetches a source: every EveryMinutes,
+// plus a random delay of up to JitterMinutes so sources spread out.
+type Schedule struct {
+	EveryMinutes  int `yaml:"every_minutes" json:"every_minutes"`
+	JitterMinutes int `yaml:"jitter_minutes" json:"jitter_minutes"`
+}
+
+// DefaultEvery is how often a source is fetched when no schedule is set.
+const DefaultEvery = 6 * time.Hour
+
+// Schedules maps each source name to its schedule, falling back to the
+// top-level one and then to DefaultEvery.
+func (c Config) Schedules() map[string]Schedule {
+	def := c.Schedule
+	if def.EveryMinutes <= 0 {
+		def.EveryMinutes = int(DefaultEvery / time.Minute)
+	}
+	out := make(map[string]Schedule, len(c.Sources))
+	for _, s := range c.Sources {
+		sched := s.Schedule
+		if sched.EveryMinutes <= 0 {
+			sched.EveryMinutes = def.EveryMinutes
+			if sched.JitterMinutes <= 0 {
+				sched.JitterMinutes = def.JitterMinutes
+			}
+		}
+		out[s.Name] = sched
+	}
+	return out
+}
+
+// Credentials are attached to every request a source makes: Token as a
+// bearer token (or as the raw value of Header when set), Username and
+// Password as basic auth.
+type Credentials struct {
+	Token    string `yaml:"token" json:"token"`
+	Header   string `yaml:"header" json:"header"`
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+}
+
+// IsEnabled reports whether the source should run.
+func (c SourceConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// HTTPClient returns a client with the source's timeout (def when unset)
+// that sends its credentials.
+func (c SourceConfig) HTTPClient(def time.Duration) *http.Client {
+	timeout := def
+	if c.Limits.TimeoutSeconds > 0 {
+		timeout = time.Duration(c.Limits.TimeoutSeconds) * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+	if c.Credentials != (Credentials{}) {
+		client.Transport = &authTransport{creds: c.Credentials, next: http.DefaultTransport}
+	}
+	return client
+}
+
+// Fetcher returns the source's fetcher: its own HTTPClient, sharing rate
+// limits and cache with the other sources Build creates.
+func (c SourceConfig) Fetcher(def time.Duration) *Fetcher {
+	shared := c.shared
+	if shared == nil {
+		shared = newFetchShared(FetchConfig{})
+	}
+	return &Fetcher{Client: c.HTTPClient(def), MaxFailures: c.Limits.MaxFailures, shared: shared}
+}
+
+type authTransport struct {
+	creds Credentials
+	next  http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if t.creds.Username != "" || t.creds.Password != "" {
+		req.SetBasicAuth(t.creds.Username, t.creds.Password)
+	}
+	if t.creds.Token != "" {
+		if t.creds.Header != "" {
+			req.Header.Set(t.creds.Header, t.creds.Token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+t.creds.Token)
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// DefaultConfig is what runs without a config file: live MangaDex capped at
+// 200 titles, then the mirror at MIRROR_BASE_URL. The mirror keeps its old
+// "source_b" name so existing SourceIDs still line up.
+func DefaultConfig() Config {
+	mirror := os.Getenv("MIRROR_BASE_URL")
+	if mirror == "" {
+		mirror = "http://localhost:9000"
+	}
+	return Config{Sources: []SourceConfig{
+		{Name: "mangadex", Type: "mangadex", Limits: Limits{MaxItems: 200}},
+		{Name: "source_b", Type: "mirror", BaseURL: mirror},
+	}}
+}
+
+// LoadConfig reads a YAML or JSON config file (JSON is valid YAML).
+// Unknown keys are rejected so a typo doesn't silently drop a setting.
+func LoadConfig(path string) (Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read scraper config: %w", err)
+	}
+	return ParseConfig(raw)
+}
+
+// ParseConfig parses and validates a config document.
+func ParseConfig(raw []byte) (Config, error) {
+	expanded := os.Expand(string(raw), expandEnv)
+
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(expanded)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("parse scraper config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// expandEnv resolves ${VAR} and ${VAR:-default}; $$ stays a literal $.
+func expandEnv(name string) string {
+	if name == "$" {
+		return "$"
+	}
+	def := ""
+	if i := strings.Index(name, ":-"); i >= 0 {
+		name, def = name[:i], name[i+2:]
+	}
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// Validate fills in default names and checks that every source has a
+// registered type and a unique name, that the merge rules exist and that
+// delist.min_items_ratio is a share.
+func (c *Config) Validate() error {
+	seen := make(map[string]bool, len(c.Sources))
+	for i := range c.Sources {
+		s := &c.Sources[i]
+		s.Type = strings.ToLower(strings.TrimSpace(s.Type))
+		s.Name = strings.TrimSpace(s.Name)
+		if s.Type == "" {
+			return fmt.Errorf("scraper config: source %d has no type", i+1)
+		}
+		if _, ok := lookup(s.Type); !ok {
+			return fmt.Errorf("scraper config: source %d: unknown type %q (registered: %s)", i+1, s.Type, strings.Join(Types(), ", "))
+		}
+		if s.Name == "" {
+			s.Name = s.Type
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("scraper config: duplicate source name %q", s.Name)
+		}
+		seen[s.Name] = true
+	}
+	if c.Delist.MinItemsRatio > 1 {
+		return fmt.Errorf("scraper config: delist.min_items_ratio must be at most 1, got %g", c.Delist.MinItemsRatio)
+	}
+	return c.Merge.Validate()
+}
@@ -0,0 +1,85 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"mangahub/internal/covers"
+	"mangahub/pkg/utils"
+)
+
+// CoversConfig controls the cover mirroring done after each saved run:
+//
+//	covers:
+//	  dir: ~/.mangahub/covers   # default: MANGAHUB_COVERS_DIR
+//	  refresh_days: 30
+//	  workers: 4
+//	  deadline_seconds: 300
+//
+// The api-server serves the mirrored images from MANGAHUB_COVERS_DIR, so
+// dir should only be set where that variable is not.
+type CoversConfig struct {
+	Enabled     *bool  `yaml:"enabled" json:"enabled"` // default true
+	Dir         string `yaml:"dir" json:"dir"`
+	RefreshDays int    `yaml:"refresh_days" json:"refresh_days"` // default 30
+	Workers     int    `yaml:"workers" json:"workers"`           // default 4
+	// DeadlineSeconds bounds the whole mirroring step; default 300. What
+	// is left over is fetched after the next run.
+	DeadlineSeconds int `yaml:"deadline_seconds" json:"deadline_seconds"`
+}
+
+// IsEnabled reports whether covers are mirrored.
+func (c CoversConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// mirrorCovers downloads the covers of stored titles that are new or due
+// for a refresh. Failures are logged and recorded per image; they never
+// fail the run.
+func mirrorCovers(ctx context.Context, db *sql.DB, cfg Config) {
+	cc := cfg.Covers
+	if !cc.IsEnabled() {
+		return
+	}
+	dir := utils.LoadCoversConfig().Dir
+	if cc.Dir != "" {
+		dir = expandHome(cc.Dir)
+	}
+	deadline := 300 * time.Second
+	if cc.DeadlineSeconds > 0 {
+		deadline = time.Duration(cc.DeadlineSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, deadline)
+	defer cancel()
+
+	// images stay out of the page cache, and reading one stops at the
+	// size limit instead of buffering whatever the host sends
+	fetcher := NewFetcher(&http.Client{Timeout: 30 * time.Second}, cfg.Fetch)
+	fetcher.MaxBytes = covers.DefaultMaxBytes
+	fetcher.NoCache = true
+	m := &covers.Mirror{
+		Store:    covers.NewStore(dir),
+		Repo:     covers.NewRepo(db),
+		Refresh:  time.Duration(cc.RefreshDays) * 24 * time.Hour,
+		Workers:  cc.Workers,
+		MaxBytes: covers.DefaultMaxBytes,
+		Fetch: func(ctx context.Context, url string) ([]byte, error) {
+			res, err := fetcher.Get(ctx, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			return res.Body, nil
+		},
+	}
+	stats, err := m.Run(ctx)
+	if err != nil {
+		log.Printf("[covers] %v", err)
+		return
+	}
+	if stats.Fetched+stats.Failed > 0 {
+		log.Printf("[covers] mirrored %d covers into %s, %d failed", stats.Fetched, dir, stats.Failed)
+	}
+}
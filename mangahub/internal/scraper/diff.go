@@ -0,0 +1,206 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"mangahub/pkg/models"
+)
+
+// Ways a title differs between a merge and the manga table.
+const (
+	DiffAdded   = "added"   // merged, not stored yet
+	DiffChanged = "changed" // stored with other values
+	DiffRemoved = "removed" // stored and listed, but no source returned it
+)
+
+// FieldChange is one stored field a save would overwrite.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Old    any    `json:"old"`
+	New    any    `json:"new"`
+	Source string `json:"source,omitempty"` // where the new value came from
+}
+
+// TitleDiff is how one title would change.
+type TitleDiff struct {
+	ID      string        `json:"id"`
+	Title   string        `json:"title"`
+	Kind    string        `json:"kind"`
+	Changes []FieldChange `json:"changes,omitempty"` // changed titles only
+}
+
+// Diff compares a merge with the manga table. Titles are sorted by id
+// within each kind.
+type Diff struct {
+	Added     []TitleDiff `json:"added"`
+	Changed   []TitleDiff `json:"changed"`
+	Removed   []TitleDiff `json:"removed"`
+	Unchanged int         `json:"unchanged"`
+}
+
+// Diff works out what saving mangas would change, the way SaveToDatabase
+// would save them: locked fields keep their stored value. Removed titles
+// are only reported; saves never delete rows, and titles already delisted
+// are left out.
+func (r *Repo) Diff(ctx context.Context, mangas []models.MangaCanonical) (*Diff, error) {
+	stored, err := loadStored(ctx, r.DB)
+	if err != nil {
+		return nil, err
+	}
+	locks, err := loadLocks(ctx, r.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Diff{Added: []TitleDiff{}, Changed: []TitleDiff{}, Removed: []TitleDiff{}}
+	seen := make(map[string]bool, len(mangas))
+	for _, m := range mangas {
+		seen[m.ID] = true
+		old, ok := stored[m.ID]
+		if !ok {
+			d.Added = append(d.Added, TitleDiff{ID: m.ID, Title: m.Title, Kind: DiffAdded})
+			continue
+		}
+		if len(locks[m.ID]) > 0 {
+			m = keepLocked(m, locks[m.ID], old)
+		}
+		changes, err := changedFields(old, m)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) == 0 {
+			d.Unchanged++
+			continue
+		}
+		d.Changed = append(d.Changed, TitleDiff{ID: m.ID, Title: m.Title, Kind: DiffChanged, Changes: changes})
+	}
+	for id, old := range stored {
+		if !seen[id] && !old.delisted {
+			d.Removed = append(d.Removed, TitleDiff{ID: id, Title: old.title, Kind: DiffRemoved})
+		}
+	}
+	for _, list := range [][]TitleDiff{d.Added, d.Changed, d.Removed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	}
+	return d, nil
+}
+
+// Print writes d for people: one line per title, then one per changed
+// field.
+func (d *Diff) Print(w io.Writer) {
+	fmt.Fprintf(w, "%d added, %d changed, %d removed, %d unchanged\n",
+		len(d.Added), len(d.Changed), len(d.Removed), d.Unchanged)
+	for _, t := range d.Added {
+		fmt.Fprintf(w, "+ %s  %s\n", t.ID, t.Title)
+	}
+	for _, t := range d.Changed {
+		fmt.Fprintf(w, "~ %s  %s\n", t.ID, t.Title)
+		for _, c := range t.Changes {
+			line := fmt.Sprintf("    %-15s %s -> %s", c.Field, showValue(c.Old), showValue(c.New))
+			if c.Source != "" {
+				line += "  (" + c.Source + ")"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+	for _, t := range d.Removed {
+		fmt.Fprintf(w, "- %s  %s\n", t.ID, t.Title)
+	}
+}
+
+// showValue renders a field value on one line, cutting long text.
+func showValue(v any) string {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = fmt.Sprintf("%q", v)
+	case []string:
+		if v == nil {
+			return "null"
+		}
+		s = "[" + strings.Join(v, ", ") + "]"
+	default:
+		s = fmt.Sprint(v)
+	}
+	if r := []rune(s); len(r) > 60 {
+		s = string(r[:57]) + "..."
+	}
+	return s
+}
+
+// changedFields lists the stored fields where m differs from old.
+func changedFields(old storedRow, m models.MangaCanonical) ([]FieldChange, error) {
+	genres, err := json.Marshal(m.Genres)
+	if err != nil {
+		return nil, fmt.Errorf("marshal genres for %s: %w", m.ID, err)
+	}
+	var changes []FieldChange
+	add := func(field string, from, to any) {
+		changes = append(changes, FieldChange{Field: field, Old: from, New: to, Source: m.Provenance[field]})
+	}
+	if old.title != m.Title {
+		add(FieldTitle, old.title, m.Title)
+	}
+	if old.author != m.Author {
+		add(FieldAuthor, old.author, m.Author)
+	}
+	if old.genres != string(genres) {
+		var was []string
+		_ = json.Unmarshal([]byte(old.genres), &was)
+		add(FieldGenres, was, m.Genres)
+	}
+	if old.status != m.Status {
+		add(FieldStatus, old.status, m.Status)
+	}
+	if old.chapters != m.TotalChapters {
+		add(FieldTotalChapters, old.chapters, m.TotalChapters)
+	}
+	if old.description != m.Description {
+		add(FieldDescription, old.description, m.Description)
+	}
+	if old.cover != m.CoverURL {
+		add(FieldCoverURL, old.cover, m.CoverURL)
+	}
+	return changes, nil
+}
+
+// querier is what reading the manga table needs from a DB or Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// loadStored reads every manga row, by id.
+func loadStored(ctx context.Context, q querier) (map[string]storedRow, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, title, COALESCE(author, ''), COALESCE(genres, ''), COALESCE(status, ''),
+		       COALESCE(total_chapters, 0), COALESCE(description, ''), COALESCE(cover_url, ''),
+		       delisted_at IS NOT NULL
+		FROM manga
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("load manga: %w", err)
+	}
+	defer rows.Close()
+	stored := make(map[string]storedRow)
+	for rows.Next() {
+		var (
+			id  string
+			old storedRow
+		)
+		if err := rows.Scan(&id, &old.title, &old.author, &old.genres,
+			&old.status, &old.chapters, &old.description, &old.cover, &old.delisted); err != nil {
+			return nil, fmt.Errorf("scan manga: %w", err)
+		}
+		stored[id] = old
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load manga: %w", err)
+	}
+	return stored, nil
+}
@@ -0,0 +1,402 @@
+package scraper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FetchConfig tunes the HTTP behaviour shared by every source in a run:
+//
+//	fetch:
+//	  cache_dir: ~/.mangahub/scraper-cache
+//	  retries: 4
+//	  requests_per_second: 2
+//	  hosts: {api.mangadex.org: 5}
+type FetchConfig struct {
+	// CacheDir keeps responses that carry an ETag or Last-Modified, so the
+	// next run sends conditional requests and can fall back to the cached
+	// copy when a host is down. Empty disables the cache.
+	CacheDir     string `yaml:"cache_dir" json:"cache_dir"`
+	Retries      int    `yaml:"retries" json:"retries"`               // default 3
+	BackoffMS    int    `yaml:"backoff_ms" json:"backoff_ms"`         // first retry; default 500
+	MaxBackoffMS int    `yaml:"max_backoff_ms" json:"max_backoff_ms"` // default 30000
+	// MaxRetryAfterSeconds is the longest Retry-After we wait out; a longer
+	// one fails the request. Default 120.
+	MaxRetryAfterSeconds int `yaml:"max_retry_after_seconds" json:"max_retry_after_seconds"`
+	// RequestsPerSecond limits each host across all sources; Hosts
+	// overrides it per host name. 0 is unlimited.
+	RequestsPerSecond float64            `yaml:"requests_per_second" json:"requests_per_second"`
+	Hosts             map[string]float64 `yaml:"hosts" json:"hosts"`
+}
+
+// defaultHostRates are the published limits of the hosts we scrape by
+// default, used unless the config names the host.
+var defaultHostRates = map[string]float64{
+	"api.mangadex.org": 5,
+}
+
+// Fetcher performs GET requests for a source. Fetchers built from the same
+// FetchConfig share per-host rate limits and the response cache, while each
+// keeps its own client (and so its own credentials and timeout).
+type Fetcher struct {
+	Client *http.Client
+	// MaxFailures is the source's failure budget: how many failed pages
+	// Tolerate lets it skip in one run.
+	MaxFailures int
+	// MaxBytes fails responses with larger bodies; 0 is unlimited.
+	MaxBytes int64
+	// NoCache keeps responses out of the response cache, for bodies too
+	// big to be worth keeping twice, like images.
+	NoCache bool
+
+	failures atomic.Int32
+	shared   *fetchShared
+}
+
+// Tolerate records a failed page and reports whether the source may skip
+// it and carry on.
+func (f *Fetcher) Tolerate(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return int(f.failures.Add(1)) <= f.MaxFailures
+}
+
+type fetchShared struct {
+	cfg   FetchConfig
+	cache *diskCache
+
+	mu   sync.Mutex
+	next map[string]time.Time // earliest start of the next request per host
+}
+
+func newFetchShared(cfg FetchConfig) *fetchShared {
+	switch {
+	case cfg.Retries == 0:
+		cfg.Retries = 3
+	case cfg.Retries < 0:
+		cfg.Retries = 0
+	}
+	if cfg.BackoffMS <= 0 {
+		cfg.BackoffMS = 500
+	}
+	if cfg.MaxBackoffMS <= 0 {
+		cfg.MaxBackoffMS = 30000
+	}
+	if cfg.MaxRetryAfterSeconds <= 0 {
+		cfg.MaxRetryAfterSeconds = 120
+	}
+	s := &fetchShared{cfg: cfg, next: make(map[string]time.Time)}
+	if cfg.CacheDir != "" {
+		s.cache = &diskCache{dir: expandHome(cfg.CacheDir)}
+	}
+	return s
+}
+
+// NewFetcher returns a standalone fetcher with cfg's settings.
+func NewFetcher(client *http.Client, cfg FetchConfig) *Fetcher {
+	return &Fetcher{Client: client, shared: newFetchShared(cfg)}
+}
+
+// Response is a fetched 200 response with its body read.
+type Response struct {
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+	// Cached is set when the body came from the cache: after a 304, or as
+	// a stale copy when the host kept failing.
+	Cached bool
+}
+
+// StatusError is returned for responses other than 200 and 304.
+type StatusError struct {
+	URL  string
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: status %d: %s", e.URL, e.Code, truncate(e.Body, 200))
+}
+
+// Get fetches rawURL, waiting for the host's rate limit, retrying
+// throttling, server and network errors with jittered backoff (or the
+// server's Retry-After), and revalidating cached copies.
+func (f *Fetcher) Get(ctx context.Context, rawURL string, header http.Header) (*Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("bad url %q: %w", rawURL, err)
+	}
+	cfg := f.shared.cfg
+	var cached *cacheEntry
+	if !f.NoCache {
+		cached = f.shared.cache.load(rawURL)
+	}
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		if err := f.shared.waitHost(ctx, u.Host); err != nil {
+			return nil, err
+		}
+		resp, retryAfter, err := f.do(ctx, u, header, cached)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil || !retryable(err) || attempt >= cfg.Retries {
+			break
+		}
+
+		wait := backoff(attempt, cfg.BackoffMS, cfg.MaxBackoffMS)
+		if retryAfter > 0 {
+			if retryAfter > time.Duration(cfg.MaxRetryAfterSeconds)*time.Second {
+				break
+			}
+			wait = retryAfter
+		}
+		log.Printf("[scraper] %s: %v; retry %d/%d in %s", u.Host, err, attempt+1, cfg.Retries, wait.Round(time.Millisecond))
+		if err := sleepCtx(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+
+	if cached != nil && ctx.Err() == nil && retryable(lastErr) {
+		log.Printf("[scraper] %s: serving stale cached copy after: %v", rawURL, lastErr)
+		return cached.response(u), nil
+	}
+	return nil, lastErr
+}
+
+// do sends one request. retryAfter is the server's Retry-After, if any.
+func (f *Fetcher) do(ctx context.Context, u *url.URL, header http.Header, cached *cacheEntry) (*Response, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached.response(resp.Request.URL), 0, nil
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), &StatusError{URL: u.String(), Code: resp.StatusCode, Body: string(body)}
+	}
+
+	var r io.Reader = resp.Body
+	if f.MaxBytes > 0 {
+		r = io.LimitReader(resp.Body, f.MaxBytes+1)
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read body: %w", err)
+	}
+	if f.MaxBytes > 0 && int64(len(body)) > f.MaxBytes {
+		return nil, 0, fmt.Errorf("%s: body over the %d byte limit", u, f.MaxBytes)
+	}
+	out := &Response{URL: resp.Request.URL, Header: resp.Header, Body: body}
+	if !f.NoCache {
+		f.shared.cache.store(u.String(), out)
+	}
+	return out, 0, nil
+}
+
+// retryable reports whether err is worth another attempt: throttling,
+// server errors and network failures.
+func retryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code == http.StatusTooManyRequests || se.Code == http.StatusRequestTimeout || se.Code >= 500
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var ne net.Error
+	return errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// backoff is base·2^attempt capped at max, with the upper half jittered so
+// sources throttled together don't retry together.
+func backoff(attempt, baseMS, maxMS int) time.Duration {
+	d := time.Duration(baseMS) * time.Millisecond << attempt
+	if limit := time.Duration(maxMS) * time.Millisecond; d > limit || d <= 0 {
+		d = limit
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// parseRetryAfter reads delay-seconds or an HTTP date; 0 when absent.
+func parseRetryAfter(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// waitHost blocks until host may be sent another request.
+func (s *fetchShared) waitHost(ctx context.Context, host string) error {
+	rate, ok := s.cfg.Hosts[host]
+	if !ok {
+		if rate, ok = defaultHostRates[host]; !ok {
+			rate = s.cfg.RequestsPerSecond
+		}
+	}
+	if rate <= 0 {
+		return nil
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+
+	s.mu.Lock()
+	now := time.Now()
+	start := s.next[host]
+	if start.Before(now) {
+		start = now
+	}
+	s.next[host] = start.Add(interval)
+	s.mu.Unlock()
+
+	return sleepCtx(ctx, time.Until(start))
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// diskCache stores validatable responses as one JSON file per URL. A nil
+// *diskCache caches nothing.
+type diskCache struct {
+	dir string
+}
+
+type cacheEntry struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	StoredAt     time.Time   `json:"stored_at"`
+}
+
+func (e *cacheEntry) response(u *url.URL) *Response {
+	return &Response{URL: u, Header: e.Header, Body: e.Body, Cached: true}
+}
+
+func (c *diskCache) path(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *diskCache) load(rawURL string) *cacheEntry {
+	if c == nil {
+		return nil
+	}
+	raw, err := os.ReadFile(c.path(rawURL))
+	if err != nil {
+		return nil
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(raw, &e); err != nil || e.URL != rawURL {
+		return nil
+	}
+	return &e
+}
+
+func (c *diskCache) store(rawURL string, resp *Response) {
+	if c == nil {
+		return
+	}
+	e := cacheEntry{
+		URL:          rawURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Header:       http.Header{},
+		Body:         resp.Body,
+		StoredAt:     time.Now().UTC(),
+	}
+	if e.ETag == "" && e.LastModified == "" {
+		return
+	}
+	for _, k := range []string{"Content-Type", "Link"} {
+		if v := resp.Header.Values(k); len(v) > 0 {
+			e.Header[k] = v
+		}
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		log.Printf("[scraper] cache: %v", err)
+		return
+	}
+	// write then rename so a concurrent reader never sees half a file
+	tmp := c.path(rawURL) + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		log.Printf("[scraper] cache: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, c.path(rawURL)); err != nil {
+		log.Printf("[scraper] cache: %v", err)
+	}
+}
+
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(p, "~"))
+		}
+	}
+	return p
+}
@@ -0,0 +1,105 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetcherRetriesThrottledRequests(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(srv.Client(), FetchConfig{BackoffMS: 1, MaxBackoffMS: 5})
+	resp, err := f.Get(context.Background(), srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body) != "ok" || calls.Load() != 3 {
+		t.Errorf("body %q after %d calls", resp.Body, calls.Load())
+	}
+}
+
+func TestFetcherDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(srv.Client(), FetchConfig{BackoffMS: 1})
+	_, err := f.Get(context.Background(), srv.URL, nil)
+	if se, ok := err.(*StatusError); !ok || se.Code != http.StatusNotFound {
+		t.Fatalf("err = %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("%d calls, want 1", calls.Load())
+	}
+}
+
+func TestFetcherRevalidatesAndFallsBackToCache(t *testing.T) {
+	var down atomic.Bool
+	var conditional atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "catalog")
+	}))
+	defer srv.Close()
+
+	cfg := FetchConfig{CacheDir: t.TempDir(), Retries: 1, BackoffMS: 1, MaxBackoffMS: 1}
+	f := NewFetcher(srv.Client(), cfg)
+	ctx := context.Background()
+
+	if _, err := f.Get(ctx, srv.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewFetcher(srv.Client(), cfg).Get(ctx, srv.URL, nil)
+	if err != nil || !resp.Cached || string(resp.Body) != "catalog" || conditional.Load() != 1 {
+		t.Fatalf("revalidate: resp=%+v err=%v conditional=%d", resp, err, conditional.Load())
+	}
+
+	down.Store(true)
+	resp, err = f.Get(ctx, srv.URL, nil)
+	if err != nil || !resp.Cached || string(resp.Body) != "catalog" {
+		t.Fatalf("stale fallback: resp=%+v err=%v", resp, err)
+	}
+}
+
+func TestFetcherRateLimitsPerHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	f := NewFetcher(srv.Client(), FetchConfig{RequestsPerSecond: 50})
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := f.Get(context.Background(), srv.URL, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// three 20ms gaps between four requests
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("4 requests at 50/s took %s", elapsed)
+	}
+}
@@ -0,0 +1,181 @@
+package scraper
+
+import (
+	"net/http"
+	"strconv"
+
+	"mangahub/internal/apperr"
+	"mangahub/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves scraper bookkeeping to admins.
+type Handler struct {
+	Repo *Repo
+}
+
+func NewHandler(repo *Repo) *Handler {
+	return &Handler{Repo: repo}
+}
+
+// RegisterAdminRoutes expects a group behind AuthMiddleware and
+// RequireRole(RoleAdmin).
+func (h *Handler) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.GET("/scrape-runs", h.listRuns)    // GET /admin/scrape-runs?limit=&offset=
+	rg.GET("/scrape-runs/:id", h.getRun)  // GET /admin/scrape-runs/:id
+	rg.POST("/scrape-runs", h.requestRun) // POST /admin/scrape-runs {"sources": [...]}
+	rg.GET("/scraper/status", h.status)   // GET /admin/scraper/status
+
+	rg.GET("/match-reviews", h.listReviews)        // GET /admin/match-reviews?status=&limit=&offset=
+	rg.POST("/match-reviews/:id", h.resolveReview) // POST /admin/match-reviews/:id {"action": "merge"|"reject"}
+}
+
+func (h *Handler) listRuns(c *gin.Context) {
+	limit := queryInt(c, "limit", 20)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset := queryInt(c, "offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+
+	runs, total, err := h.Repo.ListRuns(c.Request.Context(), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list scrape runs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+		"items":  runs,
+	})
+}
+
+func (h *Handler) getRun(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid run id"})
+		return
+	}
+	run, err := h.Repo.GetRun(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load scrape run"})
+		return
+	}
+	if run == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "scrape run not found"})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+type runReq struct {
+	Sources []string `json:"sources"` // empty: all
+}
+
+// requestRun queues a run for cmd/worker, which picks it up within its
+// poll interval.
+func (h *Handler) requestRun(c *gin.Context) {
+	claims := auth.MustGetClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req runReq
+	// an empty body asks for every source
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+	}
+
+	rr, err := h.Repo.RequestRun(c.Request.Context(), req.Sources, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to request scrape run"})
+		return
+	}
+	c.JSON(http.StatusAccepted, rr)
+}
+
+func (h *Handler) status(c *gin.Context) {
+	st, err := h.Repo.Status(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load scraper status"})
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+func (h *Handler) listReviews(c *gin.Context) {
+	status := c.DefaultQuery("status", ReviewPending)
+	switch status {
+	case ReviewPending, ReviewMerged, ReviewRejected:
+	case "all":
+		status = ""
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, merged, rejected or all"})
+		return
+	}
+	limit := queryInt(c, "limit", 20)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset := queryInt(c, "offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+
+	reviews, total, err := h.Repo.ListReviews(c.Request.Context(), status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list match reviews"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+		"items":  reviews,
+	})
+}
+
+type resolveReq struct {
+	Action string `json:"action"` // merge or reject
+}
+
+func (h *Handler) resolveReview(c *gin.Context) {
+	claims := auth.MustGetClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review id"})
+		return
+	}
+	var req resolveReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+
+	status := map[string]string{"merge": ReviewMerged, "reject": ReviewRejected}[req.Action]
+	rv, err := h.Repo.ResolveReview(c.Request.Context(), id, status, claims.UserID)
+	if err != nil {
+		apperr.JSON(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rv)
+}
+
+func queryInt(c *gin.Context, key string, def int) int {
+	n, err := strconv.Atoi(c.Query(key))
+	if err != nil {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,105 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrRunInProgress is returned by Run while another process holds the
+// scrape lease.
+var ErrRunInProgress = errors.New("another scrape run is in progress")
+
+// leaseTTL is how long a lease lasts without renewal, so a crashed run
+// blocks others for at most this long.
+const leaseTTL = 2 * time.Minute
+
+// Lease is the row in scrape_lease that marks a run in progress.
+type Lease struct {
+	Holder     string    `json:"holder"`
+	Trigger    string    `json:"trigger"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// DefaultHolder names this process in the lease: host and pid.
+func DefaultHolder() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// acquireLease takes the scrape lease for holder unless a live lease
+// exists, even one held by holder itself, and keeps renewing it until
+// release is called. Each acquisition gets a token of its own, so renewing
+// and releasing never touch a lease this call did not take.
+func (r *Repo) acquireLease(ctx context.Context, holder, trigger string) (release func(), err error) {
+	token := uuid.NewString()
+	now := time.Now().UTC()
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO scrape_lease (name, holder, token, trigger, acquired_at, expires_at) VALUES ('scrape', ?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+		  holder = excluded.holder,
+		  token = excluded.token,
+		  trigger = excluded.trigger,
+		  acquired_at = excluded.acquired_at,
+		  expires_at = excluded.expires_at
+		WHERE scrape_lease.expires_at < ?
+	`, holder, token, trigger, now, now.Add(leaseTTL), now)
+	if err != nil {
+		return nil, fmt.Errorf("acquire scrape lease: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrRunInProgress
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(leaseTTL / 3)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				res, err := r.DB.Exec(`UPDATE scrape_lease SET expires_at = ? WHERE name = 'scrape' AND token = ?`,
+					time.Now().UTC().Add(leaseTTL), token)
+				if err != nil {
+					log.Printf("[scraper] renew lease: %v", err)
+				} else if n, _ := res.RowsAffected(); n == 0 {
+					log.Printf("[scraper] renew lease: lost to another holder")
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+		if _, err := r.DB.Exec(`DELETE FROM scrape_lease WHERE name = 'scrape' AND token = ?`, token); err != nil {
+			log.Printf("[scraper] release lease: %v", err)
+		}
+	}, nil
+}
+
+// CurrentLease returns the live lease, or nil when no run is in progress.
+func (r *Repo) CurrentLease(ctx context.Context) (*Lease, error) {
+	var l Lease
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT holder, trigger, acquired_at, expires_at FROM scrape_lease WHERE name = 'scrape' AND expires_at >= ?
+	`, time.Now().UTC()).Scan(&l.Holder, &l.Trigger, &l.AcquiredAt, &l.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load scrape lease: %w", err)
+	}
+	return &l, nil
+}
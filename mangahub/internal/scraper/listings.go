@@ -0,0 +1,131 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DelistConfig decides when titles no source lists any more drop out of
+// the catalog:
+//
+//	delist: {after_runs: 3, min_items_ratio: 0.5}
+//
+// A source entry missed by after_runs complete fetches of its source in a
+// row counts as gone. Fetches that stop at max_items or a page limit do not
+// count, nor do fetches that return fewer than min_items_ratio of the
+// entries linked to the source: a site that suddenly lists far less is more
+// likely broken than emptied. A title is delisted when every entry linked to it is
+// gone, and listed again as soon as one comes back. Delisted titles stay
+// in the database, so library entries and reviews keep working.
+type DelistConfig struct {
+	Enabled       *bool   `yaml:"enabled" json:"enabled"`                 // default true
+	AfterRuns     int     `yaml:"after_runs" json:"after_runs"`           // default 3
+	MinItemsRatio float64 `yaml:"min_items_ratio" json:"min_items_ratio"` // default 0.5
+}
+
+// IsEnabled reports whether titles are delisted.
+func (c DelistConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// DefaultDelistAfter is how many runs must miss a title before it is
+// delisted, when the config sets none.
+const DefaultDelistAfter = 3
+
+// DefaultMinItemsRatio is the share of its linked entries a fetch must
+// return to count missed runs, when the config sets none.
+const DefaultMinItemsRatio = 0.5
+
+// trackListings records which source entries the run's live sources
+// returned. Entries of sources that listed their whole catalog without
+// errors, not stopping at max_items or a page limit, and did not include
+// them count a missed run, unless the fetch returned too few entries (see
+// DelistConfig). It then delists and relists titles and counts both in
+// report.
+func (r *Repo) trackListings(ctx context.Context, report *RunReport, cfg DelistConfig) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	seen, err := tx.PrepareContext(ctx, `
+		UPDATE manga_sources
+		SET first_seen_at = COALESCE(first_seen_at, ?), last_seen_at = ?, missed_runs = 0
+		WHERE source = ? AND source_id = ?
+	`)
+	if err != nil {
+		return fmt.Errorf("prepare seen: %w", err)
+	}
+	defer seen.Close()
+
+	ratio := cfg.MinItemsRatio
+	if ratio <= 0 {
+		ratio = DefaultMinItemsRatio
+	}
+	now := time.Now().UTC()
+	for name, items := range report.fetched {
+		for _, m := range items {
+			if _, err := seen.ExecContext(ctx, now, now, name, m.SourceIDs[name]); err != nil {
+				return fmt.Errorf("mark %s/%s seen: %w", name, m.SourceIDs[name], err)
+			}
+		}
+		if !report.listedAll(name) {
+			// a failed or cut short fetch says nothing about what it did
+			// not return
+			continue
+		}
+		var linked int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM manga_sources WHERE source = ?`, name).Scan(&linked); err != nil {
+			return fmt.Errorf("count %s entries: %w", name, err)
+		}
+		if float64(len(items)) < ratio*float64(linked) {
+			log.Printf("[scraper] warning: %s returned %d of its %d entries, under min_items_ratio %g; not counting missed runs",
+				name, len(items), linked, ratio)
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE manga_sources SET missed_runs = missed_runs + 1
+			WHERE source = ? AND (last_seen_at IS NULL OR last_seen_at < ?)
+		`, name, now); err != nil {
+			return fmt.Errorf("count %s misses: %w", name, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE manga SET delisted_at = NULL
+		WHERE delisted_at IS NOT NULL AND id IN (SELECT manga_id FROM manga_sources WHERE last_seen_at = ?)
+	`, now)
+	if err != nil {
+		return fmt.Errorf("relist titles: %w", err)
+	}
+	relisted, _ := res.RowsAffected()
+	report.Relisted = int(relisted)
+
+	if cfg.IsEnabled() {
+		after := cfg.AfterRuns
+		if after <= 0 {
+			after = DefaultDelistAfter
+		}
+		// links of sources no longer fetched never miss a run, so they
+		// keep their titles listed
+		res, err := tx.ExecContext(ctx, `
+			UPDATE manga SET delisted_at = ?
+			WHERE delisted_at IS NULL AND id IN (
+			  SELECT manga_id FROM manga_sources GROUP BY manga_id HAVING MIN(missed_runs) >= ?
+			)
+		`, now, after)
+		if err != nil {
+			return fmt.Errorf("delist titles: %w", err)
+		}
+		delisted, _ := res.RowsAffected()
+		report.Delisted = int(delisted)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+package scraper
+
+import (
+	"sort"
+	"strings"
+
+	"mangahub/pkg/models"
+)
+
+// MatchConfig sets how sure the aggregator must be before treating two
+// entries as the same work:
+//
+//	match:
+//	  auto_merge: 0.85
+//	  review: 0.6
+//
+// Pairs scoring at least AutoMerge are merged. Pairs between Review and
+// AutoMerge stay separate and are queued for an admin to decide.
+type MatchConfig struct {
+	AutoMerge float64 `yaml:"auto_merge" json:"auto_merge"`
+	Review    float64 `yaml:"review" json:"review"`
+}
+
+func (c MatchConfig) withDefaults() MatchConfig {
+	if c.AutoMerge <= 0 {
+		c.AutoMerge = 0.85
+	}
+	if c.Review <= 0 || c.Review > c.AutoMerge {
+		c.Review = 0.6
+	}
+	return c
+}
+
+// MatchState is what earlier runs and admins decided about matches.
+type MatchState struct {
+	// Links maps a source entry (linkKey) to the manga id it was saved
+	// under, so entries matched once stay together.
+	Links map[string]string
+	// Distinct holds source entry/manga pairs an admin said are different
+	// works (distinctKey).
+	Distinct map[string]bool
+}
+
+func linkKey(source, sourceID string) string { return source + "\x00" + sourceID }
+
+func distinctKey(source, sourceID, mangaID string) string {
+	return source + "\x00" + sourceID + "\x00" + mangaID
+}
+
+// MatchCandidate is a pair that scored too low to merge on its own: the
+// entry was saved as MangaID, and may be the same work as CandidateID.
+type MatchCandidate struct {
+	Source         string  `json:"source"`
+	SourceID       string  `json:"source_id"`
+	MangaID        string  `json:"manga_id"`
+	Title          string  `json:"title"`
+	Author         string  `json:"author,omitempty"`
+	Year           int     `json:"year,omitempty"`
+	CandidateID    string  `json:"candidate_id"`
+	CandidateTitle string  `json:"candidate_title"`
+	Score          float64 `json:"score"`
+}
+
+// matcher groups the entries of one run. Each group is one work; its
+// merged entry carries the id it is saved under.
+type matcher struct {
+	cfg    MatchConfig
+	state  MatchState
+	merger *merger
+
+	groups  []*matchGroup
+	byLink  map[string]*matchGroup // source entry -> group, this run
+	byID    map[string]*matchGroup // manga id -> group
+	byToken map[string][]*matchGroup
+
+	candidates []MatchCandidate
+}
+
+type matchGroup struct {
+	m      models.MangaCanonical
+	titles map[string]bool // normalized title and alt titles
+}
+
+func newMatcher(cfg MatchConfig, state MatchState, mg *merger) *matcher {
+	return &matcher{
+		cfg:     cfg.withDefaults(),
+		state:   state,
+		merger:  mg,
+		byLink:  make(map[string]*matchGroup),
+		byID:    make(map[string]*matchGroup),
+		byToken: make(map[string][]*matchGroup),
+	}
+}
+
+// add places m, fetched from source, into the group of the work it
+// describes, in order of certainty: this run's links, earlier runs' links,
+// then similarity.
+func (mt *matcher) add(m models.MangaCanonical, source string) {
+	for src, id := range m.SourceIDs {
+		if g := mt.byLink[linkKey(src, id)]; g != nil {
+			mt.merge(g, m, source)
+			return
+		}
+	}
+	for src, id := range m.SourceIDs {
+		if canon, ok := mt.state.Links[linkKey(src, id)]; ok {
+			if g := mt.byID[canon]; g != nil {
+				mt.merge(g, m, source)
+				return
+			}
+			m.ID = canon
+			mt.newGroup(m, source)
+			return
+		}
+	}
+
+	best, score := mt.bestCandidate(m, source)
+	if best != nil && score >= mt.cfg.AutoMerge {
+		mt.merge(best, m, source)
+		return
+	}
+	if mt.byID[m.ID] != nil {
+		// another source uses the same id for a different work
+		m.ID = source + ":" + m.ID
+	}
+	if best != nil && score >= mt.cfg.Review {
+		mt.candidates = append(mt.candidates, MatchCandidate{
+			Source:         source,
+			SourceID:       m.SourceIDs[source],
+			MangaID:        m.ID,
+			Title:          m.Title,
+			Author:         m.Author,
+			Year:           m.Year,
+			CandidateID:    best.m.ID,
+			CandidateTitle: best.m.Title,
+			Score:          score,
+		})
+	}
+	mt.newGroup(m, source)
+}
+
+// bestCandidate scores m against the groups sharing a title word with it,
+// skipping pairs an admin has ruled distinct.
+func (mt *matcher) bestCandidate(m models.MangaCanonical, source string) (*matchGroup, float64) {
+	titles := titleKeys(m)
+	seen := make(map[*matchGroup]bool)
+	var best *matchGroup
+	bestScore := 0.0
+	for t := range titles {
+		for _, tok := range strings.Fields(t) {
+			for _, g := range mt.byToken[tok] {
+				if seen[g] {
+					continue
+				}
+				seen[g] = true
+				if mt.state.Distinct[distinctKey(source, m.SourceIDs[source], g.m.ID)] {
+					continue
+				}
+				if s := matchScore(titles, m, g); s > bestScore {
+					best, bestScore = g, s
+				}
+			}
+		}
+	}
+	return best, bestScore
+}
+
+func (mt *matcher) newGroup(m models.MangaCanonical, source string) {
+	g := &matchGroup{m: mt.merger.seed(m, source), titles: map[string]bool{}}
+	mt.groups = append(mt.groups, g)
+	mt.byID[m.ID] = g
+	mt.index(g, m)
+}
+
+func (mt *matcher) merge(g *matchGroup, m models.MangaCanonical, source string) {
+	g.m = mt.merger.merge(g.m, m, source)
+	mt.index(g, m)
+}
+
+// index records m's links and title words as pointing at g.
+func (mt *matcher) index(g *matchGroup, m models.MangaCanonical) {
+	for src, id := range m.SourceIDs {
+		mt.byLink[linkKey(src, id)] = g
+	}
+	for t := range titleKeys(m) {
+		if g.titles[t] {
+			continue
+		}
+		g.titles[t] = true
+		for _, tok := range strings.Fields(t) {
+			if !containsGroup(mt.byToken[tok], g) {
+				mt.byToken[tok] = append(mt.byToken[tok], g)
+			}
+		}
+	}
+}
+
+func containsGroup(gs []*matchGroup, g *matchGroup) bool {
+	for _, x := range gs {
+		if x == g {
+			return true
+		}
+	}
+	return false
+}
+
+// results returns the merged entries in the order their groups formed.
+func (mt *matcher) results() []models.MangaCanonical {
+	out := make([]models.MangaCanonical, 0, len(mt.groups))
+	for _, g := range mt.groups {
+		out = append(out, g.m)
+	}
+	return out
+}
+
+func titleKeys(m models.MangaCanonical) map[string]bool {
+	keys := make(map[string]bool, 1+len(m.AltTitles))
+	for _, t := range append([]string{m.Title}, m.AltTitles...) {
+		if k := normalizeKey(t); k != "" {
+			keys[k] = true
+		}
+	}
+	return keys
+}
+
+// matchScore rates how likely m and g are the same work, from 0 to 1. The
+// best title pair carries most weight; author and year count only when
+// both sides know them, so a bare title match can still reach 1.
+func matchScore(titles map[string]bool, m models.MangaCanonical, g *matchGroup) float64 {
+	const (
+		titleWeight  = 0.6
+		authorWeight = 0.25
+		yearWeight   = 0.15
+	)
+
+	titleSim := 0.0
+	for a := range titles {
+		for b := range g.titles {
+			if s := similarity(a, b); s > titleSim {
+				titleSim = s
+			}
+		}
+	}
+
+	total, weight := titleSim*titleWeight, titleWeight
+	if m.Author != "" && g.m.Author != "" {
+		total += authorWeight * similarity(authorKey(m.Author), authorKey(g.m.Author))
+		weight += authorWeight
+	}
+	if m.Year > 0 && g.m.Year > 0 {
+		switch d := m.Year - g.m.Year; {
+		case d == 0:
+			total += yearWeight
+		case d == 1 || d == -1:
+			// serialization and volume release often differ by a year
+			total += yearWeight / 2
+		}
+		weight += yearWeight
+	}
+	return total / weight
+}
+
+// authorKey normalizes a name with its words sorted, so "Oda Eiichiro" and
+// "Eiichiro Oda" compare equal.
+func authorKey(s string) string {
+	words := strings.Fields(normalizeKey(s))
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// similarity is the Dice coefficient of the character bigrams of two
+// normalized strings: 1 for equal strings, near 0 for unrelated ones.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ba, bb := bigrams(a), bigrams(b)
+	if len(ba) == 0 || len(bb) == 0 {
+		return 0
+	}
+	counts := make(map[string]int, len(ba))
+	for _, x := range ba {
+		counts[x]++
+	}
+	shared := 0
+	for _, x := range bb {
+		if counts[x] > 0 {
+			counts[x]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ba)+len(bb))
+}
+
+func bigrams(s string) []string {
+	r := []rune(strings.ReplaceAll(s, " ", ""))
+	if len(r) < 2 {
+		if len(r) == 1 {
+			return []string{string(r)}
+		}
+		return nil
+	}
+	out := make([]string, 0, len(r)-1)
+	for i := 0; i < len(r)-1; i++ {
+		out = append(out, string(r[i:i+2]))
+	}
+	return out
+}
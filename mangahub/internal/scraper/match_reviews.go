@@ -0,0 +1,266 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mangahub/internal/apperr"
+)
+
+// Review statuses in scrape_match_reviews.
+const (
+	ReviewPending  = "pending"
+	ReviewMerged   = "merged"   // same work: merged from the next run on
+	ReviewRejected = "rejected" // different works: never proposed again
+)
+
+// MatchReview is a queued MatchCandidate and what an admin decided.
+type MatchReview struct {
+	ID int64 `json:"id"`
+	MatchCandidate
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy string     `json:"resolved_by,omitempty"`
+}
+
+// LoadMatchState reads the source links saved by earlier runs and the
+// pairs admins rejected, for Aggregator.State.
+func (r *Repo) LoadMatchState(ctx context.Context) (MatchState, error) {
+	state := MatchState{Links: map[string]string{}, Distinct: map[string]bool{}}
+
+	rows, err := r.DB.QueryContext(ctx, `SELECT source, source_id, manga_id FROM manga_sources`)
+	if err != nil {
+		return state, fmt.Errorf("load source links: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var source, sourceID, mangaID string
+		if err := rows.Scan(&source, &sourceID, &mangaID); err != nil {
+			return state, fmt.Errorf("scan source link: %w", err)
+		}
+		state.Links[linkKey(source, sourceID)] = mangaID
+	}
+	if err := rows.Err(); err != nil {
+		return state, fmt.Errorf("load source links: %w", err)
+	}
+	rows.Close()
+
+	rows, err = r.DB.QueryContext(ctx, `
+		SELECT source, source_id, candidate_id FROM scrape_match_reviews WHERE status = ?
+	`, ReviewRejected)
+	if err != nil {
+		return state, fmt.Errorf("load rejected matches: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var source, sourceID, candidateID string
+		if err := rows.Scan(&source, &sourceID, &candidateID); err != nil {
+			return state, fmt.Errorf("scan rejected match: %w", err)
+		}
+		state.Distinct[distinctKey(source, sourceID, candidateID)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return state, fmt.Errorf("load rejected matches: %w", err)
+	}
+	return state, nil
+}
+
+// QueueReviews stores candidates as pending reviews and returns how many
+// are pending now. A pair seen again refreshes its pending review; pairs
+// already resolved are left alone.
+func (r *Repo) QueueReviews(ctx context.Context, candidates []MatchCandidate) (int, error) {
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO scrape_match_reviews
+		  (source, source_id, manga_id, title, author, year, candidate_id, candidate_title, score)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(source, source_id, candidate_id) DO UPDATE SET
+		  manga_id = excluded.manga_id,
+		  title = excluded.title,
+		  author = excluded.author,
+		  year = excluded.year,
+		  candidate_title = excluded.candidate_title,
+		  score = excluded.score
+		WHERE scrape_match_reviews.status = 'pending'
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("prepare review: %w", err)
+	}
+	defer stmt.Close()
+
+	queued := 0
+	for _, c := range candidates {
+		res, err := stmt.ExecContext(ctx, c.Source, c.SourceID, c.MangaID, c.Title, c.Author, c.Year,
+			c.CandidateID, c.CandidateTitle, c.Score)
+		if err != nil {
+			return 0, fmt.Errorf("queue review %s/%s: %w", c.Source, c.SourceID, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			queued++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit tx: %w", err)
+	}
+	return queued, nil
+}
+
+const reviewColumns = `id, source, source_id, manga_id, title, COALESCE(author, ''), COALESCE(year, 0),
+	candidate_id, candidate_title, score, status, created_at, resolved_at, COALESCE(resolved_by, '')`
+
+// ListReviews returns reviews with the given status ("" for all), oldest
+// first, with the total count.
+func (r *Repo) ListReviews(ctx context.Context, status string, limit, offset int) ([]MatchReview, int, error) {
+	var total int
+	if err := r.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM scrape_match_reviews WHERE ? = '' OR status = ?
+	`, status, status).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count match reviews: %w", err)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT `+reviewColumns+` FROM scrape_match_reviews
+		WHERE ? = '' OR status = ?
+		ORDER BY id LIMIT ? OFFSET ?
+	`, status, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list match reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := make([]MatchReview, 0)
+	for rows.Next() {
+		rv, err := scanReview(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		reviews = append(reviews, *rv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("list match reviews: %w", err)
+	}
+	return reviews, total, nil
+}
+
+// ResolveReview settles a pending review. Merging links the source entry
+// to the candidate, so the next run folds it in. When no other source
+// entry is linked to the row it was saved under meanwhile, that row's
+// library entries, progress and reviews move to the candidate and the row
+// is delisted. Rejecting keeps the pair from being proposed again.
+func (r *Repo) ResolveReview(ctx context.Context, id int64, status, actorID string) (*MatchReview, error) {
+	if status != ReviewMerged && status != ReviewRejected {
+		return nil, apperr.Invalid("action must be merge or reject")
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, apperr.Internal("resolve failed", fmt.Errorf("begin tx: %w", err))
+	}
+	defer tx.Rollback()
+
+	rv, err := scanReview(tx.QueryRowContext(ctx, `SELECT `+reviewColumns+` FROM scrape_match_reviews WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, apperr.NotFound("match review not found")
+	}
+	if err != nil {
+		return nil, apperr.Internal("resolve failed", err)
+	}
+	if rv.Status != ReviewPending {
+		return nil, apperr.Conflict("match review already " + rv.Status)
+	}
+
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE scrape_match_reviews SET status = ?, resolved_at = ?, resolved_by = ? WHERE id = ?
+	`, status, now, actorID, id); err != nil {
+		return nil, apperr.Internal("resolve failed", fmt.Errorf("update match review: %w", err))
+	}
+	if status == ReviewMerged {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO manga_sources (source, source_id, manga_id) VALUES (?, ?, ?)
+			ON CONFLICT(source, source_id) DO UPDATE SET manga_id = excluded.manga_id
+		`, rv.Source, rv.SourceID, rv.CandidateID); err != nil {
+			return nil, apperr.Internal("resolve failed", fmt.Errorf("link %s/%s: %w", rv.Source, rv.SourceID, err))
+		}
+		if rv.MangaID != rv.CandidateID {
+			if err := foldOrphan(ctx, tx, rv.MangaID, rv.CandidateID, now); err != nil {
+				return nil, apperr.Internal("resolve failed", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, apperr.Internal("resolve failed", fmt.Errorf("commit tx: %w", err))
+	}
+
+	rv.Status, rv.ResolvedAt, rv.ResolvedBy = status, &now, actorID
+	return rv, nil
+}
+
+// foldOrphan moves what users attached to from over to to, once no source
+// entry is linked to from any more, and delists from. The row itself is
+// kept, so its chat room and edit history survive. Where a user tracks
+// both titles, the candidate's library entry is kept at the further
+// chapter.
+func foldOrphan(ctx context.Context, tx *sql.Tx, from, to string, now time.Time) error {
+	var linked int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM manga_sources WHERE manga_id = ?`, from).Scan(&linked); err != nil {
+		return fmt.Errorf("count links of %s: %w", from, err)
+	}
+	if linked > 0 {
+		// still the title of another source's entry
+		return nil
+	}
+
+	steps := []struct {
+		what, query string
+		args        []any
+	}{
+		{"merge progress", `
+			UPDATE user_progress SET current_chapter = (
+			  SELECT MAX(o.current_chapter) FROM user_progress o
+			  WHERE o.user_id = user_progress.user_id AND o.manga_id IN (?, ?)
+			)
+			WHERE manga_id = ? AND user_id IN (SELECT user_id FROM user_progress WHERE manga_id = ?)
+		`, []any{from, to, to, from}},
+		{"move progress", `UPDATE OR IGNORE user_progress SET manga_id = ? WHERE manga_id = ?`, []any{to, from}},
+		{"drop merged progress", `DELETE FROM user_progress WHERE manga_id = ?`, []any{from}},
+		{"move progress history", `UPDATE user_progress_history SET manga_id = ? WHERE manga_id = ?`, []any{to, from}},
+		{"move reviews", `UPDATE reviews SET manga_id = ? WHERE manga_id = ?`, []any{to, from}},
+		{"delist", `UPDATE manga SET delisted_at = COALESCE(delisted_at, ?) WHERE id = ?`, []any{now, from}},
+	}
+	for _, s := range steps {
+		if _, err := tx.ExecContext(ctx, s.query, s.args...); err != nil {
+			return fmt.Errorf("%s of %s: %w", s.what, from, err)
+		}
+	}
+	return nil
+}
+
+func scanReview(s scanner) (*MatchReview, error) {
+	var (
+		rv       MatchReview
+		resolved sql.NullTime
+	)
+	if err := s.Scan(&rv.ID, &rv.Source, &rv.SourceID, &rv.MangaID, &rv.Title, &rv.Author, &rv.Year,
+		&rv.CandidateID, &rv.CandidateTitle, &rv.Score, &rv.Status, &rv.CreatedAt, &resolved, &rv.ResolvedBy); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan match review: %w", err)
+	}
+	if resolved.Valid {
+		rv.ResolvedAt = &resolved.Time
+	}
+	return &rv, nil
+}
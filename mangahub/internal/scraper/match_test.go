@@ -0,0 +1,80 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+
+	"mangahub/pkg/models"
+)
+
+func TestAggregatorMatchesAcrossSources(t *testing.T) {
+	agg := NewAggregator(
+		stubSource{name: "a", items: []models.MangaCanonical{
+			{ID: "a1", Title: "Shingeki no Kyojin", AltTitles: []string{"Attack on Titan"}, Author: "Isayama Hajime", Year: 2009, SourceIDs: map[string]string{"a": "a1"}},
+			{ID: "a2", Title: "One Punch-Man", Author: "ONE", Year: 2012, SourceIDs: map[string]string{"a": "a2"}},
+			{ID: "a3", Title: "Monster", Author: "Urasawa Naoki", SourceIDs: map[string]string{"a": "a3"}},
+		}},
+		stubSource{name: "b", items: []models.MangaCanonical{
+			// alt title, reordered author name
+			{ID: "b1", Title: "Attack on Titan", Author: "Hajime Isayama", Year: 2009, SourceIDs: map[string]string{"b": "b1"}},
+			// similar title, same year, no author: ask
+			{ID: "b2", Title: "One Punch Man Remake", Year: 2012, SourceIDs: map[string]string{"b": "b2"}},
+			// linked by an earlier run despite the new title
+			{ID: "b3", Title: "Monster (Kanzenban)", SourceIDs: map[string]string{"b": "b3"}},
+			// same id as a source-a title, unrelated work
+			{ID: "a2", Title: "Vagabond", SourceIDs: map[string]string{"b": "b4"}},
+		}},
+	)
+	agg.State = MatchState{Links: map[string]string{linkKey("b", "b3"): "a3"}}
+
+	got, report, err := agg.FetchAndMerge(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := map[string]models.MangaCanonical{}
+	for _, m := range got {
+		byID[m.ID] = m
+	}
+	if len(got) != 5 {
+		t.Fatalf("merged %d titles, want 5: %+v", len(got), got)
+	}
+	if byID["a1"].SourceIDs["b"] != "b1" {
+		t.Errorf("alt title not matched: %+v", byID["a1"])
+	}
+	if byID["a3"].SourceIDs["b"] != "b3" {
+		t.Errorf("stored link not followed: %+v", byID["a3"])
+	}
+	if _, ok := byID["b:a2"]; !ok {
+		t.Errorf("colliding id not prefixed: %v", byID)
+	}
+
+	if len(report.Candidates) != 1 {
+		t.Fatalf("candidates = %+v", report.Candidates)
+	}
+	c := report.Candidates[0]
+	if c.SourceID != "b2" || c.MangaID != "b2" || c.CandidateID != "a2" {
+		t.Errorf("candidate = %+v", c)
+	}
+
+	// once rejected, the pair is no longer proposed
+	agg.State.Distinct = map[string]bool{distinctKey("b", "b2", "a2"): true}
+	if _, report, _ = agg.FetchAndMerge(context.Background()); len(report.Candidates) != 0 {
+		t.Errorf("rejected pair proposed again: %+v", report.Candidates)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"berserk", "berserk", 1, 1},
+		{"one punch man", "onepunch man", 1, 1},
+		{"attack on titan", "attack on titan season 2", 0.7, 0.9},
+		{"naruto", "bleach", 0, 0.1},
+	} {
+		if s := similarity(tc.a, tc.b); s < tc.min || s > tc.max {
+			t.Errorf("similarity(%q, %q) = %.2f, want %.2f..%.2f", tc.a, tc.b, s, tc.min, tc.max)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package scraper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"mangahub/pkg/models"
+)
+
+// Merge strategies for MergeConfig fields.
+const (
+	StrategyPriority  = "priority"  // value from the highest-priority source that has one
+	StrategyLongest   = "longest"   // longest value; ties keep the higher-priority one
+	StrategyMax       = "max"       // largest number
+	StrategyUnion     = "union"     // every distinct value
+	StrategyCompleted = "completed" // "completed" from any source, else priority
+)
+
+// Mergeable fields, named as in the manga JSON.
+const (
+	FieldTitle         = "title"
+	FieldAltTitles     = "alt_titles"
+	FieldAuthor        = "author"
+	FieldGenres        = "genres"
+	FieldStatus        = "status"
+	FieldTotalChapters = "total_chapters"
+	FieldDescription   = "description"
+	FieldCoverURL      = "cover_url"
+	FieldYear          = "year"
+)
+
+// fieldStrategies lists the strategies each field accepts; the first is
+// its default.
+var fieldStrategies = map[string][]string{
+	FieldTitle:         {StrategyPriority},
+	FieldAltTitles:     {StrategyUnion, StrategyPriority},
+	FieldAuthor:        {StrategyPriority, StrategyLongest},
+	FieldGenres:        {StrategyUnion, StrategyPriority},
+	FieldStatus:        {StrategyCompleted, StrategyPriority},
+	FieldTotalChapters: {StrategyMax, StrategyPriority},
+	FieldDescription:   {StrategyLongest, StrategyPriority},
+	FieldCoverURL:      {StrategyPriority, StrategyLongest},
+	FieldYear:          {StrategyPriority, StrategyMax},
+}
+
+// MergeConfig picks how each field is merged when several sources describe
+// the same title:
+//
+//	merge:
+//	  fields:
+//	    description: {strategy: priority, sources: [mangadex]}
+//	    cover_url: {sources: [source_b, mangadex]}
+//
+// Fields left out keep their defaults, which match the historical rules.
+type MergeConfig struct {
+	Fields map[string]FieldRule `yaml:"fields" json:"fields"`
+}
+
+// FieldRule is one field's strategy and source priority. Sources listed
+// come first, in order; the rest follow in run order (source priority).
+type FieldRule struct {
+	Strategy string   `yaml:"strategy" json:"strategy"`
+	Sources  []string `yaml:"sources" json:"sources"`
+}
+
+// Validate rejects unknown fields and strategies a field does not support.
+func (c MergeConfig) Validate() error {
+	for field, rule := range c.Fields {
+		allowed, ok := fieldStrategies[field]
+		if !ok {
+			return fmt.Errorf("scraper config: merge: unknown field %q", field)
+		}
+		if rule.Strategy != "" && !containsString(allowed, rule.Strategy) {
+			return fmt.Errorf("scraper config: merge: %s does not support strategy %q (use %s)",
+				field, rule.Strategy, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, v string) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// merger applies a MergeConfig and tracks which source each field came
+// from in MangaCanonical.Provenance.
+type merger struct {
+	rules map[string]FieldRule
+	order map[string]int // source name -> position in the run
+}
+
+func newMerger(cfg MergeConfig, sources []string) *merger {
+	mg := &merger{rules: make(map[string]FieldRule, len(fieldStrategies)), order: make(map[string]int, len(sources))}
+	for field, allowed := range fieldStrategies {
+		rule := cfg.Fields[field]
+		if rule.Strategy == "" {
+			rule.Strategy = allowed[0]
+		}
+		mg.rules[field] = rule
+	}
+	for i, s := range sources {
+		mg.order[s] = i
+	}
+	return mg
+}
+
+// rank orders sources for field: lower is preferred.
+func (mg *merger) rank(field, source string) int {
+	listed := mg.rules[field].Sources
+	for i, s := range listed {
+		if s == source {
+			return i
+		}
+	}
+	if i, ok := mg.order[source]; ok {
+		return len(listed) + i
+	}
+	return len(listed) + len(mg.order)
+}
+
+// prefers reports whether incoming outranks the source that set field on m.
+func (mg *merger) prefers(field, incoming string, m models.MangaCanonical) bool {
+	return mg.rank(field, incoming) < mg.rank(field, m.Provenance[field])
+}
+
+// seed records source as the origin of every field m has a value for.
+func (mg *merger) seed(m models.MangaCanonical, source string) models.MangaCanonical {
+	prov := make(map[string]string, len(fieldStrategies))
+	for field, set := range fieldsSet(m) {
+		if set {
+			prov[field] = source
+		}
+	}
+	m.Provenance = prov
+	return m
+}
+
+func fieldsSet(m models.MangaCanonical) map[string]bool {
+	return map[string]bool{
+		FieldTitle:         m.Title != "",
+		FieldAltTitles:     len(m.AltTitles) > 0,
+		FieldAuthor:        m.Author != "",
+		FieldGenres:        len(m.Genres) > 0,
+		FieldStatus:        m.Status != "",
+		FieldTotalChapters: m.TotalChapters > 0,
+		FieldDescription:   m.Description != "",
+		FieldCoverURL:      m.CoverURL != "",
+		FieldYear:          m.Year > 0,
+	}
+}
+
+// merge folds incoming, fetched from source, into base field by field. A
+// title that loses is kept as an alt title.
+func (mg *merger) merge(base, incoming models.MangaCanonical, source string) models.MangaCanonical {
+	prov := make(map[string]string, len(base.Provenance))
+	for k, v := range base.Provenance {
+		prov[k] = v
+	}
+	base.Provenance = prov
+
+	// Title: only priority makes sense, the loser becomes an alt title
+	if incoming.Title != "" && incoming.Title != base.Title {
+		if base.Title == "" || mg.prefers(FieldTitle, source, base) {
+			if base.Title != "" {
+				incoming.AltTitles = append([]string{base.Title}, incoming.AltTitles...)
+			}
+			base.Title, prov[FieldTitle] = incoming.Title, source
+		} else {
+			incoming.AltTitles = append([]string{incoming.Title}, incoming.AltTitles...)
+		}
+	}
+
+	alts := mg.mergeList(FieldAltTitles, base, base.AltTitles, incoming.AltTitles, source)
+	base.AltTitles = make([]string, 0, len(alts))
+	for _, t := range alts {
+		if t != base.Title {
+			base.AltTitles = append(base.AltTitles, t)
+		}
+	}
+	base.Genres = mg.mergeList(FieldGenres, base, base.Genres, incoming.Genres, source)
+
+	base.Author = mg.mergeString(FieldAuthor, base, base.Author, incoming.Author, source)
+	base.Description = mg.mergeString(FieldDescription, base, base.Description, incoming.Description, source)
+	base.CoverURL = mg.mergeString(FieldCoverURL, base, base.CoverURL, incoming.CoverURL, source)
+
+	// Status: "completed" from anyone is trusted over "ongoing" from others
+	status := strings.ToLower(strings.TrimSpace(incoming.Status))
+	base.Status = strings.ToLower(strings.TrimSpace(base.Status))
+	if mg.rules[FieldStatus].Strategy == StrategyCompleted && (base.Status == "completed") != (status == "completed") {
+		if status == "completed" {
+			base.Status, prov[FieldStatus] = status, source
+		}
+	} else {
+		base.Status = mg.mergeString(FieldStatus, base, base.Status, status, source)
+	}
+
+	base.TotalChapters = mg.mergeInt(FieldTotalChapters, base, base.TotalChapters, incoming.TotalChapters, source)
+	base.Year = mg.mergeInt(FieldYear, base, base.Year, incoming.Year, source)
+
+	if base.SourceIDs == nil {
+		base.SourceIDs = make(map[string]string)
+	}
+	for k, v := range incoming.SourceIDs {
+		base.SourceIDs[k] = v
+	}
+	return base
+}
+
+// mergeString resolves a text field under priority or longest.
+func (mg *merger) mergeString(field string, base models.MangaCanonical, cur, in, source string) string {
+	if in == "" || in == cur {
+		return cur
+	}
+	take := cur == ""
+	if !take {
+		switch mg.rules[field].Strategy {
+		case StrategyLongest:
+			take = len(in) > len(cur) || (len(in) == len(cur) && mg.prefers(field, source, base))
+		default:
+			take = mg.prefers(field, source, base)
+		}
+	}
+	if take {
+		base.Provenance[field] = source
+		return in
+	}
+	return cur
+}
+
+// mergeInt resolves a number under priority or max; zero means unknown.
+func (mg *merger) mergeInt(field string, base models.MangaCanonical, cur, in int, source string) int {
+	if in <= 0 || in == cur {
+		return cur
+	}
+	take := cur <= 0
+	if !take {
+		if mg.rules[field].Strategy == StrategyMax {
+			take = in > cur
+		} else {
+			take = mg.prefers(field, source, base)
+		}
+	}
+	if take {
+		base.Provenance[field] = source
+		return in
+	}
+	return cur
+}
+
+// mergeList resolves a list under union or priority. Under union the
+// provenance lists every contributing source, comma separated, in
+// priority order.
+func (mg *merger) mergeList(field string, base models.MangaCanonical, cur, in []string, source string) []string {
+	if len(in) == 0 {
+		return cur
+	}
+	if mg.rules[field].Strategy == StrategyPriority {
+		if len(cur) == 0 || mg.prefers(field, source, base) {
+			base.Provenance[field] = source
+			return in
+		}
+		return cur
+	}
+
+	out := append([]string(nil), cur...)
+	added := false
+	for _, v := range in {
+		if !containsString(out, v) {
+			out = append(out, v)
+			added = true
+		}
+	}
+	if added || len(cur) == 0 {
+		sources := strings.Split(base.Provenance[field], ",")
+		if base.Provenance[field] == "" {
+			sources = nil
+		}
+		if !containsString(sources, source) {
+			sources = append(sources, source)
+		}
+		sort.SliceStable(sources, func(i, j int) bool { return mg.rank(field, sources[i]) < mg.rank(field, sources[j]) })
+		base.Provenance[field] = strings.Join(sources, ",")
+	}
+	return out
+}
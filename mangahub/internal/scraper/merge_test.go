@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"testing"
+
+	"mangahub/pkg/models"
+)
+
+func TestMergerDefaultsAndProvenance(t *testing.T) {
+	mg := newMerger(MergeConfig{}, []string{"a", "b"})
+	base := mg.seed(models.MangaCanonical{
+		ID: "x", Title: "Berserk", Genres: []string{"Action"}, Status: "ongoing",
+		TotalChapters: 300, Description: "short", SourceIDs: map[string]string{"a": "x"},
+	}, "a")
+
+	got := mg.merge(base, models.MangaCanonical{
+		Title: "BERSERK", Author: "Miura Kentarou", Genres: []string{"Action", "Dark Fantasy"},
+		Status: "Completed", TotalChapters: 364, Description: "a much longer description",
+		CoverURL: "b.jpg", SourceIDs: map[string]string{"b": "y"},
+	}, "b")
+
+	if got.Title != "Berserk" || len(got.AltTitles) != 1 || got.AltTitles[0] != "BERSERK" {
+		t.Errorf("title = %q, alt = %v", got.Title, got.AltTitles)
+	}
+	if got.Status != "completed" || got.TotalChapters != 364 || got.Description != "a much longer description" {
+		t.Errorf("merged = %+v", got)
+	}
+	want := map[string]string{
+		FieldTitle:         "a",
+		FieldAltTitles:     "b",
+		FieldAuthor:        "b",
+		FieldGenres:        "a,b",
+		FieldStatus:        "b",
+		FieldTotalChapters: "b",
+		FieldDescription:   "b",
+		FieldCoverURL:      "b",
+	}
+	for field, src := range want {
+		if got.Provenance[field] != src {
+			t.Errorf("provenance[%s] = %q, want %q", field, got.Provenance[field], src)
+		}
+	}
+	if base.Provenance[FieldDescription] != "a" {
+		t.Error("merge changed the base entry's provenance")
+	}
+}
+
+func TestMergerFieldRules(t *testing.T) {
+	cfg := MergeConfig{Fields: map[string]FieldRule{
+		FieldTitle:       {Sources: []string{"b"}},
+		FieldDescription: {Strategy: StrategyPriority},
+		FieldStatus:      {Strategy: StrategyPriority},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	mg := newMerger(cfg, []string{"a", "b"})
+	base := mg.seed(models.MangaCanonical{Title: "Shingeki no Kyojin", Status: "ongoing", Description: "short"}, "a")
+
+	got := mg.merge(base, models.MangaCanonical{Title: "Attack on Titan", Status: "completed", Description: "much longer"}, "b")
+	if got.Title != "Attack on Titan" || got.Provenance[FieldTitle] != "b" || got.AltTitles[0] != "Shingeki no Kyojin" {
+		t.Errorf("title = %q from %q, alt = %v", got.Title, got.Provenance[FieldTitle], got.AltTitles)
+	}
+	if got.Description != "short" || got.Status != "ongoing" {
+		t.Errorf("priority fields = %q, %q", got.Description, got.Status)
+	}
+
+	bad := MergeConfig{Fields: map[string]FieldRule{FieldTotalChapters: {Strategy: StrategyLongest}}}
+	if err := bad.Validate(); err == nil {
+		t.Error("longest accepted for total_chapters")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"mangahub/pkg/models"
+	"time"
 )
 
 // SaveToDatabase upserts the given slice of MangaCanonical into the
@@ -22,13 +23,28 @@ import (
 //	);
 //
 // This function assumes you may have added "cover_url" as an extra column.
-func SaveToDatabase(ctx context.Context, db *sql.DB, mangas []models.MangaCanonical) error {
+// Rows that already hold the same values are left alone and counted as
+// unchanged. Fields an admin locked keep their stored value. Each entry's
+// SourceIDs are recorded in manga_sources, and the source of each stored
+// field in manga_provenance.
+func SaveToDatabase(ctx context.Context, db *sql.DB, mangas []models.MangaCanonical) (SaveStats, error) {
+	var stats SaveStats
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		return stats, fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback()
 
+	current, err := tx.PrepareContext(ctx, `
+		SELECT title, COALESCE(author, ''), COALESCE(genres, ''), COALESCE(status, ''),
+		       COALESCE(total_chapters, 0), COALESCE(description, ''), COALESCE(cover_url, '')
+		FROM manga WHERE id = ?
+	`)
+	if err != nil {
+		return stats, fmt.Errorf("prepare lookup: %w", err)
+	}
+	defer current.Close()
+
 	stmt, err := tx.PrepareContext(ctx, `
 		INSERT INTO manga (id, title, author, genres, status, total_chapters, description, cover_url)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
@@ -42,14 +58,45 @@ func SaveToDatabase(ctx context.Context, db *sql.DB, mangas []models.MangaCanoni
 		  cover_url = excluded.cover_url
 	`)
 	if err != nil {
-		return fmt.Errorf("prepare stmt: %w", err)
+		return stats, fmt.Errorf("prepare stmt: %w", err)
 	}
 	defer stmt.Close()
 
-	for _, m := range mangas {
+	locks, err := loadLocks(ctx, tx)
+	if err != nil {
+		return stats, err
+	}
+	// locked fields keep their stored value, so work on a copy
+	mangas = append([]models.MangaCanonical(nil), mangas...)
+
+	for i, m := range mangas {
 		genresJSON, err := json.Marshal(m.Genres)
 		if err != nil {
-			return fmt.Errorf("marshal genres for %s: %w", m.ID, err)
+			return stats, fmt.Errorf("marshal genres for %s: %w", m.ID, err)
+		}
+
+		var old storedRow
+		err = current.QueryRowContext(ctx, m.ID).Scan(&old.title, &old.author, &old.genres,
+			&old.status, &old.chapters, &old.description, &old.cover)
+		if err == nil && len(locks[m.ID]) > 0 {
+			m = keepLocked(m, locks[m.ID], old)
+			mangas[i] = m
+			if locks[m.ID][FieldGenres] {
+				genresJSON = []byte(old.genres)
+			}
+		}
+		switch {
+		case err == sql.ErrNoRows:
+			stats.New++
+		case err != nil:
+			return stats, fmt.Errorf("lookup %s: %w", m.ID, err)
+		case old.title == m.Title && old.author == m.Author && old.genres == string(genresJSON) &&
+			old.status == m.Status && old.chapters == m.TotalChapters &&
+			old.description == m.Description && old.cover == m.CoverURL:
+			stats.Unchanged++
+			continue
+		default:
+			stats.Updated++
 		}
 
 		if _, err := stmt.ExecContext(
@@ -63,12 +110,164 @@ func SaveToDatabase(ctx context.Context, db *sql.DB, mangas []models.MangaCanoni
 			m.Description,
 			m.CoverURL,
 		); err != nil {
-			return fmt.Errorf("exec upsert for %s: %w", m.ID, err)
+			return stats, fmt.Errorf("exec upsert for %s: %w", m.ID, err)
+		}
+	}
+
+	// remember where each source entry went, so the next run's matcher
+	// can keep it with the same title
+	link, err := tx.PrepareContext(ctx, `
+		INSERT INTO manga_sources (source, source_id, manga_id) VALUES (?, ?, ?)
+		ON CONFLICT(source, source_id) DO UPDATE SET manga_id = excluded.manga_id
+	`)
+	if err != nil {
+		return stats, fmt.Errorf("prepare link: %w", err)
+	}
+	defer link.Close()
+	for _, m := range mangas {
+		for source, id := range m.SourceIDs {
+			if _, err := link.ExecContext(ctx, source, id, m.ID); err != nil {
+				return stats, fmt.Errorf("link %s/%s to %s: %w", source, id, m.ID, err)
+			}
 		}
 	}
 
+	if err := saveProvenance(ctx, tx, mangas); err != nil {
+		return stats, err
+	}
+
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit tx: %w", err)
+		return stats, fmt.Errorf("commit tx: %w", err)
+	}
+	return stats, nil
+}
+
+// SaveStats counts what SaveToDatabase did with each title.
+type SaveStats struct {
+	New       int
+	Updated   int
+	Unchanged int
+}
+
+// loadLocks returns the fields admins locked, by manga id.
+func loadLocks(ctx context.Context, q querier) (map[string]map[string]bool, error) {
+	rows, err := q.QueryContext(ctx, `SELECT manga_id, field FROM manga_locks`)
+	if err != nil {
+		return nil, fmt.Errorf("load locks: %w", err)
+	}
+	defer rows.Close()
+	locks := make(map[string]map[string]bool)
+	for rows.Next() {
+		var id, field string
+		if err := rows.Scan(&id, &field); err != nil {
+			return nil, fmt.Errorf("scan lock: %w", err)
+		}
+		if locks[id] == nil {
+			locks[id] = make(map[string]bool)
+		}
+		locks[id][field] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load locks: %w", err)
+	}
+	return locks, nil
+}
+
+// storedRow is a manga row as SaveToDatabase reads it back.
+type storedRow struct {
+	title, author, genres, status, description, cover string
+	chapters                                          int
+	delisted                                          bool // read by loadStored only
+}
+
+// keepLocked puts the stored values of locked fields back into m and
+// marks them as set by hand.
+func keepLocked(m models.MangaCanonical, locked map[string]bool, old storedRow) models.MangaCanonical {
+	prov := make(map[string]string, len(m.Provenance))
+	for k, v := range m.Provenance {
+		prov[k] = v
+	}
+	m.Provenance = prov
+	for field := range locked {
+		switch field {
+		case FieldTitle:
+			m.Title = old.title
+		case FieldAuthor:
+			m.Author = old.author
+		case FieldGenres:
+			m.Genres = nil
+			_ = json.Unmarshal([]byte(old.genres), &m.Genres)
+		case FieldStatus:
+			m.Status = old.status
+		case FieldTotalChapters:
+			m.TotalChapters = old.chapters
+		case FieldDescription:
+			m.Description = old.description
+		case FieldCoverURL:
+			m.CoverURL = old.cover
+		default:
+			continue
+		}
+		prov[field] = models.ManualSource
+	}
+	return m
+}
+
+// storedFields are the merged fields the manga table keeps, with their
+// values as recorded in manga_provenance.
+func storedFields(m models.MangaCanonical) map[string]any {
+	return map[string]any{
+		FieldTitle:         m.Title,
+		FieldAuthor:        m.Author,
+		FieldGenres:        m.Genres,
+		FieldStatus:        m.Status,
+		FieldTotalChapters: m.TotalChapters,
+		FieldDescription:   m.Description,
+		FieldCoverURL:      m.CoverURL,
+	}
+}
+
+// saveProvenance records which source each stored field came from. A
+// field's updated_at moves only when its source or value changes, so it
+// says since when the current value has been there.
+func saveProvenance(ctx context.Context, tx *sql.Tx, mangas []models.MangaCanonical) error {
+	upsert, err := tx.PrepareContext(ctx, `
+		INSERT INTO manga_provenance (manga_id, field, source, value, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(manga_id, field) DO UPDATE SET
+		  source = excluded.source,
+		  value = excluded.value,
+		  updated_at = excluded.updated_at
+		WHERE manga_provenance.source != excluded.source OR manga_provenance.value != excluded.value
+	`)
+	if err != nil {
+		return fmt.Errorf("prepare provenance: %w", err)
+	}
+	defer upsert.Close()
+	drop, err := tx.PrepareContext(ctx, `DELETE FROM manga_provenance WHERE manga_id = ? AND field = ?`)
+	if err != nil {
+		return fmt.Errorf("prepare provenance: %w", err)
+	}
+	defer drop.Close()
+
+	now := time.Now().UTC()
+	for _, m := range mangas {
+		for field, v := range storedFields(m) {
+			source, ok := m.Provenance[field]
+			if !ok {
+				if _, err := drop.ExecContext(ctx, m.ID, field); err != nil {
+					return fmt.Errorf("clear provenance %s.%s: %w", m.ID, field, err)
+				}
+				continue
+			}
+			value, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("marshal %s.%s: %w", m.ID, field, err)
+			}
+			if _, err := upsert.ExecContext(ctx, m.ID, field, source, string(value), now); err != nil {
+				return fmt.Errorf("save provenance %s.%s: %w", m.ID, field, err)
+			}
+		}
 	}
 	return nil
 }
@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory builds a source from its config entry. Name, Type and Limits are
+// already validated; the factory applies whatever else it understands.
+type Factory func(cfg SourceConfig) (Source, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a source type available to configs. It panics on an empty
+// or duplicate type, like http.Handle, since both are programming errors.
+func Register(typ string, f Factory) {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if typ == "" || f == nil {
+		panic("scraper: Register needs a type and a factory")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[typ]; dup {
+		panic("scraper: source type registered twice: " + typ)
+	}
+	registry[typ] = f
+}
+
+// Types lists the registered source types in sorted order.
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]string, 0, len(registry))
+	for t := range registry {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func lookup(typ string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	f, ok := registry[typ]
+	return f, ok
+}
+
+func init() {
+	Register("mangadex", func(cfg SourceConfig) (Source, error) {
+		s := NewSourceA()
+		s.ID = cfg.Name
+		s.Fetcher = cfg.Fetcher(s.Fetcher.Client.Timeout)
+		if cfg.BaseURL != "" {
+			s.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+		}
+		if cfg.Limits.PageSize > 0 {
+			s.Limit = cfg.Limits.PageSize
+		}
+		if cfg.Limits.MaxItems > 0 {
+			s.Max = cfg.Limits.MaxItems
+		}
+		return s, nil
+	})
+	Register("mirror", func(cfg SourceConfig) (Source, error) {
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("source %s: base_url is required", cfg.Name)
+		}
+		s := NewSourceB(strings.TrimRight(cfg.BaseURL, "/"))
+		s.ID = cfg.Name
+		s.Fetcher = cfg.Fetcher(s.Fetcher.Client.Timeout)
+		s.Max = cfg.Limits.MaxItems
+		return s, nil
+	})
+}
+
+// Build instantiates the enabled sources in cfg, highest priority first.
+func Build(cfg Config) ([]Source, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	enabled := make([]SourceConfig, 0, len(cfg.Sources))
+	for _, sc := range cfg.Sources {
+		if sc.IsEnabled() {
+			enabled = append(enabled, sc)
+		}
+	}
+	sort.SliceStable(enabled, func(i, j int) bool {
+		return enabled[i].Priority > enabled[j].Priority
+	})
+
+	shared := newFetchShared(cfg.Fetch)
+	sources := make([]Source, 0, len(enabled))
+	for _, sc := range enabled {
+		sc.shared = shared
+		f, _ := lookup(sc.Type)
+		src, err := f(sc)
+		if err != nil {
+			return nil, fmt.Errorf("scraper config: %w", err)
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
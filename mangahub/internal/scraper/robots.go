@@ -0,0 +1,170 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robots holds the robots.txt rules that apply to one user agent on one
+// host. A nil *robots allows everything.
+type robots struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	length  int // longer patterns win, as in RFC 9309
+	pattern *regexp.Regexp
+}
+
+// fetchRobots reads robots.txt from the root of base. A missing file (4xx)
+// allows everything; a server error or unreachable host is returned as an
+// error, since RFC 9309 says to assume everything is disallowed then.
+func fetchRobots(ctx context.Context, f *Fetcher, base *url.URL, userAgent string) (*robots, error) {
+	u := url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/robots.txt"}
+	resp, err := f.Get(ctx, u.String(), http.Header{"User-Agent": {userAgent}})
+	if err != nil {
+		var se *StatusError
+		if errors.As(err, &se) && se.Code >= 400 && se.Code < 500 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("robots.txt: %w", err)
+	}
+	return parseRobots(io.LimitReader(bytes.NewReader(resp.Body), 512<<10), userAgent), nil
+}
+
+// parseRobots keeps the group for the most specific user agent that
+// matches userAgent, falling back to "*".
+func parseRobots(r io.Reader, userAgent string) *robots {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	type group struct {
+		agents []string
+		rb     robots
+	}
+	var groups []*group
+	var cur *group
+	inAgents := false
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				cur = &group{}
+				groups = append(groups, cur)
+			}
+			cur.agents = append(cur.agents, strings.ToLower(val))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if cur == nil || val == "" {
+				continue
+			}
+			cur.rb.rules = append(cur.rb.rules, robotsRule{
+				allow:   key == "allow",
+				length:  len(val),
+				pattern: robotsPattern(val),
+			})
+		case "crawl-delay":
+			inAgents = false
+			if cur == nil {
+				continue
+			}
+			if secs, err := strconv.ParseFloat(val, 64); err == nil && secs > 0 {
+				cur.rb.crawlDelay = time.Duration(secs * float64(time.Second))
+			}
+		}
+	}
+
+	var best *group
+	bestLen := -1
+	for _, g := range groups {
+		for _, a := range g.agents {
+			switch {
+			case a == "*" && bestLen < 0:
+				best, bestLen = g, 0
+			case a != "*" && strings.Contains(token, a) && len(a) > bestLen:
+				best, bestLen = g, len(a)
+			}
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return &best.rb
+}
+
+// robotsPattern compiles a path pattern with * wildcards and a $ anchor.
+func robotsPattern(p string) *regexp.Regexp {
+	anchored := strings.HasSuffix(p, "$")
+	p = strings.TrimSuffix(p, "$")
+	parts := strings.Split(p, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// Allowed reports whether u may be fetched.
+func (r *robots) Allowed(u *url.URL) bool {
+	if r == nil {
+		return true
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	allowed, bestLen := true, -1
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		// on equal length the least restrictive rule wins
+		if rule.length > bestLen || (rule.length == bestLen && rule.allow) {
+			allowed, bestLen = rule.allow, rule.length
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay is the delay robots.txt asks for, or 0.
+func (r *robots) CrawlDelay() time.Duration {
+	if r == nil {
+		return 0
+	}
+	return r.crawlDelay
+}
@@ -0,0 +1,323 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+// Run triggers, recorded in scrape_runs.
+const (
+	TriggerCLI      = "cli"
+	TriggerSchedule = "schedule"
+	TriggerAdmin    = "admin"
+)
+
+// RunOptions narrows a Run.
+type RunOptions struct {
+	Trigger string
+	// Sources are fetched live; every other source contributes its last
+	// snapshot, so the merge still sees all of them. Empty fetches all.
+	Sources []string
+	// Holder names this process in the lease; DefaultHolder when empty.
+	Holder string
+}
+
+// Run does one full scrape under the scrape lease: fetch, merge, save,
+// queue match reviews, record the run and mirror new covers. It returns ErrRunInProgress if
+// another process holds the lease. Once a run has started, its outcome is
+// in the report and the error is nil.
+func Run(ctx context.Context, db *sql.DB, cfg Config, opts RunOptions) (*RunReport, error) {
+	sources, live, err := buildSources(cfg, opts.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Holder == "" {
+		opts.Holder = DefaultHolder()
+	}
+	repo := NewRepo(db)
+	release, err := repo.acquireLease(ctx, opts.Holder, opts.Trigger)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	mangas, report, err := fetchAndMerge(ctx, repo, cfg, sources, live)
+	if report == nil {
+		return nil, err
+	}
+	report.Trigger = opts.Trigger
+	repo.save(ctx, report, mangas, report.Candidates, err, true, cfg.Delist)
+	if report.Status != RunFailed {
+		mirrorCovers(ctx, db, cfg)
+	}
+	return report, nil
+}
+
+// Plan is a merge that has not been saved, with how it differs from the
+// manga table (see Preview).
+type Plan struct {
+	Report *RunReport `json:"report"`
+	Diff   *Diff      `json:"diff"` // nil when the merge failed
+
+	cfg    Config
+	opts   RunOptions
+	mangas []models.MangaCanonical
+}
+
+// Preview fetches and merges like Run and diffs the result against the
+// manga table without writing anything; it does not need the lease. When
+// the merge fails, the plan holds the report and the error is set.
+func Preview(ctx context.Context, db *sql.DB, cfg Config, opts RunOptions) (*Plan, error) {
+	sources, live, err := buildSources(cfg, opts.Sources)
+	if err != nil {
+		return nil, err
+	}
+	repo := NewRepo(db)
+	mangas, report, err := fetchAndMerge(ctx, repo, cfg, sources, live)
+	if report == nil {
+		return nil, err
+	}
+	report.Trigger = opts.Trigger
+	plan := &Plan{Report: report, cfg: cfg, opts: opts, mangas: mangas}
+	if err != nil {
+		report.Finish(SaveStats{}, err)
+		return plan, err
+	}
+	if plan.Diff, err = repo.Diff(ctx, mangas); err != nil {
+		return nil, err
+	}
+	// counts what a full Apply would save; Apply finishes the report again
+	report.Finish(SaveStats{New: len(plan.Diff.Added), Updated: len(plan.Diff.Changed), Unchanged: plan.Diff.Unchanged}, nil)
+	return plan, nil
+}
+
+// Apply saves the added and changed titles with the given ids, or all of
+// them when ids is empty, under the scrape lease, records the run and
+// mirrors new covers. Removed titles cannot be applied: saves never delete
+// rows. Snapshots are kept and missed titles counted toward delisting
+// only when everything is applied, as after a full Run.
+func (p *Plan) Apply(ctx context.Context, db *sql.DB, ids []string) (*RunReport, error) {
+	if p.Diff == nil {
+		return nil, fmt.Errorf("nothing to apply: %s", p.Report.Error)
+	}
+	kinds := make(map[string]string)
+	for _, list := range [][]TitleDiff{p.Diff.Added, p.Diff.Changed, p.Diff.Removed} {
+		for _, t := range list {
+			kinds[t.ID] = t.Kind
+		}
+	}
+	pick := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		switch kinds[id] {
+		case DiffAdded, DiffChanged:
+			pick[id] = true
+		case DiffRemoved:
+			return nil, fmt.Errorf("%s: removed titles are not deleted", id)
+		default:
+			return nil, fmt.Errorf("%s: no change to apply", id)
+		}
+	}
+	all := len(ids) == 0
+
+	var mangas []models.MangaCanonical
+	for _, m := range p.mangas {
+		if all || pick[m.ID] {
+			mangas = append(mangas, m)
+		}
+	}
+	// a review needs both titles stored
+	var candidates []MatchCandidate
+	for _, c := range p.Report.Candidates {
+		if all || (kinds[c.MangaID] != DiffAdded || pick[c.MangaID]) && (kinds[c.CandidateID] != DiffAdded || pick[c.CandidateID]) {
+			candidates = append(candidates, c)
+		}
+	}
+
+	holder := p.opts.Holder
+	if holder == "" {
+		holder = DefaultHolder()
+	}
+	repo := NewRepo(db)
+	release, err := repo.acquireLease(ctx, holder, p.opts.Trigger)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	report := *p.Report
+	report.ID, report.Error = 0, ""
+	repo.save(ctx, &report, mangas, candidates, nil, all, p.cfg.Delist)
+	if report.Status != RunFailed {
+		mirrorCovers(ctx, db, p.cfg)
+	}
+	return &report, nil
+}
+
+// buildSources builds cfg's sources and checks that the ones to fetch
+// live exist.
+func buildSources(cfg Config, names []string) ([]Source, map[string]bool, error) {
+	sources, err := Build(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(sources) == 0 {
+		return nil, nil, fmt.Errorf("no scraper sources enabled")
+	}
+	live := make(map[string]bool, len(names))
+	for _, name := range names {
+		live[name] = true
+	}
+	for name := range live {
+		if !hasSource(sources, name) {
+			return nil, nil, fmt.Errorf("unknown or disabled source %q", name)
+		}
+	}
+	return sources, live, nil
+}
+
+// fetchAndMerge runs the aggregator, with the sources not in live (when
+// set) replaced by their snapshots. When the merge itself fails, the
+// report is returned along with the error so the caller can record it.
+func fetchAndMerge(ctx context.Context, repo *Repo, cfg Config, sources []Source, live map[string]bool) ([]models.MangaCanonical, *RunReport, error) {
+	if len(live) > 0 {
+		for i, src := range sources {
+			if live[src.Name()] {
+				continue
+			}
+			snap, err := repo.loadSnapshot(ctx, src.Name())
+			if err != nil {
+				return nil, nil, err
+			}
+			if snap != nil {
+				sources[i] = snap
+			} // no snapshot yet: fetch it live
+		}
+	}
+
+	state, err := repo.LoadMatchState(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load match state: %w", err)
+	}
+
+	agg := NewAggregator(sources...)
+	agg.Deadlines = cfg.Deadlines()
+	agg.Match = cfg.Match
+	agg.State = state
+	agg.Merge = cfg.Merge
+
+	return agg.FetchAndMerge(ctx)
+}
+
+// save stores mangas and queues candidates unless err is already set,
+// then finishes and records the run. When full is set, the save stands
+// for everything the live sources returned: once it went through, their
+// results are kept as snapshots and titles they missed are counted toward
+// delisting.
+func (r *Repo) save(ctx context.Context, report *RunReport, mangas []models.MangaCanonical, candidates []MatchCandidate, err error, full bool, delist DelistConfig) {
+	var stats SaveStats
+	if err == nil {
+		log.Printf("merged mangas: %d", len(mangas))
+		stats, err = SaveToDatabase(ctx, r.DB, mangas)
+		if err != nil {
+			err = fmt.Errorf("save failed: %w", err)
+		}
+	}
+	if err == nil {
+		// candidates point at rows that exist only once the save went through
+		if report.Queued, err = r.QueueReviews(ctx, candidates); err != nil {
+			err = fmt.Errorf("queue match reviews: %w", err)
+		}
+	}
+	report.Finish(stats, err)
+
+	if err == nil && full {
+		if err := r.trackListings(ctx, report, delist); err != nil {
+			log.Printf("track listings failed: %v", err)
+		}
+		for name, items := range report.fetched {
+			if !report.complete(name) {
+				continue
+			}
+			if err := r.saveSnapshot(ctx, name, items, report.StartedAt); err != nil {
+				log.Printf("save %s snapshot failed: %v", name, err)
+			}
+		}
+	}
+	if err := r.SaveRun(ctx, report); err != nil {
+		log.Printf("record run failed: %v", err)
+	}
+}
+
+func hasSource(sources []Source, name string) bool {
+	for _, s := range sources {
+		if s.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshotSource replays what a source returned the last time it was
+// fetched in full.
+type snapshotSource struct {
+	name      string
+	items     []models.MangaCanonical
+	fetchedAt time.Time
+}
+
+func (s *snapshotSource) Name() string { return s.name }
+
+func (s *snapshotSource) FetchAll(context.Context) ([]models.MangaCanonical, error) {
+	return s.items, nil
+}
+
+func (r *Repo) loadSnapshot(ctx context.Context, source string) (*snapshotSource, error) {
+	var (
+		items string
+		snap  = snapshotSource{name: source}
+	)
+	err := r.DB.QueryRowContext(ctx, `SELECT items, fetched_at FROM scrape_snapshots WHERE source = ?`, source).
+		Scan(&items, &snap.fetchedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load %s snapshot: %w", source, err)
+	}
+	if err := json.Unmarshal([]byte(items), &snap.items); err != nil {
+		return nil, fmt.Errorf("decode %s snapshot: %w", source, err)
+	}
+	return &snap, nil
+}
+
+func (r *Repo) saveSnapshot(ctx context.Context, source string, items []models.MangaCanonical, at time.Time) error {
+	b, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.ExecContext(ctx, `
+		INSERT INTO scrape_snapshots (source, fetched_at, items) VALUES (?, ?, ?)
+		ON CONFLICT(source) DO UPDATE SET fetched_at = excluded.fetched_at, items = excluded.items
+	`, source, at, string(b))
+	return err
+}
+
+// ParseList splits a comma separated list of names, such as sources or
+// manga ids.
+func ParseList(s string) []string {
+	var out []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,188 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+// Source and run outcomes in a RunReport.
+const (
+	SourceOK      = "ok"
+	SourcePartial = "partial" // failed after returning some titles
+	SourceFailed  = "failed"  // returned nothing
+
+	RunOK      = "ok"
+	RunPartial = "partial" // saved, but at least one source had errors
+	RunFailed  = "failed"  // nothing saved
+)
+
+// SourceReport is how one source did in a run.
+type SourceReport struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Items      int    `json:"items"`
+	DurationMS int64  `json:"duration_ms"`
+	TimedOut   bool   `json:"timed_out,omitempty"`
+	Truncated  bool   `json:"truncated,omitempty"` // stopped at max_items or a page limit
+	Cached     bool   `json:"cached,omitempty"`    // replayed from the last snapshot, not fetched
+	Error      string `json:"error,omitempty"`
+}
+
+// RunReport describes one scraper run, as stored in scrape_runs.
+type RunReport struct {
+	ID         int64          `json:"id"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Status     string         `json:"status"`
+	Trigger    string         `json:"trigger,omitempty"` // cli, schedule or admin
+	Sources    []SourceReport `json:"sources"`
+	Merged     int            `json:"merged"`
+	New        int            `json:"new"`
+	Updated    int            `json:"updated"`
+	Unchanged  int            `json:"unchanged"`
+	Queued     int            `json:"queued"` // match candidates sent to review
+	Delisted   int            `json:"delisted"`
+	Relisted   int            `json:"relisted"`
+	Error      string         `json:"error,omitempty"`
+
+	// Candidates are the uncertain matches found by the merge; Queued
+	// counts those QueueReviews stored.
+	Candidates []MatchCandidate `json:"-"`
+
+	fetched map[string][]models.MangaCanonical // live results, by source
+}
+
+// complete reports whether source was fetched live without errors.
+func (r *RunReport) complete(source string) bool {
+	for _, s := range r.Sources {
+		if s.Name == source {
+			return s.Status == SourceOK && !s.Cached
+		}
+	}
+	return false
+}
+
+// listedAll reports whether source was fetched live without errors down to
+// the end of its catalog, so that what it did not return is gone.
+func (r *RunReport) listedAll(source string) bool {
+	for _, s := range r.Sources {
+		if s.Name == source {
+			return s.Status == SourceOK && !s.Cached && !s.Truncated
+		}
+	}
+	return false
+}
+
+// Finish records the save counts and the run's final error, if any, and
+// settles its status.
+func (r *RunReport) Finish(stats SaveStats, err error) {
+	r.FinishedAt = time.Now().UTC()
+	r.New, r.Updated, r.Unchanged = stats.New, stats.Updated, stats.Unchanged
+	r.Status = RunOK
+	for _, s := range r.Sources {
+		if s.Status != SourceOK {
+			r.Status = RunPartial
+		}
+	}
+	if err != nil {
+		r.Error = err.Error()
+		r.Status = RunFailed
+	}
+}
+
+// Repo stores scraper bookkeeping.
+type Repo struct {
+	DB *sql.DB
+}
+
+func NewRepo(db *sql.DB) *Repo {
+	return &Repo{DB: db}
+}
+
+// SaveRun stores a finished run and sets its ID.
+func (r *Repo) SaveRun(ctx context.Context, run *RunReport) error {
+	sources, err := json.Marshal(run.Sources)
+	if err != nil {
+		return fmt.Errorf("marshal run sources: %w", err)
+	}
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO scrape_runs (started_at, finished_at, status, trigger, merged, new, updated, unchanged, queued, delisted, relisted, sources, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.StartedAt, run.FinishedAt, run.Status, run.Trigger, run.Merged, run.New, run.Updated, run.Unchanged, run.Queued,
+		run.Delisted, run.Relisted, string(sources), run.Error)
+	if err != nil {
+		return fmt.Errorf("insert scrape run: %w", err)
+	}
+	run.ID, _ = res.LastInsertId()
+	return nil
+}
+
+const runColumns = `id, started_at, finished_at, status, COALESCE(trigger, ''), merged, new, updated, unchanged, queued, delisted, relisted, sources, error`
+
+// ListRuns returns runs newest first, with the total count.
+func (r *Repo) ListRuns(ctx context.Context, limit, offset int) ([]RunReport, int, error) {
+	var total int
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM scrape_runs`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count scrape runs: %w", err)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `SELECT `+runColumns+` FROM scrape_runs ORDER BY id DESC LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list scrape runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]RunReport, 0)
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("list scrape runs: %w", err)
+	}
+	return runs, total, nil
+}
+
+// GetRun returns one run, or nil if there is none with that id.
+func (r *Repo) GetRun(ctx context.Context, id int64) (*RunReport, error) {
+	row := r.DB.QueryRowContext(ctx, `SELECT `+runColumns+` FROM scrape_runs WHERE id = ?`, id)
+	run, err := scanRun(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return run, err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRun(s scanner) (*RunReport, error) {
+	var (
+		run      RunReport
+		finished sql.NullTime
+		sources  string
+		errText  sql.NullString
+	)
+	if err := s.Scan(&run.ID, &run.StartedAt, &finished, &run.Status, &run.Trigger, &run.Merged,
+		&run.New, &run.Updated, &run.Unchanged, &run.Queued, &run.Delisted, &run.Relisted, &sources, &errText); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan scrape run: %w", err)
+	}
+	run.FinishedAt = finished.Time
+	run.Error = errText.String
+	if err := json.Unmarshal([]byte(sources), &run.Sources); err != nil {
+		return nil, fmt.Errorf("decode scrape run %d sources: %w", run.ID, err)
+	}
+	return &run, nil
+}
@@ -2,24 +2,46 @@ package scraper
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"mangahub/pkg/models"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 )
 
 // Source is implemented by each external data source (API / HTML / local mirror).
 // Each source is responsible for fetching its own data format and mapping it
-// into MangaCanonical.
+// into MangaCanonical. When a fetch fails partway, FetchAll returns what it
+// got so far along with the error, and the aggregator keeps it.
 type Source interface {
 	Name() string
 	FetchAll(ctx context.Context) ([]models.MangaCanonical, error)
 }
 
+// Truncater is implemented by sources that can stop before the end of their
+// catalog, at max_items or a page limit. Truncated reports whether the last
+// FetchAll did.
+type Truncater interface {
+	Truncated() bool
+}
+
 // Aggregator coordinates calls to multiple sources and merges them into a single
 // canonical set of manga entries.
 type Aggregator struct {
 	Sources []Source
+	// Deadline bounds each source's FetchAll (DefaultDeadline when zero);
+	// Deadlines overrides it by source name.
+	Deadline  time.Duration
+	Deadlines map[string]time.Duration
+	// Match sets the similarity thresholds; State carries links and
+	// rejected pairs from earlier runs (see Repo.LoadMatchState).
+	Match MatchConfig
+	State MatchState
+	// Merge picks each field's merge strategy and source priority.
+	Merge MergeConfig
 }
 
 // NewAggregator creates a new Aggregator with the given sources.
@@ -27,45 +49,105 @@ func NewAggregator(sources ...Source) *Aggregator {
 	return &Aggregator{Sources: sources}
 }
 
-// FetchAndMerge fetches all manga from all sources and merges them
-// into a single slice of MangaCanonical using deterministic conflict
-// resolution rules.
-func (a *Aggregator) FetchAndMerge(ctx context.Context) ([]models.MangaCanonical, error) {
-	byKey := make(map[string]models.MangaCanonical)
-
-	for _, src := range a.Sources {
-		log.Printf("[scraper] fetching from %s", src.Name())
-		mangas, err := src.FetchAll(ctx)
-		if err != nil {
-			log.Printf("[scraper] source %s error: %v", src.Name(), err)
-			// keep going: one broken source should not kill all scraping
-			continue
-		}
+type fetchResult struct {
+	mangas []models.MangaCanonical
+	report SourceReport
+}
 
-		for _, m := range mangas {
-			key := canonicalKey(m)
+// FetchAndMerge fetches all manga from all sources concurrently, each under
+// its own deadline, and merges them in source order into a single slice of
+// MangaCanonical field by field as a.Merge says, recording each field's
+// source in Provenance. Entries
+// that may be the same work but score below the auto-merge threshold stay
+// separate and are listed in report.Candidates. The report covers every
+// source; it fails only when no source returned anything.
+func (a *Aggregator) FetchAndMerge(ctx context.Context) ([]models.MangaCanonical, *RunReport, error) {
+	report := &RunReport{StartedAt: time.Now().UTC()}
+
+	results := make([]fetchResult, len(a.Sources))
+	var wg sync.WaitGroup
+	for i, src := range a.Sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = a.fetch(ctx, src)
+		}()
+	}
+	wg.Wait()
 
-			if existing, ok := byKey[key]; ok {
-				merged := mergeManga(existing, m, src.Name())
-				byKey[key] = merged
-			} else {
-				byKey[key] = m
-			}
+	names := make([]string, len(a.Sources))
+	for i, src := range a.Sources {
+		names[i] = src.Name()
+	}
+	mt := newMatcher(a.Match, a.State, newMerger(a.Merge, names))
+	fetched := 0
+	report.fetched = make(map[string][]models.MangaCanonical)
+	for i, res := range results {
+		src := a.Sources[i]
+		if _, ok := src.(*snapshotSource); ok {
+			res.report.Cached = true
+		} else {
+			report.fetched[src.Name()] = res.mangas
+		}
+		report.Sources = append(report.Sources, res.report)
+		fetched += len(res.mangas)
+
+		for _, m := range res.mangas {
+			mt.add(m, src.Name())
 		}
 	}
 
-	result := make([]models.MangaCanonical, 0, len(byKey))
-	for _, m := range byKey {
-		result = append(result, m)
+	result := mt.results()
+	report.Merged = len(result)
+	report.Candidates = mt.candidates
+
+	if fetched == 0 && len(a.Sources) > 0 {
+		return nil, report, errors.New("no source returned any titles")
 	}
-	return result, nil
+	return result, report, nil
 }
 
-// canonicalKey defines how we group entries that represent the “same manga”
-// coming from different sources. For now we use a normalized title key.
-// You can refine this later (e.g. prefer a primary source ID).
-func canonicalKey(m models.MangaCanonical) string {
-	return normalizeKey(m.Title)
+// fetch runs one source under its deadline and reports how it went. A
+// panicking source is reported as failed rather than taking the run down.
+func (a *Aggregator) fetch(ctx context.Context, src Source) (res fetchResult) {
+	deadline := a.Deadline
+	if d, ok := a.Deadlines[src.Name()]; ok {
+		deadline = d
+	}
+	if deadline <= 0 {
+		deadline = DefaultDeadline
+	}
+	sctx, cancel := context.WithTimeout(ctx, deadline)
+	defer cancel()
+
+	log.Printf("[scraper] fetching from %s", src.Name())
+	start := time.Now()
+	defer func() {
+		if p := recover(); p != nil {
+			res = fetchResult{report: SourceReport{Name: src.Name(), Status: SourceFailed, Error: fmt.Sprintf("panic: %v", p)}}
+		}
+		res.report.DurationMS = time.Since(start).Milliseconds()
+		if res.report.Error != "" {
+			log.Printf("[scraper] source %s %s after %d items: %s", src.Name(), res.report.Status, res.report.Items, res.report.Error)
+		}
+	}()
+
+	mangas, err := src.FetchAll(sctx)
+	res = fetchResult{mangas: mangas, report: SourceReport{Name: src.Name(), Items: len(mangas), Status: SourceOK}}
+	if err != nil {
+		// keep going: one broken source should not kill all scraping, and
+		// whatever it fetched before failing is still merged
+		res.report.Error = err.Error()
+		res.report.Status = SourcePartial
+		if len(mangas) == 0 {
+			res.report.Status = SourceFailed
+		}
+		res.report.TimedOut = errors.Is(sctx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+	}
+	if t, ok := src.(Truncater); ok {
+		res.report.Truncated = t.Truncated()
+	}
+	return res
 }
 
 // normalizeKey converts a string to a canonical form: lowercase,
@@ -92,65 +174,6 @@ func normalizeKey(s string) string {
 	return strings.TrimSpace(b.String())
 }
 
-// mergeManga defines our conflict resolution rules when two sources
-// describe the same manga. The idea:
-//
-// - Keep base.Title as the canonical title, but add incoming.Title to AltTitles if different.
-// - Fill missing fields from incoming.
-// - Merge genres (set union).
-// - For status: if any says "completed", use "completed".
-// - For total chapters: use the maximum.
-// - For description: keep whichever is longer.
-// - For cover: keep existing; if empty, use incoming.
-// - Merge SourceIDs.
-func mergeManga(base, incoming models.MangaCanonical, sourceName string) models.MangaCanonical {
-	// Alt titles
-	if incoming.Title != "" && incoming.Title != base.Title {
-		base.AltTitles = appendIfMissing(base.AltTitles, incoming.Title)
-	}
-
-	// Author
-	if base.Author == "" && incoming.Author != "" {
-		base.Author = incoming.Author
-	}
-
-	// Genres (set union)
-	base.Genres = mergeStringSlices(base.Genres, incoming.Genres)
-
-	// Status resolution
-	base.Status = resolveStatus(base.Status, incoming.Status)
-
-	// Total chapters: prefer the higher number
-	if incoming.TotalChapters > base.TotalChapters {
-		base.TotalChapters = incoming.TotalChapters
-	}
-
-	// Description: prefer the longer one
-	if len(incoming.Description) > len(base.Description) {
-		base.Description = incoming.Description
-	}
-
-	// Cover: prefer existing; if empty, use incoming
-	if base.CoverURL == "" && incoming.CoverURL != "" {
-		base.CoverURL = incoming.CoverURL
-	}
-
-	// Year: if base has 0 and incoming has >0, use incoming
-	if base.Year == 0 && incoming.Year > 0 {
-		base.Year = incoming.Year
-	}
-
-	// Merge SourceIDs
-	if base.SourceIDs == nil {
-		base.SourceIDs = make(map[string]string)
-	}
-	for k, v := range incoming.SourceIDs {
-		base.SourceIDs[k] = v
-	}
-
-	return base
-}
-
 func appendIfMissing(slice []string, v string) []string {
 	for _, x := range slice {
 		if x == v {
@@ -159,29 +182,3 @@ func appendIfMissing(slice []string, v string) []string {
 	}
 	return append(slice, v)
 }
-
-func mergeStringSlices(a, b []string) []string {
-	out := make([]string, 0, len(a)+len(b))
-	out = append(out, a...)
-	for _, v := range b {
-		out = appendIfMissing(out, v)
-	}
-	return out
-}
-
-// resolveStatus merges two status values with a simple rule:
-// - If either is "completed", return "completed".
-// - Else if one is non-empty, use that.
-// - Else empty.
-func resolveStatus(a, b string) string {
-	a = strings.ToLower(strings.TrimSpace(a))
-	b = strings.ToLower(strings.TrimSpace(b))
-
-	if a == "completed" || b == "completed" {
-		return "completed"
-	}
-	if a != "" {
-		return a
-	}
-	return b
-}
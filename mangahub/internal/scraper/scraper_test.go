@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+type stubSource struct {
+	name   string
+	items  []models.MangaCanonical
+	err    error
+	blocks bool // waits for the context instead of returning
+}
+
+func (s stubSource) Name() string { return s.name }
+
+func (s stubSource) FetchAll(ctx context.Context) ([]models.MangaCanonical, error) {
+	if s.blocks {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return s.items, s.err
+}
+
+func TestAggregatorReportsEachSource(t *testing.T) {
+	agg := NewAggregator(
+		stubSource{name: "good", items: []models.MangaCanonical{{ID: "a", Title: "Berserk"}}},
+		stubSource{name: "partial", items: []models.MangaCanonical{{ID: "b", Title: "berserk!"}}, err: errors.New("page 2: status 503")},
+		stubSource{name: "slow", blocks: true},
+	)
+	agg.Deadlines = map[string]time.Duration{"slow": 10 * time.Millisecond}
+
+	got, report, err := agg.FetchAndMerge(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Title != "Berserk" || len(got[0].AltTitles) != 1 {
+		t.Fatalf("merged = %+v", got)
+	}
+
+	want := map[string]string{"good": SourceOK, "partial": SourcePartial, "slow": SourceFailed}
+	for _, s := range report.Sources {
+		if s.Status != want[s.Name] {
+			t.Errorf("%s: status %q, want %q", s.Name, s.Status, want[s.Name])
+		}
+	}
+	if !report.Sources[2].TimedOut {
+		t.Error("slow source not reported as timed out")
+	}
+
+	report.Finish(SaveStats{New: 1}, nil)
+	if report.Status != RunPartial || report.Merged != 1 || report.New != 1 {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestAggregatorFailsWhenNothingFetched(t *testing.T) {
+	agg := NewAggregator(stubSource{name: "down", err: errors.New("connection refused")})
+	if _, report, err := agg.FetchAndMerge(context.Background()); err == nil || report.Sources[0].Status != SourceFailed {
+		t.Fatalf("err = %v, report = %+v", err, report)
+	}
+}
@@ -0,0 +1,219 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// selector is a compiled JSONPath-style path into a decoded JSON document.
+// Supported syntax is a subset of JSONPath:
+//
+//	$.data[*].attributes.title.en
+//	items[0].name
+//	tags[*]           every element of an array
+//	title.*           every value of an object
+//
+// The leading "$" is optional. Alternatives separated by " | " are tried in
+// order and the first one that yields a non-empty value wins, so
+// "title.en | title.ja" falls back to the Japanese title.
+type selector struct {
+	alts [][]step
+}
+
+type step struct {
+	key      string // object member; empty for an index step
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func parseSelector(s string) (selector, error) {
+	var sel selector
+	for _, alt := range strings.Split(s, "|") {
+		steps, err := parsePath(strings.TrimSpace(alt))
+		if err != nil {
+			return selector{}, fmt.Errorf("selector %q: %w", s, err)
+		}
+		sel.alts = append(sel.alts, steps)
+	}
+	return sel, nil
+}
+
+func parsePath(p string) ([]step, error) {
+	if p == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+	p = strings.TrimPrefix(p, "$")
+	p = strings.TrimPrefix(p, ".")
+
+	var steps []step
+	for p != "" {
+		switch {
+		case p[0] == '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [")
+			}
+			inner := strings.TrimSpace(p[1:end])
+			p = p[end+1:]
+			switch {
+			case inner == "*":
+				steps = append(steps, step{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, step{key: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("bad index [%s]", inner)
+				}
+				steps = append(steps, step{index: n, isIndex: true})
+			}
+		case p[0] == '.':
+			p = p[1:]
+		default:
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			key := p[:end]
+			p = p[end:]
+			if key == "*" {
+				steps = append(steps, step{wildcard: true})
+			} else {
+				steps = append(steps, step{key: key})
+			}
+		}
+	}
+	return steps, nil
+}
+
+// IsZero reports whether the selector was never set.
+func (s selector) IsZero() bool { return len(s.alts) == 0 }
+
+// all returns every value the first matching alternative selects.
+func (s selector) all(doc any) []any {
+	for _, steps := range s.alts {
+		if vals := walk([]any{doc}, steps); len(vals) > 0 {
+			return vals
+		}
+	}
+	return nil
+}
+
+// str returns the first non-empty scalar the selector finds, as a string.
+func (s selector) str(doc any) string {
+	for _, steps := range s.alts {
+		for _, v := range walk([]any{doc}, steps) {
+			if str := scalarString(v); str != "" {
+				return str
+			}
+		}
+	}
+	return ""
+}
+
+// strs returns every scalar the selector finds, flattening arrays and
+// dropping empty and repeated values.
+func (s selector) strs(doc any) []string {
+	var out []string
+	for _, v := range s.all(doc) {
+		for _, x := range flatten(v) {
+			if str := scalarString(x); str != "" {
+				out = appendIfMissing(out, str)
+			}
+		}
+	}
+	return out
+}
+
+// int returns the leading integer of the first value found, so "1997-04"
+// gives 1997; 0 when there is none.
+func (s selector) int(doc any) int {
+	return leadingInt(s.str(doc))
+}
+
+// leadingInt parses a number, or failing that the digits it starts with.
+func leadingInt(v string) int {
+	v = strings.TrimSpace(v)
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		return int(f)
+	}
+	end := 0
+	for end < len(v) && v[end] >= '0' && v[end] <= '9' {
+		end++
+	}
+	return parseIntOrZero(v[:end])
+}
+
+func walk(cur []any, steps []step) []any {
+	for _, st := range steps {
+		next := make([]any, 0, len(cur))
+		for _, v := range cur {
+			switch node := v.(type) {
+			case map[string]any:
+				if st.wildcard {
+					// sorted keys keep results stable between runs
+					keys := make([]string, 0, len(node))
+					for k := range node {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						if node[k] != nil {
+							next = append(next, node[k])
+						}
+					}
+				} else if !st.isIndex {
+					if x, ok := node[st.key]; ok && x != nil {
+						next = append(next, x)
+					}
+				}
+			case []any:
+				switch {
+				case st.wildcard:
+					next = append(next, node...)
+				case st.isIndex:
+					i := st.index
+					if i < 0 {
+						i += len(node)
+					}
+					if i >= 0 && i < len(node) && node[i] != nil {
+						next = append(next, node[i])
+					}
+				}
+			}
+		}
+		cur = next
+	}
+	return cur
+}
+
+func flatten(v any) []any {
+	arr, ok := v.([]any)
+	if !ok {
+		return []any{v}
+	}
+	var out []any
+	for _, x := range arr {
+		out = append(out, flatten(x)...)
+	}
+	return out
+}
+
+func scalarString(v any) string {
+	switch x := v.(type) {
+	case string:
+		return strings.TrimSpace(x)
+	case json.Number:
+		return x.String()
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	default:
+		return ""
+	}
+}
@@ -3,8 +3,8 @@ package scraper
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -18,20 +18,30 @@ const mangadexBase = "https://api.mangadex.org"
 
 // SourceA fetches manga list from MangaDex.
 type SourceA struct {
-	Client *http.Client
-	Limit  int // items per request
-	Max    int // maximum items to fetch total (safety)
+	ID      string // source name in logs and SourceIDs; "mangadex" when empty
+	BaseURL string
+	Fetcher *Fetcher
+	Limit   int // items per request
+	Max     int // maximum items to fetch total (safety)
+
+	truncated bool
 }
 
 func NewSourceA() *SourceA {
 	return &SourceA{
-		Client: &http.Client{Timeout: 12 * time.Second},
-		Limit:  50,
-		Max:    200, // keep demo-safe; bump later if you want
+		BaseURL: mangadexBase,
+		Fetcher: NewFetcher(&http.Client{Timeout: 12 * time.Second}, FetchConfig{}),
+		Limit:   50,
+		Max:     200, // keep demo-safe; bump later if you want
 	}
 }
 
-func (s *SourceA) Name() string { return "mangadex" }
+func (s *SourceA) Name() string {
+	if s.ID != "" {
+		return s.ID
+	}
+	return "mangadex"
+}
 
 type mdResponse struct {
 	Result string `json:"result"`
@@ -69,9 +79,10 @@ func (s *SourceA) FetchAll(ctx context.Context) ([]models.MangaCanonical, error)
 
 	offset := 0
 	fetched := 0
+	var skipped []error
 
 	for fetched < s.Max {
-		u, _ := url.Parse(mangadexBase + "/manga")
+		u, _ := url.Parse(s.BaseURL + "/manga")
 		q := u.Query()
 		q.Set("limit", fmt.Sprintf("%d", s.Limit))
 		q.Set("offset", fmt.Sprintf("%d", offset))
@@ -89,26 +100,22 @@ func (s *SourceA) FetchAll(ctx context.Context) ([]models.MangaCanonical, error)
 
 		u.RawQuery = q.Encode()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-		if err != nil {
-			return nil, fmt.Errorf("mangadex: build request: %w", err)
+		// pages already fetched are kept: the aggregator merges partial results
+		var md mdResponse
+		resp, err := s.Fetcher.Get(ctx, u.String(), nil)
+		if err == nil {
+			if err = json.Unmarshal(resp.Body, &md); err != nil {
+				err = fmt.Errorf("decode: %w", err)
+			}
 		}
-
-		resp, err := s.Client.Do(req)
 		if err != nil {
-			return nil, fmt.Errorf("mangadex: request: %w", err)
-		}
-
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("mangadex: status %d: %s", resp.StatusCode, string(body))
-		}
-
-		var md mdResponse
-		if err := json.Unmarshal(body, &md); err != nil {
-			return nil, fmt.Errorf("mangadex: decode: %w", err)
+			err = fmt.Errorf("mangadex: offset %d: %w", offset, err)
+			skipped = append(skipped, err)
+			if !s.Fetcher.Tolerate(err) {
+				return all, errors.Join(skipped...)
+			}
+			offset += s.Limit
+			continue
 		}
 
 		if len(md.Data) == 0 {
@@ -187,7 +194,7 @@ func (s *SourceA) FetchAll(ctx context.Context) ([]models.MangaCanonical, error)
 				Description:   desc,
 				CoverURL:      coverURL,
 				Year:          item.Attributes.Year,
-				SourceIDs:     map[string]string{"mangadex": item.ID},
+				SourceIDs:     map[string]string{s.Name(): item.ID},
 			}
 			all = append(all, m)
 			fetched++
@@ -199,7 +206,14 @@ func (s *SourceA) FetchAll(ctx context.Context) ([]models.MangaCanonical, error)
 		offset += s.Limit
 	}
 
-	return all, nil
+	s.truncated = fetched >= s.Max
+	return all, errors.Join(skipped...)
+}
+
+// Truncated reports whether the last FetchAll stopped at Max rather than at
+// the end of the catalog.
+func (s *SourceA) Truncated() bool {
+	return s.truncated
 }
 
 func pickLang(m map[string]string, lang string) string {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -16,21 +15,26 @@ import (
 // SourceB is a second source with a different JSON shape.
 // For example, your own hosted JSON or another public API.
 type SourceB struct {
+	ID      string // source name in logs and SourceIDs; "source_b" when empty
 	BaseURL string
-	Client  *http.Client
+	Fetcher *Fetcher
+	Max     int // maximum items to keep; 0 keeps everything
+
+	truncated bool
 }
 
 // NewSourceB creates a new SourceB.
 func NewSourceB(baseURL string) *SourceB {
 	return &SourceB{
 		BaseURL: baseURL,
-		Client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		Fetcher: NewFetcher(&http.Client{Timeout: 10 * time.Second}, FetchConfig{}),
 	}
 }
 
 func (s *SourceB) Name() string {
+	if s.ID != "" {
+		return s.ID
+	}
 	return "source_b"
 }
 
@@ -55,23 +59,13 @@ func (s *SourceB) Name() string {
 //	  ...
 //	]
 func (s *SourceB) FetchAll(ctx context.Context) ([]models.MangaCanonical, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.BaseURL+"/titles", nil)
-	if err != nil {
-		return nil, fmt.Errorf("source_b: build request: %w", err)
-	}
-
-	resp, err := s.Client.Do(req)
+	s.truncated = false
+	resp, err := s.Fetcher.Get(ctx, s.BaseURL+"/titles", nil)
 	if err != nil {
-		return nil, fmt.Errorf("source_b: do request: %w", err)
+		return nil, fmt.Errorf("source_b: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("source_b: status %d: %s", resp.StatusCode, string(body))
-	}
-
-	// TODO: adapt to your actual JSON structure.
+	// Feeds with any other shape can use the "json" source type instead.
 	var raw []struct {
 		Slug          string   `json:"slug"`
 		Name          string   `json:"name"`
@@ -84,7 +78,7 @@ func (s *SourceB) FetchAll(ctx context.Context) ([]models.MangaCanonical, error)
 		ImageURL      string   `json:"image_url"`
 		Year          string   `json:"year"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+	if err := json.Unmarshal(resp.Body, &raw); err != nil {
 		return nil, fmt.Errorf("source_b: decode json: %w", err)
 	}
 
@@ -108,13 +102,22 @@ func (s *SourceB) FetchAll(ctx context.Context) ([]models.MangaCanonical, error)
 			Description:   r.Summary,
 			CoverURL:      r.ImageURL,
 			Year:          year,
-			SourceIDs:     map[string]string{"source_b": r.Slug},
+			SourceIDs:     map[string]string{s.Name(): r.Slug},
 		}
 		result = append(result, m)
+		if s.Max > 0 && len(result) >= s.Max {
+			s.truncated = true
+			break
+		}
 	}
 	return result, nil
 }
 
+// Truncated reports whether the last FetchAll stopped at Max.
+func (s *SourceB) Truncated() bool {
+	return s.truncated
+}
+
 func parseIntOrZero(s string) int {
 	s = strings.TrimSpace(s)
 	if s == "" {
@@ -0,0 +1,429 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"mangahub/pkg/models"
+)
+
+// HTMLSiteConfig describes an HTML catalog for the "html" source type. The
+// crawler starts at ListPath, takes every Item on the page, and follows
+// Next to the following listing page. With Link set, each item's link is
+// fetched and Fields are read from that detail page; without it they are
+// read from the item itself.
+//
+//   - name: somesite
+//     type: html
+//     base_url: https://manga.example.com
+//     html:
+//     list_path: /directory
+//     item: ul.series > li
+//     link: a.title
+//     next: a[rel=next]
+//     fields:
+//     title: h1
+//     author: .meta .author
+//     genres: .genres a
+//     cover: img.cover @src
+//     crawl_delay_ms: 1500
+//
+// A field is a CSS selector, optionally followed by " @attr" to read an
+// attribute instead of the text; "@attr" alone reads the current element.
+type HTMLSiteConfig struct {
+	ListPath  string            `yaml:"list_path" json:"list_path"`
+	Item      string            `yaml:"item" json:"item"`
+	Link      string            `yaml:"link" json:"link"`
+	Next      string            `yaml:"next" json:"next"`
+	Fields    FieldMap          `yaml:"fields" json:"fields"`
+	StatusMap map[string]string `yaml:"status_map" json:"status_map"`
+	// CrawlDelayMS is the pause between requests; robots.txt may ask for
+	// more. Default 1000.
+	CrawlDelayMS int    `yaml:"crawl_delay_ms" json:"crawl_delay_ms"`
+	UserAgent    string `yaml:"user_agent" json:"user_agent"`
+	// IgnoreRobots skips robots.txt; only for sites we run ourselves.
+	IgnoreRobots bool `yaml:"ignore_robots" json:"ignore_robots"`
+	MaxPages     int  `yaml:"max_pages" json:"max_pages"` // listing pages; default 100
+}
+
+const defaultUserAgent = "MangaHubScraper/1.0"
+
+// HTMLSource crawls an HTML catalog described by an HTMLSiteConfig.
+type HTMLSource struct {
+	ID      string
+	BaseURL string
+	Fetcher *Fetcher
+	Max     int // maximum items to fetch total; 0 is unlimited
+
+	site   HTMLSiteConfig
+	base   *url.URL
+	fields struct {
+		id, title, altTitles, author, genres, status, chapters, description, year, cover htmlField
+	}
+	truncated bool // the last FetchAll stopped before the last listing page
+
+	mu       sync.Mutex
+	robots   *robots
+	lastHit  time.Time
+	robotsOK bool
+}
+
+type htmlField struct {
+	sel  string // empty means the element itself
+	attr string // empty means its text
+}
+
+func parseHTMLField(spec string) htmlField {
+	spec = strings.TrimSpace(spec)
+	if i := strings.LastIndex(spec, "@"); i >= 0 && (i == 0 || spec[i-1] == ' ') {
+		return htmlField{sel: strings.TrimSpace(spec[:i]), attr: strings.TrimSpace(spec[i+1:])}
+	}
+	return htmlField{sel: spec}
+}
+
+func (f htmlField) isZero() bool { return f.sel == "" && f.attr == "" }
+
+func (f htmlField) nodes(s *goquery.Selection) *goquery.Selection {
+	if f.sel == "" {
+		return s
+	}
+	return s.Find(f.sel)
+}
+
+func (f htmlField) value(n *goquery.Selection) string {
+	if f.attr != "" {
+		return strings.TrimSpace(n.AttrOr(f.attr, ""))
+	}
+	return strings.Join(strings.Fields(n.Text()), " ")
+}
+
+// str returns the first non-empty value.
+func (f htmlField) str(s *goquery.Selection) string {
+	if f.isZero() {
+		return ""
+	}
+	out := ""
+	f.nodes(s).EachWithBreak(func(_ int, n *goquery.Selection) bool {
+		out = f.value(n)
+		return out == ""
+	})
+	return out
+}
+
+// strs returns every distinct non-empty value.
+func (f htmlField) strs(s *goquery.Selection) []string {
+	if f.isZero() {
+		return nil
+	}
+	var out []string
+	f.nodes(s).Each(func(_ int, n *goquery.Selection) {
+		if v := f.value(n); v != "" {
+			out = appendIfMissing(out, v)
+		}
+	})
+	return out
+}
+
+// text keeps paragraph breaks, for descriptions.
+func (f htmlField) text(s *goquery.Selection) string {
+	if f.isZero() || f.attr != "" {
+		return f.str(s)
+	}
+	return strings.TrimSpace(f.nodes(s).First().Text())
+}
+
+func init() {
+	Register("html", func(cfg SourceConfig) (Source, error) {
+		if cfg.HTML == nil {
+			return nil, fmt.Errorf("source %s: html section is required", cfg.Name)
+		}
+		s, err := NewHTMLSource(cfg.Name, cfg.BaseURL, *cfg.HTML)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", cfg.Name, err)
+		}
+		s.Fetcher = cfg.Fetcher(s.Fetcher.Client.Timeout)
+		s.Max = cfg.Limits.MaxItems
+		return s, nil
+	})
+}
+
+// NewHTMLSource checks site and prepares its selectors.
+func NewHTMLSource(name, baseURL string, site HTMLSiteConfig) (*HTMLSource, error) {
+	base, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("base_url must be an absolute URL")
+	}
+	if site.Item == "" {
+		return nil, fmt.Errorf("html.item is required")
+	}
+	if site.Fields.Title == "" {
+		return nil, fmt.Errorf("fields.title is required")
+	}
+	if site.Link == "" && site.Fields.ID == "" {
+		return nil, fmt.Errorf("fields.id is required without html.link")
+	}
+	if site.CrawlDelayMS <= 0 {
+		site.CrawlDelayMS = 1000
+	}
+	if site.UserAgent == "" {
+		site.UserAgent = defaultUserAgent
+	}
+	if site.MaxPages <= 0 {
+		site.MaxPages = 100
+	}
+
+	s := &HTMLSource{
+		ID:      name,
+		BaseURL: base.String(),
+		Fetcher: NewFetcher(&http.Client{Timeout: 10 * time.Second}, FetchConfig{}),
+		site:    site,
+		base:    base,
+	}
+	f := site.Fields
+	s.fields.id = parseHTMLField(f.ID)
+	s.fields.title = parseHTMLField(f.Title)
+	s.fields.altTitles = parseHTMLField(f.AltTitles)
+	s.fields.author = parseHTMLField(f.Author)
+	s.fields.genres = parseHTMLField(f.Genres)
+	s.fields.status = parseHTMLField(f.Status)
+	s.fields.chapters = parseHTMLField(f.Chapters)
+	s.fields.description = parseHTMLField(f.Description)
+	s.fields.year = parseHTMLField(f.Year)
+	s.fields.cover = parseHTMLField(f.Cover)
+	return s, nil
+}
+
+func (s *HTMLSource) Name() string { return s.ID }
+
+// FetchAll crawls the listing pages and, when configured, each item's
+// detail page. Pages robots.txt disallows are skipped.
+func (s *HTMLSource) FetchAll(ctx context.Context) ([]models.MangaCanonical, error) {
+	var all []models.MangaCanonical
+	var skipped []error
+	seen := map[string]bool{}
+	s.truncated = false
+
+	listURL := s.base.String()
+	if s.site.ListPath != "" {
+		listURL = s.resolve(listURL+"/", strings.TrimPrefix(s.site.ListPath, "/"))
+	}
+	for n := 0; n < s.site.MaxPages && listURL != "" && !seen[listURL]; n++ {
+		seen[listURL] = true
+		doc, err := s.get(ctx, listURL)
+		if err != nil {
+			return all, errors.Join(append(skipped, err)...)
+		}
+		if doc == nil {
+			// the rest of the listing is off limits
+			s.truncated = true
+			break
+		}
+
+		var items []*goquery.Selection
+		doc.Find(s.site.Item).Each(func(_ int, item *goquery.Selection) {
+			items = append(items, item)
+		})
+
+		for _, item := range items {
+			m, ok, err := s.fetchItem(ctx, item, listURL, seen)
+			if err != nil {
+				// a broken detail page costs one title, within the budget
+				skipped = append(skipped, err)
+				if !s.Fetcher.Tolerate(err) {
+					return all, errors.Join(skipped...)
+				}
+				continue
+			}
+			if !ok {
+				continue
+			}
+			all = append(all, m)
+			if s.Max > 0 && len(all) >= s.Max {
+				s.truncated = true
+				return all, errors.Join(skipped...)
+			}
+		}
+
+		listURL = ""
+		if s.site.Next != "" {
+			listURL = s.resolve(doc.Url.String(), doc.Find(s.site.Next).First().AttrOr("href", ""))
+		}
+	}
+	if listURL != "" && !seen[listURL] {
+		// stopped at max_pages
+		s.truncated = true
+	}
+	return all, errors.Join(skipped...)
+}
+
+// Truncated reports whether the last FetchAll stopped at max_items,
+// max_pages or a listing page robots.txt disallows.
+func (s *HTMLSource) Truncated() bool {
+	return s.truncated
+}
+
+// fetchItem maps one listing entry, fetching its detail page if the site
+// has one. Items without a title are skipped.
+func (s *HTMLSource) fetchItem(ctx context.Context, item *goquery.Selection, listURL string, seen map[string]bool) (models.MangaCanonical, bool, error) {
+	page := item
+	pageURL := listURL
+
+	if s.site.Link != "" {
+		link := item
+		if !item.Is(s.site.Link) {
+			link = item.Find(s.site.Link).First()
+		}
+		detailURL := s.resolve(listURL, link.AttrOr("href", ""))
+		if detailURL == "" || seen[detailURL] {
+			return models.MangaCanonical{}, false, nil
+		}
+		seen[detailURL] = true
+
+		doc, err := s.get(ctx, detailURL)
+		if err != nil {
+			return models.MangaCanonical{}, false, err
+		}
+		if doc == nil {
+			return models.MangaCanonical{}, false, nil
+		}
+		page, pageURL = doc.Selection, detailURL
+	}
+
+	f := &s.fields
+	title := f.title.str(page)
+	id := f.id.str(page)
+	if id == "" {
+		// the last path segment of the page is usually the site's slug
+		if u, err := url.Parse(pageURL); err == nil && s.site.Link != "" {
+			id = path.Base(strings.TrimRight(u.Path, "/"))
+		}
+	}
+	if id == "" || id == "." || id == "/" || title == "" {
+		return models.MangaCanonical{}, false, nil
+	}
+
+	alts := make([]string, 0)
+	for _, a := range f.altTitles.strs(page) {
+		if a != title {
+			alts = append(alts, a)
+		}
+	}
+
+	return models.MangaCanonical{
+		ID:            id,
+		Title:         title,
+		AltTitles:     alts,
+		Author:        f.author.str(page),
+		Genres:        f.genres.strs(page),
+		Status:        mapStatus(s.site.StatusMap, f.status.str(page)),
+		TotalChapters: leadingInt(f.chapters.str(page)),
+		Description:   f.description.text(page),
+		CoverURL:      s.resolve(pageURL, f.cover.str(page)),
+		Year:          leadingInt(f.year.str(page)),
+		SourceIDs:     map[string]string{s.Name(): id},
+	}, true, nil
+}
+
+// get fetches and parses one page, waiting out the crawl delay first. It
+// returns a nil document for pages robots.txt disallows.
+func (s *HTMLSource) get(ctx context.Context, rawURL string) (*goquery.Document, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: bad url %q: %w", s.Name(), rawURL, err)
+	}
+
+	if !s.site.IgnoreRobots {
+		rb, err := s.loadRobots(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.Name(), err)
+		}
+		if u.Host == s.base.Host && !rb.Allowed(u) {
+			log.Printf("[scraper] %s: robots.txt disallows %s", s.Name(), rawURL)
+			return nil, nil
+		}
+	}
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.Fetcher.Get(ctx, rawURL, http.Header{
+		"User-Agent": {s.site.UserAgent},
+		"Accept":     {"text/html"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Name(), err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(resp.Body))
+	if err != nil {
+		return nil, fmt.Errorf("%s: parse html: %w", s.Name(), err)
+	}
+	doc.Url = resp.URL
+	return doc, nil
+}
+
+func (s *HTMLSource) loadRobots(ctx context.Context) (*robots, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.robotsOK {
+		return s.robots, nil
+	}
+	rb, err := fetchRobots(ctx, s.Fetcher, s.base, s.site.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	s.robots, s.robotsOK = rb, true
+	return rb, nil
+}
+
+// wait sleeps until the crawl delay since the previous request has passed.
+func (s *HTMLSource) wait(ctx context.Context) error {
+	delay := time.Duration(s.site.CrawlDelayMS) * time.Millisecond
+	s.mu.Lock()
+	if d := s.robots.CrawlDelay(); d > delay {
+		delay = d
+	}
+	wait := time.Until(s.lastHit.Add(delay))
+	if wait < 0 {
+		wait = 0
+	}
+	s.lastHit = time.Now().Add(wait)
+	s.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// resolve makes ref absolute against base; empty and non-http links
+// (javascript:, mailto:) resolve to "".
+func (s *HTMLSource) resolve(base, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return ""
+	}
+	out := resolveURL(base, ref)
+	if !strings.HasPrefix(out, "http://") && !strings.HasPrefix(out, "https://") {
+		return ""
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixtureSite serves a two-page catalog with detail pages, one of which
+// robots.txt disallows.
+func fixtureSite(t *testing.T) (*httptest.Server, *sync.Map) {
+	t.Helper()
+	hits := &sync.Map{}
+	pages := map[string]string{
+		"/robots.txt": "User-agent: *\nDisallow: /series/secret\nCrawl-delay: 0.01\n",
+		"/directory": `<ul class="series">
+			<li><a class="title" href="/series/one-piece">One Piece</a></li>
+			<li><a class="title" href="/series/secret">Hidden</a></li>
+		</ul><a rel="next" href="/directory?page=2">next</a>`,
+		"/directory?page=2": `<ul class="series">
+			<li><a class="title" href="series/berserk/">Berserk</a></li>
+			<li><a class="title" href="javascript:void(0)">Broken</a></li>
+		</ul>`,
+		"/series/one-piece": `<h1> One  Piece </h1>
+			<div class="alt"><span>ワンピース</span><span>One Piece</span></div>
+			<p class="author">Oda Eiichiro</p>
+			<ul class="genres"><li>Action</li><li>Adventure</li><li>Action</li></ul>
+			<dl><dd class="status">Publishing</dd><dd class="chapters">1100 chapters</dd><dd class="year">1997-07-22</dd></dl>
+			<div class="summary">Pirates.
+
+			Treasure.</div>
+			<img class="cover" src="/img/op.jpg">`,
+		"/series/secret":   `<h1>Secret</h1>`,
+		"/series/berserk/": `<h1>Berserk</h1><dd class="status">Hiatus</dd>`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Store(r.URL.RequestURI(), r.UserAgent())
+		body, ok := pages[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, hits
+}
+
+func TestHTMLSourceCrawlsFixtureSite(t *testing.T) {
+	srv, hits := fixtureSite(t)
+	src, err := NewHTMLSource("fixture", srv.URL, HTMLSiteConfig{
+		ListPath: "/directory",
+		Item:     "ul.series > li",
+		Link:     "a.title",
+		Next:     "a[rel=next]",
+		Fields: FieldMap{
+			Title:       "h1",
+			AltTitles:   ".alt span",
+			Author:      ".author",
+			Genres:      ".genres li",
+			Status:      ".status",
+			Chapters:    ".chapters",
+			Description: ".summary",
+			Year:        ".year",
+			Cover:       "img.cover @src",
+		},
+		StatusMap:    map[string]string{"Publishing": "ongoing"},
+		CrawlDelayMS: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	got, err := src.FetchAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d titles, want 2: %+v", len(got), got)
+	}
+
+	op := got[0]
+	if op.ID != "one-piece" || op.Title != "One Piece" {
+		t.Errorf("id/title = %q/%q", op.ID, op.Title)
+	}
+	if !reflect.DeepEqual(op.AltTitles, []string{"ワンピース"}) {
+		t.Errorf("alt titles = %v", op.AltTitles)
+	}
+	if !reflect.DeepEqual(op.Genres, []string{"Action", "Adventure"}) {
+		t.Errorf("genres = %v", op.Genres)
+	}
+	if op.Author != "Oda Eiichiro" || op.Status != "ongoing" || op.TotalChapters != 1100 || op.Year != 1997 {
+		t.Errorf("author/status/chapters/year = %q/%q/%d/%d", op.Author, op.Status, op.TotalChapters, op.Year)
+	}
+	if op.CoverURL != srv.URL+"/img/op.jpg" {
+		t.Errorf("cover = %q", op.CoverURL)
+	}
+	if op.SourceIDs["fixture"] != "one-piece" {
+		t.Errorf("source ids = %v", op.SourceIDs)
+	}
+
+	// relative link resolved against the second listing page
+	if got[1].ID != "berserk" || got[1].Status != "hiatus" {
+		t.Errorf("second title = %+v", got[1])
+	}
+
+	if _, ok := hits.Load("/series/secret"); ok {
+		t.Error("fetched a page disallowed by robots.txt")
+	}
+	if ua, _ := hits.Load("/directory"); ua != defaultUserAgent {
+		t.Errorf("user agent = %v", ua)
+	}
+}
+
+func TestRobotsRules(t *testing.T) {
+	rb := parseRobots(strings.NewReader(`
+User-agent: *
+Disallow: /
+
+User-agent: MangaHubScraper
+Disallow: /private
+Allow: /private/ok$
+Disallow: /*.pdf
+`), "MangaHubScraper/1.0")
+
+	for path, want := range map[string]bool{
+		"/series/x":       true,
+		"/private/x":      false,
+		"/private/ok":     true,
+		"/private/ok/no":  false,
+		"/files/list.pdf": false,
+	} {
+		u, err := url.Parse("https://example.com" + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rb.Allowed(u); got != want {
+			t.Errorf("Allowed(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
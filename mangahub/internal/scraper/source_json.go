@@ -0,0 +1,435 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+// JSONFeedConfig describes a JSON feed for the "json" source type: where
+// the items are, which field of an item holds what, and how to page.
+//
+//   - name: otherfeed
+//     type: json
+//     base_url: https://feed.example.com
+//     json:
+//     path: /api/series
+//     items: $.results[*]
+//     fields:
+//     id: uuid
+//     title: titles.en | titles.ja
+//     genres: genres[*].name
+//     status_map: {finished: completed, airing: ongoing}
+//     pagination: {style: page, param: p}
+type JSONFeedConfig struct {
+	Path  string            `yaml:"path" json:"path"`   // appended to base_url
+	Query map[string]string `yaml:"query" json:"query"` // sent with every request
+	// Items selects the list of items in a response; empty means the
+	// response is the list.
+	Items      string            `yaml:"items" json:"items"`
+	Fields     FieldMap          `yaml:"fields" json:"fields"`
+	StatusMap  map[string]string `yaml:"status_map" json:"status_map"`
+	Pagination Pagination        `yaml:"pagination" json:"pagination"`
+}
+
+// FieldMap holds one selector per MangaCanonical field, relative to an
+// item. ID and Title are required; the rest are left empty when unset.
+type FieldMap struct {
+	ID          string `yaml:"id" json:"id"`
+	Title       string `yaml:"title" json:"title"`
+	AltTitles   string `yaml:"alt_titles" json:"alt_titles"`
+	Author      string `yaml:"author" json:"author"`
+	Genres      string `yaml:"genres" json:"genres"`
+	Status      string `yaml:"status" json:"status"`
+	Chapters    string `yaml:"chapters" json:"chapters"`
+	Description string `yaml:"description" json:"description"`
+	Year        string `yaml:"year" json:"year"`
+	Cover       string `yaml:"cover" json:"cover"`
+}
+
+// Pagination styles understood by JSONSource.
+const (
+	PageNone   = "none"      // a single request
+	PageOffset = "offset"    // ?offset=0,50,100…
+	PagePage   = "page"      // ?page=1,2,3…
+	PageCursor = "cursor"    // ?cursor=<value of Cursor in the last response>
+	PageNext   = "next_link" // follow the URL at Next, or the Link: rel="next" header
+)
+
+// Pagination says how to reach the next page of a feed. Limits.PageSize is
+// sent as SizeParam when both are set, and a short page ends the offset and
+// page styles.
+type Pagination struct {
+	Style     string `yaml:"style" json:"style"`
+	Param     string `yaml:"param" json:"param"`           // offset, page or cursor parameter
+	SizeParam string `yaml:"size_param" json:"size_param"` // page size parameter
+	Start     int    `yaml:"start" json:"start"`           // first page number (page style); default 1
+	Cursor    string `yaml:"cursor" json:"cursor"`         // selector for the next cursor
+	Next      string `yaml:"next" json:"next"`             // selector for the next URL
+	MaxPages  int    `yaml:"max_pages" json:"max_pages"`   // safety stop; default 1000
+}
+
+// JSONSource reads any JSON feed described by a JSONFeedConfig.
+type JSONSource struct {
+	ID       string
+	BaseURL  string
+	Fetcher  *Fetcher
+	PageSize int
+	Max      int // maximum items to fetch total; 0 is unlimited
+
+	feed   JSONFeedConfig
+	items  selector
+	fields struct {
+		id, title, altTitles, author, genres, status, chapters, description, year, cover selector
+	}
+	cursor, next selector
+
+	truncated bool // the last FetchAll stopped before the last page
+}
+
+func init() {
+	Register("json", func(cfg SourceConfig) (Source, error) {
+		if cfg.JSON == nil {
+			return nil, fmt.Errorf("source %s: json section is required", cfg.Name)
+		}
+		s, err := NewJSONSource(cfg.Name, cfg.BaseURL, *cfg.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", cfg.Name, err)
+		}
+		s.Fetcher = cfg.Fetcher(s.Fetcher.Client.Timeout)
+		s.PageSize = cfg.Limits.PageSize
+		s.Max = cfg.Limits.MaxItems
+		return s, nil
+	})
+}
+
+// NewJSONSource compiles feed's selectors and checks its pagination.
+func NewJSONSource(name, baseURL string, feed JSONFeedConfig) (*JSONSource, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("base_url is required")
+	}
+	if feed.Fields.ID == "" || feed.Fields.Title == "" {
+		return nil, fmt.Errorf("fields.id and fields.title are required")
+	}
+
+	s := &JSONSource{
+		ID:      name,
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Fetcher: NewFetcher(&http.Client{Timeout: 10 * time.Second}, FetchConfig{}),
+		feed:    feed,
+	}
+
+	compile := func(dst *selector, expr string) error {
+		if expr == "" {
+			return nil
+		}
+		sel, err := parseSelector(expr)
+		if err != nil {
+			return err
+		}
+		*dst = sel
+		return nil
+	}
+	f := feed.Fields
+	for _, c := range []struct {
+		dst  *selector
+		expr string
+	}{
+		{&s.items, feed.Items},
+		{&s.fields.id, f.ID},
+		{&s.fields.title, f.Title},
+		{&s.fields.altTitles, f.AltTitles},
+		{&s.fields.author, f.Author},
+		{&s.fields.genres, f.Genres},
+		{&s.fields.status, f.Status},
+		{&s.fields.chapters, f.Chapters},
+		{&s.fields.description, f.Description},
+		{&s.fields.year, f.Year},
+		{&s.fields.cover, f.Cover},
+		{&s.cursor, feed.Pagination.Cursor},
+		{&s.next, feed.Pagination.Next},
+	} {
+		if err := compile(c.dst, c.expr); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &s.feed.Pagination
+	p.Style = strings.ToLower(strings.TrimSpace(p.Style))
+	switch p.Style {
+	case "", PageNone:
+		p.Style = PageNone
+	case PageOffset:
+		if p.Param == "" {
+			p.Param = "offset"
+		}
+	case PagePage:
+		if p.Param == "" {
+			p.Param = "page"
+		}
+		if p.Start == 0 {
+			p.Start = 1
+		}
+	case PageCursor:
+		if p.Param == "" {
+			p.Param = "cursor"
+		}
+		if s.cursor.IsZero() {
+			return nil, fmt.Errorf("pagination.cursor is required for the cursor style")
+		}
+	case PageNext:
+	default:
+		return nil, fmt.Errorf("unknown pagination style %q", p.Style)
+	}
+	if p.MaxPages <= 0 {
+		p.MaxPages = 1000
+	}
+	return s, nil
+}
+
+func (s *JSONSource) Name() string { return s.ID }
+
+// FetchAll walks the feed page by page and maps every item that has an ID
+// and a title.
+func (s *JSONSource) FetchAll(ctx context.Context) ([]models.MangaCanonical, error) {
+	var all []models.MangaCanonical
+	p := s.feed.Pagination
+
+	pageURL, err := s.pageURL(p.Start, "")
+	if err != nil {
+		return nil, err
+	}
+	offset := p.Start
+	page := p.Start
+	seen := map[string]bool{}
+	var skipped []error
+	s.truncated = false
+
+	for n := 0; n < p.MaxPages && pageURL != ""; n++ {
+		seen[pageURL] = true
+		doc, header, err := s.get(ctx, pageURL)
+		if err != nil {
+			skipped = append(skipped, err)
+			// only numbered pages can be skipped; a cursor or link is lost
+			// with the page that carried it
+			next := ""
+			switch {
+			case p.Style == PageOffset && s.PageSize > 0:
+				offset += s.PageSize
+				next, _ = s.pageURL(offset, "")
+			case p.Style == PagePage:
+				page++
+				next, _ = s.pageURL(page, "")
+			}
+			if next == "" || !s.Fetcher.Tolerate(err) {
+				return all, errors.Join(skipped...)
+			}
+			pageURL = next
+			continue
+		}
+
+		items := s.itemsOf(doc)
+		for _, item := range items {
+			m, ok := s.mapItem(item, pageURL)
+			if !ok {
+				continue
+			}
+			all = append(all, m)
+			if s.Max > 0 && len(all) >= s.Max {
+				s.truncated = true
+				return all, errors.Join(skipped...)
+			}
+		}
+
+		short := len(items) == 0 || (s.PageSize > 0 && len(items) < s.PageSize)
+		next := ""
+		switch p.Style {
+		case PageOffset:
+			if !short {
+				offset += len(items)
+				next, err = s.pageURL(offset, "")
+			}
+		case PagePage:
+			if !short {
+				page++
+				next, err = s.pageURL(page, "")
+			}
+		case PageCursor:
+			if c := s.cursor.str(doc); c != "" {
+				next, err = s.pageURL(0, c)
+			}
+		case PageNext:
+			next = s.nextLink(doc, header, pageURL)
+		}
+		if err != nil {
+			return all, errors.Join(append(skipped, err)...)
+		}
+		if seen[next] {
+			break
+		}
+		pageURL = next
+	}
+	if pageURL != "" && !seen[pageURL] {
+		// stopped at max_pages
+		s.truncated = true
+	}
+	return all, errors.Join(skipped...)
+}
+
+// Truncated reports whether the last FetchAll stopped at max_items or
+// max_pages.
+func (s *JSONSource) Truncated() bool {
+	return s.truncated
+}
+
+// pageURL builds the request URL for a page: n is the offset or page
+// number, cursor the cursor value.
+func (s *JSONSource) pageURL(n int, cursor string) (string, error) {
+	u, err := url.Parse(s.BaseURL + s.feed.Path)
+	if err != nil {
+		return "", fmt.Errorf("%s: bad url: %w", s.Name(), err)
+	}
+	q := u.Query()
+	for k, v := range s.feed.Query {
+		q.Set(k, v)
+	}
+
+	p := s.feed.Pagination
+	if p.SizeParam != "" && s.PageSize > 0 {
+		q.Set(p.SizeParam, strconv.Itoa(s.PageSize))
+	}
+	switch p.Style {
+	case PageOffset, PagePage:
+		q.Set(p.Param, strconv.Itoa(n))
+	case PageCursor:
+		if cursor != "" {
+			q.Set(p.Param, cursor)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (s *JSONSource) get(ctx context.Context, u string) (any, http.Header, error) {
+	resp, err := s.Fetcher.Get(ctx, u, http.Header{"Accept": {"application/json"}})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", s.Name(), err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(resp.Body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("%s: decode json: %w", s.Name(), err)
+	}
+	return doc, resp.Header, nil
+}
+
+func (s *JSONSource) itemsOf(doc any) []any {
+	if s.items.IsZero() {
+		arr, _ := doc.([]any)
+		return arr
+	}
+	vals := s.items.all(doc)
+	if len(vals) == 1 {
+		if arr, ok := vals[0].([]any); ok {
+			return arr
+		}
+	}
+	return vals
+}
+
+func (s *JSONSource) mapItem(item any, pageURL string) (models.MangaCanonical, bool) {
+	f := &s.fields
+	id := f.id.str(item)
+	title := f.title.str(item)
+	if id == "" || title == "" {
+		return models.MangaCanonical{}, false
+	}
+
+	alts := make([]string, 0)
+	for _, a := range f.altTitles.strs(item) {
+		if a != title {
+			alts = append(alts, a)
+		}
+	}
+
+	return models.MangaCanonical{
+		ID:            id,
+		Title:         title,
+		AltTitles:     alts,
+		Author:        f.author.str(item),
+		Genres:        f.genres.strs(item),
+		Status:        mapStatus(s.feed.StatusMap, f.status.str(item)),
+		TotalChapters: f.chapters.int(item),
+		Description:   f.description.str(item),
+		CoverURL:      resolveURL(pageURL, f.cover.str(item)),
+		Year:          f.year.int(item),
+		SourceIDs:     map[string]string{s.Name(): id},
+	}, true
+}
+
+// mapStatus applies a source's status map (case-insensitive), then the
+// usual spellings of ongoing and completed.
+func mapStatus(statusMap map[string]string, raw string) string {
+	key := strings.ToLower(strings.TrimSpace(raw))
+	for k, v := range statusMap {
+		if strings.ToLower(k) == key {
+			return v
+		}
+	}
+	return strings.ToLower(normalizeStatusB(key))
+}
+
+// nextLink finds the next page URL from the Next selector or, without one,
+// the Link header.
+func (s *JSONSource) nextLink(doc any, header http.Header, pageURL string) string {
+	if !s.next.IsZero() {
+		return resolveURL(pageURL, s.next.str(doc))
+	}
+	for _, link := range header.Values("Link") {
+		for _, part := range strings.Split(link, ",") {
+			segs := strings.Split(part, ";")
+			target := strings.Trim(strings.TrimSpace(segs[0]), "<>")
+			for _, param := range segs[1:] {
+				param = strings.ReplaceAll(strings.TrimSpace(param), `"`, "")
+				if strings.EqualFold(param, "rel=next") {
+					return resolveURL(pageURL, target)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// resolveURL makes ref absolute against base; empty stays empty.
+func resolveURL(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,406 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Request statuses in scrape_requests.
+const (
+	RequestPending = "pending"
+	RequestRunning = "running"
+	RequestDone    = "done"
+	RequestFailed  = "failed"
+)
+
+// RunRequest is an on-demand run asked for through the admin API.
+type RunRequest struct {
+	ID          int64     `json:"id"`
+	Sources     []string  `json:"sources"` // empty means all
+	RequestedBy string    `json:"requested_by,omitempty"`
+	RequestedAt time.Time `json:"requested_at"`
+	Status      string    `json:"status"`
+	RunID       int64     `json:"run_id,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	// ClaimedBy is the worker that took the request, as named in the
+	// scrape lease.
+	ClaimedBy string     `json:"claimed_by,omitempty"`
+	ClaimedAt *time.Time `json:"claimed_at,omitempty"`
+}
+
+// ScheduleEntry is when the worker fetches a source next.
+type ScheduleEntry struct {
+	Source string     `json:"source"`
+	NextAt time.Time  `json:"next_at"`
+	LastAt *time.Time `json:"last_at,omitempty"`
+}
+
+// Status is what the scraper is doing, for admins.
+type Status struct {
+	Running  *Lease          `json:"running"` // nil when idle
+	Schedule []ScheduleEntry `json:"schedule"`
+	Requests []RunRequest    `json:"requests"` // pending and running
+	LastRun  *RunReport      `json:"last_run"`
+}
+
+// Worker runs scrapes on each source's schedule and on request, one at a
+// time across every process sharing the database.
+type Worker struct {
+	DB     *sql.DB
+	Config Config
+	Holder string        // lease holder; DefaultHolder when empty
+	Poll   time.Duration // how often to look for due sources and requests
+
+	failures int // scheduled runs in a row that failed before fetching
+}
+
+// Run polls until ctx is done.
+func (w *Worker) Run(ctx context.Context) error {
+	if err := w.Config.Validate(); err != nil {
+		return err
+	}
+	if w.Holder == "" {
+		w.Holder = DefaultHolder()
+	}
+	if w.Poll <= 0 {
+		w.Poll = 5 * time.Second
+	}
+	repo := NewRepo(w.DB)
+	for {
+		if err := w.tick(ctx, repo); err != nil && ctx.Err() == nil {
+			log.Printf("[worker] %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.Poll):
+		}
+	}
+}
+
+// tick serves the oldest request, or else fetches the sources that are due.
+// Requests left running by a worker that died are queued again first.
+func (w *Worker) tick(ctx context.Context, repo *Repo) error {
+	if err := repo.requeueStale(ctx, time.Now().UTC()); err != nil {
+		return err
+	}
+	req, err := repo.claimRequest(ctx, w.Holder)
+	if err != nil {
+		return err
+	}
+	if req != nil {
+		log.Printf("[worker] running request #%d", req.ID)
+		report, err := w.run(ctx, repo, TriggerAdmin, req.Sources)
+		if errors.Is(err, ErrRunInProgress) {
+			// try again once the other run is done
+			return repo.finishRequest(ctx, req.ID, RequestPending, 0, "")
+		}
+		if err != nil {
+			return repo.finishRequest(ctx, req.ID, RequestFailed, 0, err.Error())
+		}
+		return repo.finishRequest(ctx, req.ID, RequestDone, report.ID, report.Error)
+	}
+
+	due, err := repo.dueSources(ctx, w.Config, time.Now().UTC())
+	if err != nil || len(due) == 0 {
+		return err
+	}
+	log.Printf("[worker] scheduled run for %v", due)
+	report, err := w.run(ctx, repo, TriggerSchedule, due)
+	if errors.Is(err, ErrRunInProgress) {
+		return nil
+	}
+	if report == nil && err != nil && ctx.Err() == nil {
+		// the run failed before fetching (config, database), so the due
+		// sources were not rescheduled: retry later rather than every poll
+		w.failures++
+		if perr := w.postponeDue(ctx, repo, due); perr != nil {
+			log.Printf("[worker] %v", perr)
+		}
+		return err
+	}
+	w.failures = 0
+	return err
+}
+
+// postponeDue pushes the next run of due sources back after a failed run:
+// by up to a minute after the first failure, doubling with each one in a
+// row, at most the source's own interval.
+func (w *Worker) postponeDue(ctx context.Context, repo *Repo, due []string) error {
+	schedules := w.Config.Schedules()
+	now := time.Now().UTC()
+	for _, name := range due {
+		every := max(schedules[name].EveryMinutes, 1)
+		wait := backoff(w.failures-1, int(time.Minute/time.Millisecond), every*int(time.Minute/time.Millisecond))
+		if err := repo.postpone(ctx, name, now.Add(wait)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Worker) run(ctx context.Context, repo *Repo, trigger string, sources []string) (*RunReport, error) {
+	report, err := Run(ctx, w.DB, w.Config, RunOptions{Trigger: trigger, Sources: sources, Holder: w.Holder})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[worker] run #%d %s: %d merged (%d new, %d updated)", report.ID, report.Status, report.Merged, report.New, report.Updated)
+
+	// a failed fetch waits for its next slot too, rather than retrying
+	// every poll
+	if len(sources) == 0 {
+		for _, s := range report.Sources {
+			if !s.Cached {
+				sources = append(sources, s.Name)
+			}
+		}
+	}
+	schedules := w.Config.Schedules()
+	now := time.Now().UTC()
+	for _, name := range sources {
+		if err := repo.reschedule(ctx, name, now, schedules[name].next(now)); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// next picks the time after from when the source is due again.
+func (s Schedule) next(from time.Time) time.Time {
+	next := from.Add(time.Duration(s.EveryMinutes) * time.Minute)
+	if s.JitterMinutes > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(time.Duration(s.JitterMinutes) * time.Minute))))
+	}
+	return next
+}
+
+// dueSources lists the enabled sources whose next run has come. Sources
+// never scheduled are due at once.
+func (r *Repo) dueSources(ctx context.Context, cfg Config, now time.Time) ([]string, error) {
+	entries, err := r.ListSchedule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	next := make(map[string]time.Time, len(entries))
+	for _, e := range entries {
+		next[e.Source] = e.NextAt
+	}
+	var due []string
+	for _, s := range cfg.Sources {
+		if !s.IsEnabled() {
+			continue
+		}
+		if at, ok := next[s.Name]; !ok || !at.After(now) {
+			due = append(due, s.Name)
+		}
+	}
+	return due, nil
+}
+
+func (r *Repo) reschedule(ctx context.Context, source string, last, next time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO scrape_schedule (source, next_at, last_at) VALUES (?, ?, ?)
+		ON CONFLICT(source) DO UPDATE SET next_at = excluded.next_at, last_at = excluded.last_at
+	`, source, next, last)
+	if err != nil {
+		return fmt.Errorf("reschedule %s: %w", source, err)
+	}
+	return nil
+}
+
+// postpone moves source's next run to next, keeping its last run.
+func (r *Repo) postpone(ctx context.Context, source string, next time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO scrape_schedule (source, next_at) VALUES (?, ?)
+		ON CONFLICT(source) DO UPDATE SET next_at = excluded.next_at
+	`, source, next)
+	if err != nil {
+		return fmt.Errorf("postpone %s: %w", source, err)
+	}
+	return nil
+}
+
+// ListSchedule returns when each source runs next, soonest first.
+func (r *Repo) ListSchedule(ctx context.Context) ([]ScheduleEntry, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT source, next_at, last_at FROM scrape_schedule ORDER BY next_at`)
+	if err != nil {
+		return nil, fmt.Errorf("list schedule: %w", err)
+	}
+	defer rows.Close()
+	out := make([]ScheduleEntry, 0)
+	for rows.Next() {
+		var (
+			e    ScheduleEntry
+			last sql.NullTime
+		)
+		if err := rows.Scan(&e.Source, &e.NextAt, &last); err != nil {
+			return nil, fmt.Errorf("scan schedule: %w", err)
+		}
+		if last.Valid {
+			e.LastAt = &last.Time
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list schedule: %w", err)
+	}
+	return out, nil
+}
+
+// RequestRun queues an on-demand run of sources (all when empty) for the
+// worker.
+func (r *Repo) RequestRun(ctx context.Context, sources []string, requestedBy string) (*RunRequest, error) {
+	if sources == nil {
+		sources = []string{}
+	}
+	sort.Strings(sources)
+	b, err := json.Marshal(sources)
+	if err != nil {
+		return nil, err
+	}
+	req := RunRequest{Sources: sources, RequestedBy: requestedBy, RequestedAt: time.Now().UTC(), Status: RequestPending}
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO scrape_requests (sources, requested_by, requested_at, status) VALUES (?, ?, ?, ?)
+	`, string(b), requestedBy, req.RequestedAt, req.Status)
+	if err != nil {
+		return nil, fmt.Errorf("request scrape run: %w", err)
+	}
+	req.ID, _ = res.LastInsertId()
+	return &req, nil
+}
+
+// claimRequest marks the oldest pending request as running by holder and
+// returns it, or nil when there is none.
+func (r *Repo) claimRequest(ctx context.Context, holder string) (*RunRequest, error) {
+	for {
+		req, err := scanRequest(r.DB.QueryRowContext(ctx, `
+			SELECT `+requestColumns+` FROM scrape_requests WHERE status = ? ORDER BY id LIMIT 1
+		`, RequestPending))
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now().UTC()
+		res, err := r.DB.ExecContext(ctx, `
+			UPDATE scrape_requests SET status = ?, claimed_by = ?, claimed_at = ? WHERE id = ? AND status = ?
+		`, RequestRunning, holder, now, req.ID, RequestPending)
+		if err != nil {
+			return nil, fmt.Errorf("claim scrape request: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			req.Status, req.ClaimedBy, req.ClaimedAt = RequestRunning, holder, &now
+			return req, nil
+		}
+		// another worker took it first
+	}
+}
+
+// requeueStale sets running requests back to pending when the worker that
+// claimed them no longer holds the scrape lease: it crashed or was killed
+// mid-run. A claim is taken before the lease, so claims younger than
+// leaseTTL are left alone.
+func (r *Repo) requeueStale(ctx context.Context, now time.Time) error {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE scrape_requests SET status = ?, claimed_by = NULL, claimed_at = NULL
+		WHERE status = ? AND (claimed_at IS NULL OR claimed_at < ?) AND NOT EXISTS (
+		  SELECT 1 FROM scrape_lease
+		  WHERE name = 'scrape' AND holder = scrape_requests.claimed_by AND expires_at >= ?
+		)
+	`, RequestPending, RequestRunning, now.Add(-leaseTTL), now)
+	if err != nil {
+		return fmt.Errorf("requeue stale scrape requests: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("[worker] requeued %d scrape requests left running by a stopped worker", n)
+	}
+	return nil
+}
+
+func (r *Repo) finishRequest(ctx context.Context, id int64, status string, runID int64, errText string) error {
+	var run any
+	if runID > 0 {
+		run = runID
+	}
+	if _, err := r.DB.ExecContext(ctx, `UPDATE scrape_requests SET status = ?, run_id = ?, error = ? WHERE id = ?`,
+		status, run, errText, id); err != nil {
+		return fmt.Errorf("finish scrape request %d: %w", id, err)
+	}
+	return nil
+}
+
+// Status reports the run in progress, the schedule, open requests and
+// the last finished run.
+func (r *Repo) Status(ctx context.Context) (*Status, error) {
+	var (
+		st  Status
+		err error
+	)
+	if st.Running, err = r.CurrentLease(ctx); err != nil {
+		return nil, err
+	}
+	if st.Schedule, err = r.ListSchedule(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT `+requestColumns+` FROM scrape_requests WHERE status IN (?, ?) ORDER BY id
+	`, RequestPending, RequestRunning)
+	if err != nil {
+		return nil, fmt.Errorf("list scrape requests: %w", err)
+	}
+	defer rows.Close()
+	st.Requests = make([]RunRequest, 0)
+	for rows.Next() {
+		req, err := scanRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		st.Requests = append(st.Requests, *req)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list scrape requests: %w", err)
+	}
+
+	runs, _, err := r.ListRuns(ctx, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) > 0 {
+		st.LastRun = &runs[0]
+	}
+	return &st, nil
+}
+
+const requestColumns = `id, sources, COALESCE(requested_by, ''), requested_at, status, COALESCE(run_id, 0), COALESCE(error, ''),
+	COALESCE(claimed_by, ''), claimed_at`
+
+func scanRequest(s scanner) (*RunRequest, error) {
+	var (
+		req     RunRequest
+		sources string
+		claimed sql.NullTime
+	)
+	if err := s.Scan(&req.ID, &sources, &req.RequestedBy, &req.RequestedAt, &req.Status, &req.RunID, &req.Error,
+		&req.ClaimedBy, &claimed); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan scrape request: %w", err)
+	}
+	if claimed.Valid {
+		req.ClaimedAt = &claimed.Time
+	}
+	if err := json.Unmarshal([]byte(sources), &req.Sources); err != nil {
+		return nil, fmt.Errorf("decode scrape request %d sources: %w", req.ID, err)
+	}
+	return &req, nil
+}
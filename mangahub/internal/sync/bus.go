@@ -0,0 +1,49 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+const busTopic = "sync"
+
+// busEvent is what a Hub publishes on its broker: a library event, or an
+// account whose connections must go.
+type busEvent struct {
+	Kind   string        `json:"kind"` // "event" or "remove_user"
+	Event  *LibraryEvent `json:"event,omitempty"`
+	UserID string        `json:"user_id,omitempty"`
+}
+
+// publish must not be called with h.mu held: a local broker applies ev
+// before returning.
+func (h *Hub) publish(ev busEvent) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("[sync] encode %s event: %v", ev.Kind, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.broker.Publish(ctx, busTopic, payload); err != nil {
+		log.Printf("[sync] publish %s event: %v", ev.Kind, err)
+	}
+}
+
+func (h *Hub) receive(seq int64, payload []byte) {
+	var ev busEvent
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		log.Printf("[sync] decode bus event: %v", err)
+		return
+	}
+	switch {
+	case ev.Kind == "event" && ev.Event != nil:
+		h.publishLocal(*ev.Event, seq)
+	case ev.Kind == "remove_user":
+		h.removeUserLocal(ev.UserID)
+	default:
+		log.Printf("[sync] unknown bus event %q", ev.Kind)
+	}
+}
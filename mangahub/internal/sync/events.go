@@ -9,4 +9,5 @@ type LibraryEvent struct {
 	CurrentChapter int       `json:"current_chapter,omitempty"`
 	Status         string    `json:"status,omitempty"`
 	At             time.Time `json:"at"`
+	Cursor         string    `json:"cursor,omitempty"` // set by Hub.Publish; pass back to resume
 }
@@ -9,12 +9,26 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"mangahub/internal/bus"
 )
 
 type Hub struct {
 	mu        sync.Mutex
 	clients   map[net.Conn]struct{}
-	wsClients map[*websocket.Conn]struct{}
+	wsClients map[*websocket.Conn]string // value: user id, "" when anonymous
+
+	// event log for resumable subscribers (see subscribe.go); events are
+	// numbered by the broker, so cursors resume on any replica
+	stream      string
+	floor       int64 // events at or below it are not in the backlog
+	backlog     []backlogEntry
+	backlogSize int
+	subs        map[*Subscription]struct{}
+
+	// broker carries published events and account removals to every
+	// replica sharing it; each fans out to its own clients
+	broker bus.Broker
 }
 
 type Stats struct {
@@ -22,11 +36,25 @@ type Stats struct {
 	WSClients  int `json:"ws_clients"`
 }
 
-func NewHub() *Hub {
-	return &Hub{
+// NewHub returns a Hub subscribed to broker; nil keeps it to this process.
+func NewHub(broker bus.Broker) *Hub {
+	if broker == nil {
+		broker = bus.NewMemory()
+	}
+	stream, start := broker.Position()
+	h := &Hub{
 		clients:   make(map[net.Conn]struct{}),
-		wsClients: make(map[*websocket.Conn]struct{}),
+		wsClients: make(map[*websocket.Conn]string),
+
+		stream:      stream,
+		floor:       start,
+		backlogSize: defaultBacklogSize,
+		subs:        make(map[*Subscription]struct{}),
+
+		broker: broker,
 	}
+	broker.Subscribe(busTopic, h.receive)
+	return h
 }
 
 func (h *Hub) Add(conn net.Conn) {
@@ -42,9 +70,9 @@ func (h *Hub) Remove(conn net.Conn) {
 	_ = conn.Close()
 }
 
-func (h *Hub) AddWS(ws *websocket.Conn) {
+func (h *Hub) AddWS(ws *websocket.Conn, userID string) {
 	h.mu.Lock()
-	h.wsClients[ws] = struct{}{}
+	h.wsClients[ws] = userID
 	h.mu.Unlock()
 }
 
@@ -55,6 +83,33 @@ func (h *Hub) RemoveWS(ws *websocket.Conn) {
 	_ = ws.Close()
 }
 
+// RemoveUser drops every WebSocket connection and subscriber registered for
+// userID, on every replica.
+func (h *Hub) RemoveUser(userID string) {
+	if userID == "" {
+		return
+	}
+	h.publish(busEvent{Kind: "remove_user", UserID: userID})
+}
+
+func (h *Hub) removeUserLocal(userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ws, uid := range h.wsClients {
+		if uid == userID {
+			_ = ws.Close()
+			delete(h.wsClients, ws)
+		}
+	}
+	for sub := range h.subs {
+		if sub.UserID == userID {
+			sub.err = ErrUserRemoved
+			close(sub.ch)
+			delete(h.subs, sub)
+		}
+	}
+}
+
 func (h *Hub) BroadcastJSON(v any) {
 	b, err := json.Marshal(v)
 	if err != nil {
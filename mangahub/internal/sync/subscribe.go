@@ -0,0 +1,163 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultBacklogSize = 1024
+	subscriberBuffer   = 64
+)
+
+// ErrCursorExpired means the requested cursor is older than the retained
+// backlog (or from another bus); the client must resync from scratch.
+var ErrCursorExpired = errors.New("sync: cursor expired")
+
+// Why a Subscription's channel was closed (see Subscription.Err).
+var (
+	ErrFellBehind  = errors.New("sync: subscriber fell behind")
+	ErrUserRemoved = errors.New("sync: user removed")
+)
+
+// Subscription receives published events for one user (all users when UserID
+// is empty). C is closed when the subscriber falls too far behind or is
+// removed; Err says which.
+type Subscription struct {
+	UserID string
+	C      <-chan LibraryEvent
+
+	ch    chan LibraryEvent
+	after int64 // events up to this number were seen before subscribing
+	err   error // set before ch is closed
+}
+
+// Err reports why C was closed: ErrFellBehind, after which the subscriber
+// may resume from the last seen cursor, or ErrUserRemoved when the account
+// was deleted or its tokens revoked (logout, password change; see
+// auth.Service.OnRevoke). It is nil after Unsubscribe, and before C is
+// closed.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+type backlogEntry struct {
+	seq int64
+	ev  LibraryEvent
+}
+
+// Publish sends ev to every replica sharing the hub's broker. Each stamps it
+// with a cursor from the bus message's sequence number, so every replica
+// gives it the same one, keeps it for resuming subscribers, hands it to
+// in-process subscribers and broadcasts it to TCP/WebSocket clients.
+func (h *Hub) Publish(ev LibraryEvent) {
+	h.publish(busEvent{Kind: "event", Event: &ev})
+}
+
+// publishLocal fans out ev, numbered seq by the broker. An event the
+// broker could not number (seq 0) only reached this replica: it is sent
+// live without a cursor and not kept.
+func (h *Hub) publishLocal(ev LibraryEvent, seq int64) {
+	h.mu.Lock()
+	if seq > 0 {
+		ev.Cursor = h.cursor(seq)
+		h.backlog = append(h.backlog, backlogEntry{seq: seq, ev: ev})
+		if n := len(h.backlog) - h.backlogSize; n > 0 {
+			for _, e := range h.backlog[:n] {
+				h.floor = max(h.floor, e.seq)
+			}
+			h.backlog = h.backlog[n:]
+		}
+	}
+
+	for sub := range h.subs {
+		if sub.UserID != "" && sub.UserID != ev.UserID {
+			continue
+		}
+		if seq > 0 && seq <= sub.after {
+			// delivered by another replica before the subscriber moved here
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			// slow consumer: drop it rather than block publishers
+			sub.err = ErrFellBehind
+			close(sub.ch)
+			delete(h.subs, sub)
+		}
+	}
+	h.mu.Unlock()
+
+	go h.BroadcastJSON(ev)
+}
+
+// Subscribe registers a subscriber and returns the retained events after
+// cursor that it missed. An empty cursor starts from now.
+func (h *Hub) Subscribe(userID, cursor string) (*Subscription, []LibraryEvent, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var (
+		replay []LibraryEvent
+		after  int64
+	)
+	if cursor != "" {
+		var err error
+		if after, err = h.parseCursor(cursor); err != nil {
+			return nil, nil, err
+		}
+		if after < h.floor {
+			return nil, nil, ErrCursorExpired
+		}
+		for _, e := range h.backlog {
+			if e.seq <= after {
+				continue
+			}
+			if userID != "" && e.ev.UserID != userID {
+				continue
+			}
+			replay = append(replay, e.ev)
+		}
+	}
+
+	ch := make(chan LibraryEvent, subscriberBuffer)
+	sub := &Subscription{UserID: userID, C: ch, ch: ch, after: after}
+	h.subs[sub] = struct{}{}
+	return sub, replay, nil
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		close(sub.ch)
+		delete(h.subs, sub)
+	}
+}
+
+// cursors are "<stream>-<seq>": the broker's stream and the bus message's
+// number. Every replica on the same bus reads them alike; cursors from
+// another bus (a restarted memory bus, a replaced database) are recognised
+// as expired instead of misread. A cursor ahead of this replica is one it
+// has not polled up to yet.
+func (h *Hub) cursor(seq int64) string {
+	return fmt.Sprintf("%s-%d", h.stream, seq)
+}
+
+func (h *Hub) parseCursor(cursor string) (int64, error) {
+	i := strings.LastIndexByte(cursor, '-')
+	if i < 0 {
+		return 0, fmt.Errorf("sync: malformed cursor %q", cursor)
+	}
+	n, err := strconv.ParseInt(cursor[i+1:], 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("sync: malformed cursor %q", cursor)
+	}
+	if cursor[:i] != h.stream {
+		return 0, ErrCursorExpired
+	}
+	return n, nil
+}
@@ -16,14 +16,21 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-func WSHandler(hub *Hub) gin.HandlerFunc {
+// WSHandler upgrades to a sync WebSocket. identify may be nil; when set it ties
+// the connection to a user so it can be dropped when the account goes away.
+func WSHandler(hub *Hub, identify func(r *http.Request) string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		userID := ""
+		if identify != nil {
+			userID = identify(c.Request)
+		}
+
 		ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
 			return
 		}
 
-		hub.AddWS(ws)
+		hub.AddWS(ws, userID)
 		log.Println("[ws] client connected")
 
 		// Optional welcome message
@@ -6,6 +6,34 @@ import (
 	"os"
 )
 
+// addedColumns lists columns introduced after their table first shipped.
+// CREATE TABLE IF NOT EXISTS leaves existing tables untouched, so databases
+// created by an older schema.sql get these via ALTER TABLE.
+var addedColumns = []struct {
+	Table  string
+	Column string
+	Def    string
+}{
+	{"users", "deleted_at", "TIMESTAMP"},
+	{"chat_messages", "user_id", "TEXT REFERENCES users(id) ON DELETE CASCADE"},
+	{"chat_messages", "spoiler_chapter", "INTEGER"},
+	{"users", "role", "TEXT NOT NULL DEFAULT 'user'"},
+	{"chat_messages", "type", "TEXT NOT NULL DEFAULT 'message'"},
+	{"chat_rooms", "owner_id", "TEXT REFERENCES users(id) ON DELETE SET NULL"},
+	{"chat_rooms", "slow_mode_seconds", "INTEGER NOT NULL DEFAULT 0"},
+	{"scrape_runs", "queued", "INTEGER NOT NULL DEFAULT 0"},
+	{"scrape_runs", "trigger", "TEXT"},
+	{"manga", "delisted_at", "TIMESTAMP"},
+	{"manga_sources", "first_seen_at", "TIMESTAMP"},
+	{"manga_sources", "last_seen_at", "TIMESTAMP"},
+	{"manga_sources", "missed_runs", "INTEGER NOT NULL DEFAULT 0"},
+	{"scrape_runs", "delisted", "INTEGER NOT NULL DEFAULT 0"},
+	{"scrape_runs", "relisted", "INTEGER NOT NULL DEFAULT 0"},
+	{"scrape_requests", "claimed_by", "TEXT"},
+	{"scrape_requests", "claimed_at", "TIMESTAMP"},
+	{"scrape_lease", "token", "TEXT"},
+}
+
 func Migrate(db *sql.DB) error {
 	b, err := os.ReadFile("docs/schema.sql")
 	if err != nil {
@@ -15,5 +43,45 @@ func Migrate(db *sql.DB) error {
 	if _, err := db.Exec(string(b)); err != nil {
 		return fmt.Errorf("apply schema: %w", err)
 	}
+
+	for _, c := range addedColumns {
+		if err := ensureColumn(db, c.Table, c.Column, c.Def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ensureColumn(db *sql.DB, table, column, def string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("table info %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return fmt.Errorf("scan table info %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("table info %s: %w", table, err)
+	}
+	rows.Close()
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, def)); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
+	}
 	return nil
 }
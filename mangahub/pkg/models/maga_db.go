@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type MangaDB struct {
 	ID            string   `json:"id"`
 	Title         string   `json:"title"`
@@ -9,4 +11,25 @@ type MangaDB struct {
 	TotalChapters int      `json:"total_chapters,omitempty"`
 	Description   string   `json:"description,omitempty"`
 	CoverURL      string   `json:"cover_url,omitempty"`
+	// Cover is the mirrored copy of CoverURL, served by the api-server at
+	// /covers/:hash (add ?size=small|medium|large for a thumbnail). It is
+	// empty until the scraper has downloaded the image.
+	Cover string `json:"cover,omitempty"`
+	// DelistedAt is set once no source returns the title any more. Such
+	// titles are left out of listings unless asked for, but stay
+	// reachable by id.
+	DelistedAt *time.Time `json:"delisted_at,omitempty"`
+
+	// Provenance is only filled in on request (GET /manga/:id?provenance=true).
+	Provenance map[string]FieldSource `json:"provenance,omitempty"`
+}
+
+// ManualSource is the FieldSource of a field set through the admin API.
+const ManualSource = "manual"
+
+// FieldSource says which scraper source a field's value came from and
+// since when it has held that value.
+type FieldSource struct {
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
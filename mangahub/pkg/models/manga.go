@@ -17,4 +17,5 @@ type MangaCanonical struct {
 	CoverURL      string            `json:"cover_url,omitempty"`  // cover image URL (if any)
 	Year          int               `json:"year,omitempty"`       // publication start year (optional)
 	SourceIDs     map[string]string `json:"source_ids,omitempty"` // e.g. {"source_a": "...", "source_b": "..."}
+	Provenance    map[string]string `json:"provenance,omitempty"` // field -> source its value came from
 }
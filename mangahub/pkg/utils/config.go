@@ -1,25 +1,119 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// DefaultJWTSecret is the well-known development secret. Servers refuse to
+// start with it unless dev mode is enabled.
+const DefaultJWTSecret = "dev-secret-change-me"
+
 type AuthConfig struct {
 	JWTSecret   string
 	JWTIssuer   string
 	JWTDuration time.Duration
+
+	// JWTAlg selects the signing algorithm: HS256 (shared secret),
+	// RS256 or EdDSA (asymmetric keys identified by kid).
+	JWTAlg string
+	// JWTKeysPath is the keyset file used by asymmetric signers.
+	JWTKeysPath string
+	// JWTRotateEvery is how long a signing key stays active before a new one
+	// is generated. Zero disables scheduled rotation.
+	JWTRotateEvery time.Duration
+	// JWKSURL lets verify-only services fetch public keys instead of holding
+	// the keyset file.
+	JWKSURL string
+
+	// AdminEmails are promoted to admin at startup, to bootstrap the first
+	// admin account.
+	AdminEmails []string
+
+	DevMode bool
 }
 
 type GrpcConfig struct {
 	Addr string
+	// InProcess makes the api-server serve gRPC itself, sharing its sync hub
+	// so SyncService.Subscribe sees library events.
+	InProcess bool
+	// Reflection registers the server reflection service for grpcurl & co.
+	Reflection bool
+
+	MaxRecvMsgBytes int
+	MaxSendMsgBytes int
+
+	// KeepaliveTime is how long a connection may sit idle before the server
+	// pings it; KeepaliveTimeout is how long it waits for the ack.
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+	// MinPingInterval is the most often clients may ping; faster clients are
+	// disconnected.
+	MinPingInterval time.Duration
+
+	// ShutdownTimeout bounds GracefulStop before open streams are cut off.
+	ShutdownTimeout time.Duration
+}
+
+type ChatConfig struct {
+	// RoomIdle is how long an empty room stays in memory before eviction.
+	RoomIdle time.Duration
+	// AllowAnonymous lets connections without a token read rooms. They can
+	// never post.
+	AllowAnonymous bool
+
+	// BlockedWords are masked in messages, and links too with BlockLinks.
+	// FilterReject refuses such messages instead.
+	BlockedWords []string
+	BlockLinks   bool
+	FilterReject bool
+}
+
+// ListenConfig holds the api-server's listen addresses, so several replicas
+// can share a host.
+type ListenConfig struct {
+	HTTPAddr string
+	TCPAddr  string // sync
+	UDPAddr  string // notify
+}
+
+// BusConfig picks the message bus the chat and sync hubs share with other
+// api-server replicas.
+type BusConfig struct {
+	// Backend is "memory" (one process) or "sqlite" (every process using
+	// the same database file).
+	Backend string
+	// Path is a database file for the sqlite backend; empty uses the main
+	// database.
+	Path         string
+	PollInterval time.Duration
+	// Retention is how long published messages are kept for slow pollers.
+	Retention time.Duration
+}
+
+// CoversConfig says where mirrored cover images are kept. The scraper
+// writes there and the api-server reads, so both need the same directory.
+type CoversConfig struct {
+	Dir string
+}
+
+type AccountConfig struct {
+	// DeletePolicy is "delete" (remove everything) or "anonymize" (keep
+	// reviews under a scrubbed placeholder account).
+	DeletePolicy string
 }
 
 func LoadAuthConfig() AuthConfig {
 	secret := os.Getenv("MANGAHUB_JWT_SECRET")
 	if secret == "" {
 		// dev default (change for demo / production)
-		secret = "dev-secret-change-me"
+		secret = DefaultJWTSecret
 	}
 
 	issuer := os.Getenv("MANGAHUB_JWT_ISSUER")
@@ -27,29 +121,165 @@ func LoadAuthConfig() AuthConfig {
 		issuer = "mangahub"
 	}
 
-	ttl := os.Getenv("MANGAHUB_JWT_TTL_HOURS")
-	if ttl == "" {
-		return AuthConfig{
-			JWTSecret:   secret,
-			JWTIssuer:   issuer,
-			JWTDuration: 24 * time.Hour,
+	// simple parse: hours
+	// if parse fails, fallback to 24h
+	duration := 24 * time.Hour
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("MANGAHUB_JWT_TTL_HOURS"))); err == nil && n > 0 {
+		duration = time.Duration(n) * time.Hour
+	}
+
+	alg := strings.TrimSpace(os.Getenv("MANGAHUB_JWT_ALG"))
+	if alg == "" {
+		alg = "HS256"
+	}
+
+	keysPath := os.Getenv("MANGAHUB_JWT_KEYS_PATH")
+	if keysPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil || home == "" {
+			home = "."
+		}
+		keysPath = filepath.Join(home, ".mangahub", "jwt-keys.json")
+	}
+
+	rotateEvery := 30 * 24 * time.Hour
+	if raw := strings.TrimSpace(os.Getenv("MANGAHUB_JWT_ROTATE_HOURS")); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n >= 0 {
+			rotateEvery = time.Duration(n) * time.Hour
 		}
 	}
 
-	// simple parse: hours
-	// if parse fails, fallback to 24h
 	return AuthConfig{
-		JWTSecret:   secret,
-		JWTIssuer:   issuer,
-		JWTDuration: 24 * time.Hour,
+		JWTSecret:      secret,
+		JWTIssuer:      issuer,
+		JWTDuration:    duration,
+		JWTAlg:         alg,
+		JWTKeysPath:    keysPath,
+		JWTRotateEvery: rotateEvery,
+		JWKSURL:        strings.TrimSpace(os.Getenv("MANGAHUB_JWT_JWKS_URL")),
+		AdminEmails:    envList("MANGAHUB_ADMIN_EMAILS"),
+		DevMode:        envBool("MANGAHUB_DEV_MODE"),
 	}
 }
 
+// Validate rejects configurations that are unsafe outside of dev mode.
+func (c AuthConfig) Validate() error {
+	switch c.JWTAlg {
+	case "HS256":
+		if c.JWTSecret == DefaultJWTSecret && !c.DevMode {
+			return errors.New("MANGAHUB_JWT_SECRET is the default dev secret; set a real secret or MANGAHUB_DEV_MODE=1")
+		}
+		if len(c.JWTSecret) < 16 && !c.DevMode {
+			return errors.New("MANGAHUB_JWT_SECRET must be at least 16 bytes")
+		}
+	case "RS256", "EdDSA":
+		if c.JWKSURL == "" && c.JWTKeysPath == "" {
+			return errors.New("asymmetric JWT signing requires MANGAHUB_JWT_KEYS_PATH or MANGAHUB_JWT_JWKS_URL")
+		}
+	default:
+		return fmt.Errorf("unsupported MANGAHUB_JWT_ALG %q (use HS256, RS256 or EdDSA)", c.JWTAlg)
+	}
+	return nil
+}
+
 func LoadGrpcConfig() GrpcConfig {
 	addr := os.Getenv("MANGAHUB_GRPC_ADDR")
 	if addr == "" {
 		addr = ":9090"
 	}
 
-	return GrpcConfig{Addr: addr}
+	return GrpcConfig{
+		Addr:             addr,
+		InProcess:        envBool("MANGAHUB_GRPC_IN_PROCESS"),
+		Reflection:       envBool("MANGAHUB_GRPC_REFLECTION"),
+		MaxRecvMsgBytes:  envInt("MANGAHUB_GRPC_MAX_RECV_MB", 4) << 20,
+		MaxSendMsgBytes:  envInt("MANGAHUB_GRPC_MAX_SEND_MB", 4) << 20,
+		KeepaliveTime:    time.Duration(envInt("MANGAHUB_GRPC_KEEPALIVE_SECONDS", 60)) * time.Second,
+		KeepaliveTimeout: time.Duration(envInt("MANGAHUB_GRPC_KEEPALIVE_TIMEOUT_SECONDS", 20)) * time.Second,
+		MinPingInterval:  time.Duration(envInt("MANGAHUB_GRPC_MIN_PING_SECONDS", 10)) * time.Second,
+		ShutdownTimeout:  time.Duration(envInt("MANGAHUB_GRPC_SHUTDOWN_SECONDS", 10)) * time.Second,
+	}
+}
+
+func LoadChatConfig() ChatConfig {
+	return ChatConfig{
+		RoomIdle:       time.Duration(envInt("MANGAHUB_CHAT_ROOM_IDLE_MINUTES", 10)) * time.Minute,
+		AllowAnonymous: envBool("MANGAHUB_CHAT_ANONYMOUS_READ"),
+		BlockedWords:   envList("MANGAHUB_CHAT_BLOCKED_WORDS"),
+		BlockLinks:     envBool("MANGAHUB_CHAT_BLOCK_LINKS"),
+		FilterReject:   strings.EqualFold(strings.TrimSpace(os.Getenv("MANGAHUB_CHAT_FILTER_MODE")), "reject"),
+	}
+}
+
+func LoadListenConfig() ListenConfig {
+	return ListenConfig{
+		HTTPAddr: envString("MANGAHUB_HTTP_ADDR", ":8080"),
+		TCPAddr:  envString("MANGAHUB_TCP_ADDR", ":7070"),
+		UDPAddr:  envString("MANGAHUB_UDP_ADDR", ":6060"),
+	}
+}
+
+func LoadBusConfig() BusConfig {
+	return BusConfig{
+		Backend:      strings.ToLower(strings.TrimSpace(os.Getenv("MANGAHUB_BUS"))),
+		Path:         strings.TrimSpace(os.Getenv("MANGAHUB_BUS_PATH")),
+		PollInterval: time.Duration(envInt("MANGAHUB_BUS_POLL_MS", 100)) * time.Millisecond,
+		Retention:    time.Duration(envInt("MANGAHUB_BUS_RETENTION_SECONDS", 60)) * time.Second,
+	}
+}
+
+func LoadCoversConfig() CoversConfig {
+	dir := strings.TrimSpace(os.Getenv("MANGAHUB_COVERS_DIR"))
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil || home == "" {
+			home = "."
+		}
+		dir = filepath.Join(home, ".mangahub", "covers")
+	}
+	return CoversConfig{Dir: dir}
+}
+
+func LoadAccountConfig() AccountConfig {
+	policy := strings.ToLower(strings.TrimSpace(os.Getenv("MANGAHUB_ACCOUNT_DELETE_POLICY")))
+	if policy != "anonymize" {
+		policy = "delete"
+	}
+	return AccountConfig{DeletePolicy: policy}
+}
+
+// envInt parses a positive integer, falling back to def when unset or invalid.
+func envInt(key string, def int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+func envString(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
+
+// envList splits a comma-separated variable, dropping empty items.
+func envList(key string) []string {
+	var out []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func envBool(key string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+	case "1", "true", "yes", "on":
+		return true
+	default:
+		return false
+	}
 }
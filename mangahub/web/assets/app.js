@@ -410,11 +410,13 @@ function disconnectSocket(socket, label, log) {
 document.getElementById("chat-connect").addEventListener("click", () => {
   disconnectSocket(state.chatSocket, "Chat", chatLog);
   const room = document.getElementById("chat-room").value || "general";
-  const user = document.getElementById("chat-user").value || "anon";
-  const url = wsURL("/ws/chat", { room, user });
-  const socket = new WebSocket(url);
+  const url = wsURL("/ws/chat", { room });
+  // browsers cannot set headers on a handshake, so the token rides along as
+  // a subprotocol; without one the server may admit us read-only
+  const socket = state.token ? new WebSocket(url, ["bearer", state.token]) : new WebSocket(url);
   state.chatSocket = socket;
-  logCommand(`wscat -c ${shellQuote(url)}`);
+  const auth = state.token ? ` -H ${shellQuote(`Authorization: Bearer ${state.token}`)}` : "";
+  logCommand(`wscat -c ${shellQuote(url)}${auth}`);
   appendLog(chatLog, `Connecting to ${url}`);
   socket.addEventListener("open", () => appendLog(chatLog, "Chat connected"));
   socket.addEventListener("message", (event) => appendLog(chatLog, event.data));